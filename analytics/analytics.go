@@ -0,0 +1,228 @@
+// Package analytics aggregates card events into spending totals by
+// category, merchant, country or calendar period.
+//
+// Amounts are summed exactly in each event's billing currency; events in
+// different billing currencies land in separate groups. Events are counted
+// as gnosispay.CardEvent.SignedAmount counts them: payments add to
+// spending, refunds and reversal events are subtracted from it, and
+// declined and reversed payments are left out. A reversal event whose
+// payment already shows the reversal is left out too (see
+// gnosispay.CardEvent.DoubleCounted). Pending and cleared events are
+// totalled separately.
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+// Unknown is the group key used when an event lacks the grouped field.
+const Unknown = "(unknown)"
+
+// Totals sums one group of events in a single currency.
+type Totals struct {
+	Spent    gnosispay.Money `json:"spent"`    // Payments.
+	Refunded gnosispay.Money `json:"refunded"` // Refunds and reversal events.
+	Count    int             `json:"count"`    // Events included.
+}
+
+// Net returns spending minus refunds.
+func (t Totals) Net() gnosispay.Money {
+	net, _ := t.Spent.Sub(t.Refunded)
+	return net
+}
+
+// Group holds the totals of the events sharing a key and billing currency.
+type Group struct {
+	Key      string `json:"key"`
+	Currency string `json:"currency"`
+	Cleared  Totals `json:"cleared"`
+	Pending  Totals `json:"pending"`
+}
+
+// Total returns the cleared and pending totals combined.
+func (g Group) Total() Totals {
+	spent, _ := g.Cleared.Spent.Add(g.Pending.Spent)
+	refunded, _ := g.Cleared.Refunded.Add(g.Pending.Refunded)
+	return Totals{Spent: spent, Refunded: refunded, Count: g.Cleared.Count + g.Pending.Count}
+}
+
+// Report is the result of Aggregate.
+type Report struct {
+	Groups []Group `json:"groups"`
+
+	// Events left out of the totals.
+	Declined int `json:"declined"`
+	Reversed int `json:"reversed"` // Reversed payments and DoubleCounted reversal events.
+	Invalid  int `json:"invalid"`  // Missing billing currency or bad amount.
+}
+
+// KeyFunc returns the group an event belongs to.
+type KeyFunc func(gnosispay.CardEvent) string
+
+// Aggregate sums events by key. Groups are ordered by key, then currency.
+func Aggregate(events []gnosispay.CardEvent, key KeyFunc) *Report {
+	r := &Report{}
+	index := map[[2]string]int{}
+	for _, e := range events {
+		switch {
+		case e.Declined():
+			r.Declined++
+			continue
+		case !e.Counts(), e.DoubleCounted(events):
+			r.Reversed++
+			continue
+		}
+		m, err := e.SignedAmount()
+		if err != nil {
+			r.Invalid++
+			continue
+		}
+		refund := m.Sign() > 0
+		m = m.Abs()
+
+		k := [2]string{key(e), m.Currency.Code}
+		i, ok := index[k]
+		if !ok {
+			zero := gnosispay.NewMoney(0, m.Currency)
+			empty := Totals{Spent: zero, Refunded: zero}
+			r.Groups = append(r.Groups, Group{Key: k[0], Currency: k[1], Cleared: empty, Pending: empty})
+			i = len(r.Groups) - 1
+			index[k] = i
+		}
+		t := &r.Groups[i].Cleared
+		if e.IsPending {
+			t = &r.Groups[i].Pending
+		}
+		// Groups are keyed by currency code; a differing precision for the
+		// same code means the amount cannot be trusted.
+		var sum gnosispay.Money
+		if refund {
+			sum, err = t.Refunded.Add(m)
+		} else {
+			sum, err = t.Spent.Add(m)
+		}
+		if err != nil {
+			r.Invalid++
+			continue
+		}
+		if refund {
+			t.Refunded = sum
+		} else {
+			t.Spent = sum
+		}
+		t.Count++
+	}
+	sort.SliceStable(r.Groups, func(i, j int) bool {
+		a, b := r.Groups[i], r.Groups[j]
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Currency < b.Currency
+	})
+	return r
+}
+
+// SortByNet orders the groups by combined net spending, largest first.
+// Groups in different currencies are compared by their minor-unit amounts
+// only when the currencies match; otherwise currency order decides.
+func (r *Report) SortByNet() {
+	sort.SliceStable(r.Groups, func(i, j int) bool {
+		a, b := r.Groups[i].Total().Net(), r.Groups[j].Total().Net()
+		if c, err := a.Cmp(b); err == nil {
+			return c > 0
+		}
+		return a.Currency.Code < b.Currency.Code
+	})
+}
+
+// Spending returns what e adds to spending in its billing currency: the
+// negated SignedAmount, so positive for payments and negative for refunds
+// and reversal events. It reports false for events that did not move
+// money and events without a valid billing amount. When summing a slice of
+// events, skip those that are DoubleCounted.
+func Spending(e gnosispay.CardEvent) (gnosispay.Money, bool) {
+	if !e.Counts() {
+		return gnosispay.Money{}, false
+	}
+	m, err := e.SignedAmount()
+	if err != nil {
+		return gnosispay.Money{}, false
+	}
+	return m.Neg(), true
+}
+
+// ByCategory groups events by the spending category of their MCC, such as
+// groceries or travel. See package mcc for the mapping and its overrides.
+func ByCategory(e gnosispay.CardEvent) string {
+	return string(e.Category())
+}
+
+// ByCategoryGroup groups events by the ISO 18245 category group of their
+// MCC.
+func ByCategoryGroup(e gnosispay.CardEvent) string {
+	return CategoryGroup(e.Mcc)
+}
+
+// ByMerchant groups events by merchant name, ignoring case and repeated
+// spaces.
+func ByMerchant(e gnosispay.CardEvent) string {
+	if e.Merchant == nil {
+		return Unknown
+	}
+	name := strings.Join(strings.Fields(e.Merchant.Name), " ")
+	if name == "" {
+		return Unknown
+	}
+	return strings.ToUpper(name)
+}
+
+// ByCountry groups events by the merchant's ISO 3166 alpha-2 country,
+// falling back to the event's country.
+func ByCountry(e gnosispay.CardEvent) string {
+	if e.Merchant != nil && e.Merchant.Country != nil && e.Merchant.Country.Alpha2 != "" {
+		return strings.ToUpper(e.Merchant.Country.Alpha2)
+	}
+	if e.Country != nil && e.Country.Alpha2 != "" {
+		return strings.ToUpper(e.Country.Alpha2)
+	}
+	return Unknown
+}
+
+// Period is a calendar bucket for ByPeriod.
+type Period string
+
+const (
+	Day   Period = "day"   // Keys like "2025-01-31".
+	Week  Period = "week"  // ISO weeks, keys like "2025-W05".
+	Month Period = "month" // Keys like "2025-01".
+)
+
+// ByPeriod groups events by the period containing their creation time in
+// loc; a nil loc means UTC. Keys sort chronologically.
+func ByPeriod(p Period, loc *time.Location) KeyFunc {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return func(e gnosispay.CardEvent) string {
+		t := e.CreatedAt.In(loc)
+		switch p {
+		case Week:
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%04d-W%02d", year, week)
+		case Month:
+			return t.Format("2006-01")
+		default:
+			return t.Format(time.DateOnly)
+		}
+	}
+}
+
+// All puts every event in one group per currency.
+func All(gnosispay.CardEvent) string {
+	return "all"
+}
@@ -0,0 +1,171 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/internal/eventtest"
+)
+
+// day returns a time late on day d of January 2025, so that time zones
+// east of UTC move it to the next day.
+func day(d int) time.Time {
+	return time.Date(2025, 1, d, 23, 30, 0, 0, time.UTC)
+}
+
+func testEvents() []gnosispay.CardEvent {
+	return []gnosispay.CardEvent{
+		eventtest.Payment(day(1), "2500", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE"), eventtest.Country("DE")),
+		eventtest.Payment(day(2), "1000", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("rewe "), eventtest.Country("DE"), eventtest.Pending()),
+		eventtest.Refund(day(3), "500", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE"), eventtest.Country("DE")),
+		eventtest.Payment(day(3), "750", eventtest.GBP, eventtest.MCC("5812"), eventtest.Merchant("Pret A Manger"), eventtest.Country("GB")),
+		eventtest.Payment(day(5), "300", eventtest.EUR, eventtest.Status(gnosispay.CardEventInsufficientFunds), eventtest.MCC("5812"), eventtest.Merchant("Cafe"), eventtest.Country("DE")),
+		eventtest.Payment(day(6), "300", eventtest.EUR, eventtest.Status(gnosispay.CardEventReversed), eventtest.MCC("4111"), eventtest.Merchant("BVG"), eventtest.Country("DE")),
+		eventtest.Reversal(day(6), "300", eventtest.EUR, eventtest.MCC("4111"), eventtest.Merchant("BVG"), eventtest.Country("DE")),
+		eventtest.Payment(day(7), "1500", eventtest.EUR, eventtest.Status(gnosispay.CardEventPartialReversal), eventtest.MCC("4111"), eventtest.Merchant("DB"), eventtest.Country("DE")),
+		{Kind: "Payment", BillingAmount: "100"}, // No currency.
+	}
+}
+
+func TestAggregate_ByCategoryGroup(t *testing.T) {
+	r := Aggregate(testEvents(), ByCategoryGroup)
+
+	if r.Declined != 1 || r.Reversed != 2 || r.Invalid != 1 {
+		t.Errorf("skipped = declined %d, reversed %d, invalid %d; want 1, 2, 1", r.Declined, r.Reversed, r.Invalid)
+	}
+
+	want := []struct {
+		key, currency           string
+		cleared, pending, spent string
+	}{
+		{MiscellaneousStores, "GBP", "7.50 GBP", "0.00 GBP", "7.50 GBP"},
+		{RetailOutlets, "EUR", "20.00 EUR", "10.00 EUR", "35.00 EUR"},
+		{Transportation, "EUR", "15.00 EUR", "0.00 EUR", "15.00 EUR"},
+	}
+	if len(r.Groups) != len(want) {
+		t.Fatalf("got %d groups, want %d: %+v", len(r.Groups), len(want), r.Groups)
+	}
+	for i, w := range want {
+		g := r.Groups[i]
+		if g.Key != w.key || g.Currency != w.currency {
+			t.Errorf("group %d = %s/%s, want %s/%s", i, g.Key, g.Currency, w.key, w.currency)
+		}
+		if got := g.Cleared.Net().String(); got != w.cleared {
+			t.Errorf("%s cleared net = %s, want %s", g.Key, got, w.cleared)
+		}
+		if got := g.Pending.Net().String(); got != w.pending {
+			t.Errorf("%s pending net = %s, want %s", g.Key, got, w.pending)
+		}
+		if got := g.Total().Spent.String(); got != w.spent {
+			t.Errorf("%s total spent = %s, want %s", g.Key, got, w.spent)
+		}
+	}
+	if retail := r.Groups[1]; retail.Cleared.Refunded.String() != "5.00 EUR" || retail.Total().Count != 3 {
+		t.Errorf("retail refunded = %s, count = %d", retail.Cleared.Refunded, retail.Total().Count)
+	}
+}
+
+func TestAggregate_reversalEvent(t *testing.T) {
+	// The payment keeps its Approved status; only the reversal event tells
+	// that it was undone.
+	events := []gnosispay.CardEvent{
+		eventtest.Payment(day(1), "300", eventtest.EUR, eventtest.MCC("4111"), eventtest.Merchant("BVG"), eventtest.Country("DE")),
+		eventtest.Reversal(day(2), "300", eventtest.EUR, eventtest.MCC("4111"), eventtest.Merchant("BVG"), eventtest.Country("DE")),
+		eventtest.Payment(day(3), "200", eventtest.EUR, eventtest.MCC("4111"), eventtest.Merchant("BVG"), eventtest.Country("DE")),
+	}
+	r := Aggregate(events, All)
+	if len(r.Groups) != 1 || r.Reversed != 0 {
+		t.Fatalf("report = %+v", r)
+	}
+	if got := r.Groups[0].Total(); got.Net().String() != "2.00 EUR" || got.Refunded.String() != "3.00 EUR" {
+		t.Errorf("total = net %s, refunded %s; want 2.00 EUR, 3.00 EUR", got.Net(), got.Refunded)
+	}
+}
+
+func TestAggregate_keys(t *testing.T) {
+	keys := func(r *Report) []string {
+		var out []string
+		for _, g := range r.Groups {
+			out = append(out, g.Key+"/"+g.Currency)
+		}
+		return out
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	tests := []struct {
+		name string
+		key  KeyFunc
+		want []string
+	}{
+		{"category", ByCategory, []string{"dining/GBP", "groceries/EUR", "transport/EUR"}},
+		{"merchant", ByMerchant, []string{"DB/EUR", "PRET A MANGER/GBP", "REWE/EUR"}},
+		{"country", ByCountry, []string{"DE/EUR", "GB/GBP"}},
+		{"day", ByPeriod(Day, nil), []string{"2025-01-01/EUR", "2025-01-02/EUR", "2025-01-03/EUR", "2025-01-03/GBP", "2025-01-07/EUR"}},
+		{"day in Berlin", ByPeriod(Day, berlin), []string{"2025-01-02/EUR", "2025-01-03/EUR", "2025-01-04/EUR", "2025-01-04/GBP", "2025-01-08/EUR"}},
+		{"week", ByPeriod(Week, nil), []string{"2025-W01/EUR", "2025-W01/GBP", "2025-W02/EUR"}},
+		{"month", ByPeriod(Month, nil), []string{"2025-01/EUR", "2025-01/GBP"}},
+		{"all", All, []string{"all/EUR", "all/GBP"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := keys(Aggregate(testEvents(), tt.key))
+			if len(got) != len(tt.want) {
+				t.Fatalf("keys = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("keys = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestReport_SortByNet(t *testing.T) {
+	r := Aggregate(testEvents(), ByMerchant)
+	r.SortByNet()
+	if r.Groups[0].Key != "REWE" || r.Groups[1].Key != "DB" {
+		t.Errorf("order = %s, %s, %s", r.Groups[0].Key, r.Groups[1].Key, r.Groups[2].Key)
+	}
+}
+
+func TestCategoryGroup(t *testing.T) {
+	tests := map[string]string{
+		"0742":  Agricultural,
+		"3012":  Airlines,
+		"4829":  Utilities,
+		"5411":  RetailOutlets,
+		"5651":  ClothingStores,
+		"5812":  MiscellaneousStores,
+		"7372":  BusinessServices,
+		"8062":  ProfessionalServices,
+		"9399":  Government,
+		"":      Unknown,
+		"0000":  Unknown,
+		"+411":  Unknown,
+		"54111": Unknown,
+	}
+	for mcc, want := range tests {
+		if got := CategoryGroup(mcc); got != want {
+			t.Errorf("CategoryGroup(%q) = %q, want %q", mcc, got, want)
+		}
+	}
+}
+
+func TestSpending(t *testing.T) {
+	var got []string
+	for _, e := range testEvents() {
+		if m, ok := Spending(e); ok {
+			got = append(got, m.String())
+		} else {
+			got = append(got, "-")
+		}
+	}
+	want := []string{"25.00 EUR", "10.00 EUR", "-5.00 EUR", "7.50 GBP", "-", "-", "-3.00 EUR", "15.00 EUR", "-"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Spending = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package analytics
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Category groups of ISO 18245 merchant category codes.
+const (
+	Agricultural         = "Agricultural services"
+	ContractedServices   = "Contracted services"
+	Airlines             = "Airlines"
+	CarRental            = "Car rental"
+	Lodging              = "Lodging"
+	Transportation       = "Transportation"
+	Utilities            = "Utilities"
+	RetailOutlets        = "Retail outlets"
+	ClothingStores       = "Clothing stores"
+	MiscellaneousStores  = "Miscellaneous stores"
+	BusinessServices     = "Business services"
+	ProfessionalServices = "Professional services and membership organizations"
+	Government           = "Government services"
+)
+
+// categoryRanges maps inclusive MCC ranges to their category group.
+var categoryRanges = []struct {
+	from, to int
+	group    string
+}{
+	{1, 1499, Agricultural},
+	{1500, 2999, ContractedServices},
+	{3000, 3299, Airlines},
+	{3300, 3499, CarRental},
+	{3500, 3999, Lodging},
+	{4000, 4799, Transportation},
+	{4800, 4999, Utilities},
+	{5000, 5599, RetailOutlets},
+	{5600, 5699, ClothingStores},
+	{5700, 7299, MiscellaneousStores},
+	{7300, 7999, BusinessServices},
+	{8000, 8999, ProfessionalServices},
+	{9000, 9999, Government},
+}
+
+// CategoryGroup returns the category group of a 4-digit MCC, or Unknown.
+func CategoryGroup(mcc string) string {
+	if len(mcc) != 4 || strings.Trim(mcc, "0123456789") != "" {
+		return Unknown
+	}
+	n, _ := strconv.Atoi(mcc)
+	for _, r := range categoryRanges {
+		if n >= r.from && n <= r.to {
+			return r.group
+		}
+	}
+	return Unknown
+}
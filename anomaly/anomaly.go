@@ -0,0 +1,249 @@
+// Package anomaly flags unusual card activity with configurable rules and
+// can freeze a card automatically when a rule fires.
+//
+// A Detector keeps a bounded history of each card's events, which rules use
+// as their baseline. Seed it with past transactions before observing new
+// ones, or rules that compare against history stay quiet until enough
+// events have been seen.
+package anomaly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+// DefaultHistorySize is the number of events kept per card when
+// Detector.HistorySize is zero.
+const DefaultHistorySize = 1000
+
+// Action is what a Detector does when a rule fires.
+type Action string
+
+const (
+	// Flag reports the finding and records it in the audit log.
+	Flag Action = "flag"
+	// Freeze also freezes the card, unless it is frozen already.
+	Freeze Action = "freeze"
+)
+
+// Rule checks one event against the card's history.
+type Rule interface {
+	// Name identifies the rule in findings and audit records.
+	Name() string
+
+	// Check returns a reason if the event is anomalous.
+	Check(ctx context.Context, c *Context) (reason string, flagged bool, err error)
+}
+
+// Context is the input of a Rule.
+type Context struct {
+	Card  string
+	Event gnosispay.CardEvent
+
+	// History holds the card's earlier events, oldest first.
+	History []gnosispay.CardEvent
+
+	cards  *gnosispay.CardService
+	status *gnosispay.CardStatus
+}
+
+// Status returns the card's current status. It is fetched at most once per
+// observed event.
+func (c *Context) Status(ctx context.Context) (*gnosispay.CardStatus, error) {
+	if c.status != nil {
+		return c.status, nil
+	}
+	if c.cards == nil {
+		return nil, fmt.Errorf("card status unavailable: detector has no card service")
+	}
+	s, err := c.cards.GetStatus(ctx, c.Card)
+	if err != nil {
+		return nil, err
+	}
+	c.status = s
+	return s, nil
+}
+
+// Finding is a rule that fired for an event.
+type Finding struct {
+	Rule   string              `json:"rule"`
+	Action Action              `json:"action"`
+	Card   string              `json:"card"`
+	Reason string              `json:"reason"`
+	Event  gnosispay.CardEvent `json:"event"`
+
+	// Frozen is set when the detector froze the card for this finding.
+	Frozen bool `json:"frozen,omitempty"`
+}
+
+type entry struct {
+	rule   Rule
+	action Action
+}
+
+// Detector runs rules over card events.
+//
+// Set the exported fields before the first call to Observe.
+type Detector struct {
+	cards *gnosispay.CardService
+	rules []entry
+	now   func() time.Time
+
+	// Audit records every finding and freeze. Optional.
+	Audit AuditLog
+
+	// HistorySize caps the events kept per card. Defaults to
+	// DefaultHistorySize.
+	HistorySize int
+
+	mu      sync.Mutex
+	history map[string][]gnosispay.CardEvent
+}
+
+// NewDetector returns a Detector without rules. cards is used to read card
+// status and to freeze cards; it may be nil when no rule needs either.
+func NewDetector(cards *gnosispay.CardService) *Detector {
+	return &Detector{cards: cards, now: time.Now, history: map[string][]gnosispay.CardEvent{}}
+}
+
+// Add registers a rule with the action to take when it fires.
+func (d *Detector) Add(r Rule, a Action) *Detector {
+	d.rules = append(d.rules, entry{r, a})
+	return d
+}
+
+// Seed adds past events of card to its history without checking them.
+func (d *Detector) Seed(card string, events []gnosispay.CardEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, e := range events {
+		d.remember(card, e)
+	}
+}
+
+// Handler returns a callback for gnosispay.Watcher.Run that observes the
+// events of one card. Create one watcher per card, filtered with
+// ListTransactionsOptions.CardTokens, since card events do not name their
+// card.
+func (d *Detector) Handler(ctx context.Context, card string) func(gnosispay.WatchEvent) error {
+	return func(w gnosispay.WatchEvent) error {
+		_, err := d.Observe(ctx, card, w.Event)
+		return err
+	}
+}
+
+// Observe checks an event of card against every rule, then adds it to the
+// card's history. An event already in the history, such as a pending event
+// reported again when it clears, only updates the history. Rules that fail
+// are skipped and their errors returned with the findings of the others.
+//
+// The history is only locked while it is updated, so a slow card service
+// or audit log does not hold up other calls.
+func (d *Detector) Observe(ctx context.Context, card string, e gnosispay.CardEvent) ([]Finding, error) {
+	d.mu.Lock()
+	i, replaced := d.remember(card, e)
+	history := slices.Clone(d.history[card][:i])
+	d.mu.Unlock()
+	if replaced {
+		return nil, nil
+	}
+	c := &Context{Card: card, Event: e, History: history, cards: d.cards}
+
+	var (
+		findings []Finding
+		errs     []error
+		freeze   = -1
+	)
+	for _, r := range d.rules {
+		reason, flagged, err := r.rule.Check(ctx, c)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: %w", r.rule.Name(), err))
+			continue
+		}
+		if !flagged {
+			continue
+		}
+		findings = append(findings, Finding{Rule: r.rule.Name(), Action: r.action, Card: card, Reason: reason, Event: e})
+		if r.action == Freeze && freeze < 0 {
+			freeze = len(findings) - 1
+		}
+	}
+
+	var freezeErr error
+	if freeze >= 0 {
+		findings[freeze].Frozen, freezeErr = d.freeze(ctx, c)
+		if freezeErr != nil {
+			errs = append(errs, fmt.Errorf("freezing card %s: %w", card, freezeErr))
+		}
+	}
+	if d.Audit != nil {
+		for i, f := range findings {
+			rec := AuditRecord{Time: d.now(), Finding: f}
+			if i == freeze && freezeErr != nil {
+				rec.Error = freezeErr.Error()
+			}
+			if err := d.Audit.Record(ctx, rec); err != nil {
+				errs = append(errs, fmt.Errorf("audit: %w", err))
+			}
+		}
+	}
+	return findings, errors.Join(errs...)
+}
+
+// freeze freezes the card unless its status shows it frozen already.
+func (d *Detector) freeze(ctx context.Context, c *Context) (bool, error) {
+	if d.cards == nil {
+		return false, fmt.Errorf("detector has no card service")
+	}
+	s, err := c.Status(ctx)
+	if err != nil {
+		return false, err
+	}
+	if s.IsFrozen {
+		return false, nil
+	}
+	if err := d.cards.Freeze(ctx, c.Card); err != nil {
+		return false, err
+	}
+	s.IsFrozen = true
+	return true, nil
+}
+
+// remember adds e to the card's history, replacing an earlier version of
+// it, matched by CardEvent.ID. It returns e's index and whether it
+// replaced an event.
+func (d *Detector) remember(card string, e gnosispay.CardEvent) (int, bool) {
+	hist := d.history[card]
+	id := e.ID()
+	for i := len(hist) - 1; i >= 0; i-- {
+		// The ID covers the creation time, which is cheaper to compare.
+		if hist[i].CreatedAt.Equal(e.CreatedAt) && hist[i].ID() == id {
+			hist[i] = e
+			return i, true
+		}
+	}
+	// Keep the history in creation order; events mostly arrive in order.
+	i := len(hist)
+	for i > 0 && hist[i-1].CreatedAt.After(e.CreatedAt) {
+		i--
+	}
+	hist = append(hist, gnosispay.CardEvent{})
+	copy(hist[i+1:], hist[i:])
+	hist[i] = e
+	size := d.HistorySize
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	if drop := len(hist) - size; drop > 0 {
+		hist = append(hist[:0:0], hist[drop:]...)
+		i = max(i-drop, 0)
+	}
+	d.history[card] = hist
+	return i, false
+}
@@ -0,0 +1,254 @@
+package anomaly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/internal/eventtest"
+)
+
+var start = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// minute returns the time m minutes after start.
+func minute(m int) time.Time {
+	return start.Add(time.Duration(m) * time.Minute)
+}
+
+// history is a week of ordinary spending in Germany.
+func history() []gnosispay.CardEvent {
+	var out []gnosispay.CardEvent
+	for i, amount := range []string{"2400", "3100", "2700", "2900", "2600"} {
+		out = append(out, eventtest.Payment(minute(-24*60*(7-i)), amount, eventtest.EUR, eventtest.Merchant("REWE"), eventtest.Country("DE"), eventtest.MCC("5411")))
+	}
+	return out
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		history []gnosispay.CardEvent
+		event   gnosispay.CardEvent
+		want    string // Empty when the rule must not fire.
+	}{
+		{"new country", &NewCountry{}, history(), eventtest.Payment(minute(0), "500", eventtest.EUR, eventtest.Merchant("Shop"), eventtest.Country("BR"), eventtest.MCC("5999")), "first transaction in BR"},
+		{"known country", &NewCountry{}, history(), eventtest.Payment(minute(0), "500", eventtest.EUR, eventtest.Merchant("Shop"), eventtest.Country("DE"), eventtest.MCC("5999")), ""},
+		{"new country without baseline", &NewCountry{}, history()[:2], eventtest.Payment(minute(0), "500", eventtest.EUR, eventtest.Merchant("Shop"), eventtest.Country("BR"), eventtest.MCC("5999")), ""},
+		{"large amount", &LargeAmount{}, history(), eventtest.Payment(minute(0), "9000", eventtest.EUR, eventtest.Merchant("rewe"), eventtest.Country("DE"), eventtest.MCC("5411")), "90.00 EUR at REWE is over 3x the usual 27.00 EUR"},
+		{"usual amount", &LargeAmount{}, history(), eventtest.Payment(minute(0), "8000", eventtest.EUR, eventtest.Merchant("REWE"), eventtest.Country("DE"), eventtest.MCC("5411")), ""},
+		{"large amount elsewhere", &LargeAmount{}, history(), eventtest.Payment(minute(0), "90000", eventtest.EUR, eventtest.Merchant("Apple"), eventtest.Country("DE"), eventtest.MCC("5732")), ""},
+		{"custom factor", &LargeAmount{Factor: 1.5}, history(), eventtest.Payment(minute(0), "4500", eventtest.EUR, eventtest.Merchant("REWE"), eventtest.Country("DE"), eventtest.MCC("5411")), "45.00 EUR at REWE is over 1.5x the usual 27.00 EUR"},
+		{"high-risk mcc", &HighRiskMCC{}, nil, eventtest.Payment(minute(0), "100", eventtest.EUR, eventtest.Merchant("Casino"), eventtest.Country("DE"), eventtest.MCC("7995")), "high-risk merchant category 7995"},
+		{"custom high-risk mcc", &HighRiskMCC{Codes: []string{"5411"}}, nil, eventtest.Payment(minute(0), "100", eventtest.EUR, eventtest.Merchant("Casino"), eventtest.Country("DE"), eventtest.MCC("7995")), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, flagged, err := tt.rule.Check(context.Background(), &Context{Card: "card-1", Event: tt.event, History: tt.history})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if flagged != (tt.want != "") || !strings.HasPrefix(reason, tt.want) {
+				t.Errorf("Check = %q, %v; want %q", reason, flagged, tt.want)
+			}
+		})
+	}
+}
+
+func TestRapidAuthorizations(t *testing.T) {
+	r := &RapidAuthorizations{Count: 3, Window: 5 * time.Minute, Max: gnosispay.NewMoney(200, *eventtest.EUR)}
+	var hist []gnosispay.CardEvent
+	check := func(e gnosispay.CardEvent) bool {
+		_, flagged, _ := r.Check(context.Background(), &Context{Event: e, History: hist})
+		hist = append(hist, e)
+		return flagged
+	}
+	declined := eventtest.Payment(minute(1), "100", eventtest.EUR, eventtest.Merchant("Web"), eventtest.Country("US"), eventtest.MCC("5999"))
+	declined.Status = "IncorrectPin"
+	steps := []struct {
+		event gnosispay.CardEvent
+		want  bool
+	}{
+		{eventtest.Payment(minute(0), "100", eventtest.EUR, eventtest.Merchant("Web"), eventtest.Country("US"), eventtest.MCC("5999")), false},
+		{eventtest.Payment(minute(1), "5000", eventtest.EUR, eventtest.Merchant("Web"), eventtest.Country("US"), eventtest.MCC("5999")), false}, // Not small.
+		{declined, false},
+		{eventtest.Payment(minute(2), "150", eventtest.EUR, eventtest.Merchant("Web"), eventtest.Country("US"), eventtest.MCC("5999")), true},
+		{eventtest.Payment(minute(9), "150", eventtest.EUR, eventtest.Merchant("Web"), eventtest.Country("US"), eventtest.MCC("5999")), false}, // Outside the window.
+	}
+	for i, s := range steps {
+		if got := check(s.event); got != s.want {
+			t.Errorf("step %d flagged = %v, want %v", i, got, s.want)
+		}
+	}
+}
+
+// fakeCards serves card status and freeze requests.
+func fakeCards(t *testing.T, frozen bool) (*gnosispay.CardService, *int) {
+	t.Helper()
+	freezes := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v1/cards/card-1/status":
+			json.NewEncoder(w).Encode(gnosispay.CardStatus{IsFrozen: frozen})
+		case "POST /api/v1/cards/card-1/freeze":
+			freezes++
+			frozen = true
+			json.NewEncoder(w).Encode(map[string]bool{"ok": true})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	c, err := gnosispay.New(nil, gnosispay.SetBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.Cards, &freezes
+}
+
+func TestDetector(t *testing.T) {
+	ctx := context.Background()
+	cards, freezes := fakeCards(t, false)
+	var audit bytes.Buffer
+	d := NewDetector(cards).
+		Add(&NewCountry{}, Flag).
+		Add(&HighRiskMCC{}, Freeze).
+		Add(&FrozenCard{}, Flag)
+	d.Audit = &JSONAuditLog{W: &audit}
+	d.now = func() time.Time { return start }
+	d.Seed("card-1", history())
+
+	findings, err := d.Observe(ctx, "card-1", eventtest.Payment(minute(0), "10000", eventtest.EUR, eventtest.Merchant("Casino"), eventtest.Country("MT"), eventtest.MCC("7995")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 || findings[0].Rule != "new-country" || findings[1].Rule != "high-risk-mcc" || !findings[1].Frozen {
+		t.Fatalf("findings = %+v", findings)
+	}
+	if *freezes != 1 {
+		t.Errorf("freezes = %d, want 1", *freezes)
+	}
+
+	// The same event clearing is not checked again.
+	cleared := eventtest.Payment(minute(0), "10000", eventtest.EUR, eventtest.Merchant("Casino"), eventtest.Country("MT"), eventtest.MCC("7995"))
+	clearedAt := start.Add(time.Hour)
+	cleared.ClearedAt = &clearedAt
+	if findings, _ := d.Observe(ctx, "card-1", cleared); len(findings) != 0 {
+		t.Errorf("findings on redelivery = %+v", findings)
+	}
+
+	// Malta is known now; the card is frozen, so it is not frozen again.
+	findings, err = d.Observe(ctx, "card-1", eventtest.Payment(minute(5), "10000", eventtest.EUR, eventtest.Merchant("Casino"), eventtest.Country("MT"), eventtest.MCC("7995")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 || findings[0].Rule != "high-risk-mcc" || findings[0].Frozen || findings[1].Rule != "frozen-card" {
+		t.Errorf("findings = %+v", findings)
+	}
+	if *freezes != 1 {
+		t.Errorf("freezes = %d, want 1", *freezes)
+	}
+
+	var recs []AuditRecord
+	for _, line := range strings.Split(strings.TrimSpace(audit.String()), "\n") {
+		var rec AuditRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("audit line %q: %v", line, err)
+		}
+		recs = append(recs, rec)
+	}
+	if len(recs) != 4 || !recs[1].Frozen || recs[1].Action != Freeze || recs[1].Card != "card-1" || !recs[1].Time.Equal(start) {
+		t.Errorf("audit = %+v", recs)
+	}
+}
+
+func TestDetector_freezeFailure(t *testing.T) {
+	var audit bytes.Buffer
+	d := NewDetector(nil).Add(&HighRiskMCC{}, Freeze)
+	d.Audit = &JSONAuditLog{W: &audit}
+	findings, err := d.Observe(context.Background(), "card-1", eventtest.Payment(minute(0), "100", eventtest.EUR, eventtest.Merchant("Casino"), eventtest.Country("MT"), eventtest.MCC("7995")))
+	if err == nil || len(findings) != 1 || findings[0].Frozen {
+		t.Fatalf("Observe = %+v, %v; want the finding and an error", findings, err)
+	}
+	if !strings.Contains(audit.String(), `"error":"detector has no card service"`) {
+		t.Errorf("audit = %s", audit.String())
+	}
+}
+
+func TestDetector_history(t *testing.T) {
+	d := NewDetector(nil)
+	d.HistorySize = 3
+	var seen []int
+	d.Add(ruleFunc(func(c *Context) { seen = append(seen, len(c.History)) }), Flag)
+	for _, m := range []int{0, 10, 20, 30, 5} {
+		d.Observe(context.Background(), "card-1", eventtest.Payment(minute(m), "100", eventtest.EUR, eventtest.Merchant("Shop"), eventtest.Country("DE"), eventtest.MCC("5999")))
+	}
+	// The late event at minute 5 predates the retained history, which
+	// keeps the newest events.
+	if want := "[0 1 2 2 0]"; fmt.Sprint(seen) != want {
+		t.Errorf("history lengths = %v, want %v", seen, want)
+	}
+	if h := d.history["card-1"]; len(h) != 3 || !h[0].CreatedAt.Equal(start.Add(10*time.Minute)) {
+		t.Errorf("history = %v", h)
+	}
+}
+
+func TestDetector_sameCreationTime(t *testing.T) {
+	d := NewDetector(nil)
+	checked := 0
+	d.Add(ruleFunc(func(c *Context) { checked++ }), Flag)
+
+	a := eventtest.Payment(minute(0), "100", eventtest.EUR, eventtest.Merchant("Shop"), eventtest.Country("DE"), eventtest.MCC("5999"))
+	a.ThreadID = "thread-a"
+	b := a
+	b.ThreadID = "thread-b"
+	cleared := a
+	cleared.Transactions = []gnosispay.Transaction{{Hash: "0xa"}}
+	for _, e := range []gnosispay.CardEvent{a, b, cleared} {
+		d.Observe(context.Background(), "card-1", e)
+	}
+	// Two events created at once are both checked; the clearing of one
+	// only updates it.
+	if checked != 2 || len(d.history["card-1"]) != 2 {
+		t.Errorf("checked %d events, history has %d; want 2 and 2", checked, len(d.history["card-1"]))
+	}
+}
+
+func TestDetector_unlockedDuringRules(t *testing.T) {
+	d := NewDetector(nil)
+	entered, release := make(chan struct{}), make(chan struct{})
+	d.Add(ruleFunc(func(c *Context) {
+		if c.Card == "slow" {
+			close(entered)
+			<-release
+		}
+	}), Flag)
+
+	done := make(chan struct{})
+	go func() {
+		d.Observe(context.Background(), "slow", eventtest.Payment(minute(0), "100", eventtest.EUR, eventtest.Merchant("Shop"), eventtest.Country("DE"), eventtest.MCC("5999")))
+		close(done)
+	}()
+	<-entered
+	if _, err := d.Observe(context.Background(), "fast", eventtest.Payment(minute(0), "100", eventtest.EUR, eventtest.Merchant("Shop"), eventtest.Country("DE"), eventtest.MCC("5999"))); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	<-done
+}
+
+type ruleFunc func(*Context)
+
+func (f ruleFunc) Name() string { return "func" }
+
+func (f ruleFunc) Check(ctx context.Context, c *Context) (string, bool, error) {
+	f(c)
+	return "", false, nil
+}
@@ -0,0 +1,41 @@
+package anomaly
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// AuditRecord is written for every finding.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	Finding
+
+	// Error is set when freezing the card failed.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditLog stores audit records.
+type AuditLog interface {
+	Record(ctx context.Context, rec AuditRecord) error
+}
+
+// JSONAuditLog writes one JSON object per line, e.g. to an append-only
+// file.
+type JSONAuditLog struct {
+	W  io.Writer
+	mu sync.Mutex
+}
+
+func (l *JSONAuditLog) Record(ctx context.Context, rec AuditRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.W.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,190 @@
+package anomaly
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/analytics"
+	"github.com/guarilha/go-gnosispay/mcc"
+)
+
+// NewCountry flags an event in a country the card has not been used in.
+// Countries are taken from the merchant, falling back to the event.
+type NewCountry struct {
+	// MinHistory is the number of earlier events needed before a country
+	// counts as new. Defaults to 5.
+	MinHistory int
+}
+
+func (r *NewCountry) Name() string { return "new-country" }
+
+func (r *NewCountry) Check(ctx context.Context, c *Context) (string, bool, error) {
+	country := analytics.ByCountry(c.Event)
+	if country == analytics.Unknown || len(c.History) < withDefault(r.MinHistory, 5) {
+		return "", false, nil
+	}
+	for _, e := range c.History {
+		if analytics.ByCountry(e) == country {
+			return "", false, nil
+		}
+	}
+	return fmt.Sprintf("first transaction in %s", country), true, nil
+}
+
+// LargeAmount flags a payment well above the usual amount at the same
+// merchant.
+type LargeAmount struct {
+	// Factor over the median of earlier payments at the merchant.
+	// Defaults to 3.
+	Factor float64
+
+	// MinHistory is the number of earlier payments at the merchant needed
+	// for a baseline. Defaults to 3.
+	MinHistory int
+}
+
+func (r *LargeAmount) Name() string { return "large-amount" }
+
+func (r *LargeAmount) Check(ctx context.Context, c *Context) (string, bool, error) {
+	m, ok := analytics.Spending(c.Event)
+	if !ok || m.Sign() <= 0 {
+		return "", false, nil
+	}
+	merchant := analytics.ByMerchant(c.Event)
+	if merchant == analytics.Unknown {
+		return "", false, nil
+	}
+	var past []gnosispay.Money
+	for _, e := range c.History {
+		if analytics.ByMerchant(e) != merchant {
+			continue
+		}
+		if p, ok := analytics.Spending(e); ok && p.Sign() > 0 && p.SameCurrency(m) {
+			past = append(past, p)
+		}
+	}
+	if len(past) < withDefault(r.MinHistory, 3) {
+		return "", false, nil
+	}
+	sort.Slice(past, func(i, j int) bool {
+		c, _ := past[i].Cmp(past[j])
+		return c < 0
+	})
+	median := past[len(past)/2]
+	factor := r.Factor
+	if factor <= 0 {
+		factor = 3
+	}
+	limit := new(big.Float).Mul(new(big.Float).SetInt(median.MinorUnits()), big.NewFloat(factor))
+	if new(big.Float).SetInt(m.MinorUnits()).Cmp(limit) <= 0 {
+		return "", false, nil
+	}
+	return fmt.Sprintf("%s at %s is over %gx the usual %s", m, merchant, factor, median), true, nil
+}
+
+// RapidAuthorizations flags bursts of small authorizations, a common sign
+// of a card being tested. Declined attempts count too.
+type RapidAuthorizations struct {
+	// Count of authorizations within Window that fires the rule, including
+	// the observed one. Defaults to 5.
+	Count int
+
+	// Window defaults to 10 minutes.
+	Window time.Duration
+
+	// Max is the largest amount counted as small. The zero value counts
+	// every amount.
+	Max gnosispay.Money
+}
+
+func (r *RapidAuthorizations) Name() string { return "rapid-authorizations" }
+
+func (r *RapidAuthorizations) Check(ctx context.Context, c *Context) (string, bool, error) {
+	window := r.Window
+	if window <= 0 {
+		window = 10 * time.Minute
+	}
+	if !r.small(c.Event) {
+		return "", false, nil
+	}
+	n := 1
+	from := c.Event.CreatedAt.Add(-window)
+	for i := len(c.History) - 1; i >= 0 && c.History[i].CreatedAt.After(from); i-- {
+		if r.small(c.History[i]) {
+			n++
+		}
+	}
+	if n < withDefault(r.Count, 5) {
+		return "", false, nil
+	}
+	return fmt.Sprintf("%d small authorizations within %s", n, window), true, nil
+}
+
+func (r *RapidAuthorizations) small(e gnosispay.CardEvent) bool {
+	if e.Kind != "" && e.Kind != gnosispay.CardEventPayment {
+		return false
+	}
+	if r.Max.Currency.Code == "" {
+		return true
+	}
+	m, err := e.BillingMoney()
+	if err != nil || !m.SameCurrency(r.Max) {
+		return false
+	}
+	cmp, _ := m.Abs().Cmp(r.Max)
+	return cmp <= 0
+}
+
+// DefaultHighRiskMCCs are the codes HighRiskMCC uses when it sets none:
+// wire transfers, quasi-cash and crypto, stored value loads, gambling and
+// inbound telemarketing.
+var DefaultHighRiskMCCs = []string{"4829", "5967", "6051", "6540", "7800", "7801", "7802", "7995", "9754"}
+
+// HighRiskMCC flags events with a high-risk merchant category code.
+type HighRiskMCC struct {
+	// Codes defaults to DefaultHighRiskMCCs.
+	Codes []string
+}
+
+func (r *HighRiskMCC) Name() string { return "high-risk-mcc" }
+
+func (r *HighRiskMCC) Check(ctx context.Context, c *Context) (string, bool, error) {
+	codes := r.Codes
+	if codes == nil {
+		codes = DefaultHighRiskMCCs
+	}
+	for _, code := range codes {
+		if c.Event.Mcc == code {
+			return fmt.Sprintf("high-risk merchant category %s (%s)", code, mcc.Describe(code)), true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// FrozenCard flags any activity on a card that is frozen. It reads the
+// card status, so the Detector needs a card service.
+type FrozenCard struct{}
+
+func (r *FrozenCard) Name() string { return "frozen-card" }
+
+func (r *FrozenCard) Check(ctx context.Context, c *Context) (string, bool, error) {
+	s, err := c.Status(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	if !s.IsFrozen {
+		return "", false, nil
+	}
+	return "activity on a frozen card", true, nil
+}
+
+func withDefault(n, def int) int {
+	if n <= 0 {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,390 @@
+// Package budget tracks monthly spending against budgets and raises alerts
+// when thresholds are crossed.
+//
+// An Engine consumes card events, typically from a gnosispay.Watcher, and
+// keeps a running total per budget and month. Events are counted as
+// gnosispay.CardEvent.SignedAmount counts them: payments add to the total,
+// refunds and reversal events subtract from it, and declined or reversed
+// payments do not count. Observing the same event again replaces it, so
+// redelivered events and pending events that later clear or are reversed
+// are handled. Only the newest month observed and the one before are kept.
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/mcc"
+)
+
+// keptPeriods is how many months of tallies an Engine keeps: the newest
+// month observed and the one before, whose pending events may still clear
+// or be reversed. Older tallies are dropped and their events ignored.
+const keptPeriods = 2
+
+// DefaultThresholds are the alert thresholds, in percent of the limit, of a
+// Budget that sets none.
+var DefaultThresholds = []int{80, 100}
+
+// Budget is a monthly spending limit.
+type Budget struct {
+	Name string `json:"name"`
+
+	// Category restricts the budget to events in a spending category.
+	// Empty matches every category.
+	Category mcc.Category `json:"category,omitempty"`
+
+	// Card restricts the budget to one card ID. Empty matches every card.
+	Card string `json:"card,omitempty"`
+
+	// Limit per calendar month. Only events billed in its currency count.
+	Limit gnosispay.Money `json:"limit"`
+
+	// Thresholds in percent of Limit, e.g. 80 and 100. Defaults to
+	// DefaultThresholds.
+	Thresholds []int `json:"thresholds,omitempty"`
+}
+
+func (b Budget) thresholds() []int {
+	if len(b.Thresholds) == 0 {
+		return DefaultThresholds
+	}
+	return b.Thresholds
+}
+
+func (b Budget) validate() error {
+	if b.Name == "" {
+		return fmt.Errorf("budget name cannot be empty")
+	}
+	if b.Limit.Currency.Code == "" || b.Limit.Sign() <= 0 {
+		return fmt.Errorf("budget %q: limit must be a positive amount with a currency", b.Name)
+	}
+	for _, t := range b.Thresholds {
+		if t <= 0 {
+			return fmt.Errorf("budget %q: threshold %d%% must be positive", b.Name, t)
+		}
+	}
+	return nil
+}
+
+func (b Budget) matches(card string, e gnosispay.CardEvent) bool {
+	if b.Card != "" && b.Card != card {
+		return false
+	}
+	if b.Category != "" && b.Category != e.Category() {
+		return false
+	}
+	return e.BillingCurrency != nil && e.BillingCurrency.Code == b.Limit.Currency.Code
+}
+
+// Alert reports that spending reached a threshold of a budget.
+type Alert struct {
+	Budget    string              `json:"budget"`
+	Period    string              `json:"period"`    // Month as "2025-01".
+	Threshold int                 `json:"threshold"` // Percent of Limit.
+	Spent     gnosispay.Money     `json:"spent"`
+	Limit     gnosispay.Money     `json:"limit"`
+	Card      string              `json:"card,omitempty"`
+	Event     gnosispay.CardEvent `json:"event"` // The event that crossed the threshold.
+}
+
+func (a Alert) String() string {
+	return fmt.Sprintf("budget %s %s: %d%% reached (%s of %s)", a.Budget, a.Period, a.Threshold, a.Spent, a.Limit)
+}
+
+// Usage is the spending of a budget in one month.
+type Usage struct {
+	Budget  string          `json:"budget"`
+	Period  string          `json:"period"`
+	Spent   gnosispay.Money `json:"spent"`
+	Limit   gnosispay.Money `json:"limit"`
+	Percent int             `json:"percent"`
+}
+
+// Engine evaluates card events against budgets.
+//
+// Set the exported fields before the first call to Observe.
+type Engine struct {
+	budgets []Budget
+
+	// Store persists running totals. Defaults to a MemoryStore.
+	Store Store
+
+	// Notifiers receive every alert.
+	Notifiers []Notifier
+
+	// Location decides which month an event belongs to. Defaults to UTC.
+	Location *time.Location
+
+	mu    sync.Mutex
+	state *State
+}
+
+// NewEngine returns an Engine for budgets. Budget names must be unique.
+func NewEngine(budgets ...Budget) (*Engine, error) {
+	seen := map[string]bool{}
+	for _, b := range budgets {
+		if err := b.validate(); err != nil {
+			return nil, err
+		}
+		if seen[b.Name] {
+			return nil, fmt.Errorf("duplicate budget %q", b.Name)
+		}
+		seen[b.Name] = true
+	}
+	return &Engine{budgets: budgets}, nil
+}
+
+// Handler returns a callback for gnosispay.Watcher.Run that observes the
+// events of one card. Create one watcher per card, filtered with
+// ListTransactionsOptions.CardTokens, since card events do not name their
+// card.
+func (en *Engine) Handler(ctx context.Context, card string) func(gnosispay.WatchEvent) error {
+	return func(w gnosispay.WatchEvent) error {
+		_, err := en.Observe(ctx, card, w.Event)
+		return err
+	}
+}
+
+// Observe counts an event of card towards the matching budgets and sends
+// an alert for every threshold it crosses. A threshold fires once per
+// month; it is only marked as fired once all notifiers accepted the alert,
+// so a failed notification is retried when the event is observed again.
+// Events from before the kept months are ignored. Observe returns the
+// alerts it raised.
+func (en *Engine) Observe(ctx context.Context, card string, e gnosispay.CardEvent) ([]Alert, error) {
+	en.mu.Lock()
+	defer en.mu.Unlock()
+	if err := en.load(ctx); err != nil {
+		return nil, err
+	}
+
+	period := e.CreatedAt.In(en.location()).Format(periodLayout)
+	if period < en.state.oldestKept(period) {
+		return nil, nil
+	}
+	var alerts []Alert
+	var errs []error
+	for _, b := range en.budgets {
+		if !b.matches(card, e) {
+			continue
+		}
+		t := en.state.tally(b.Name, period)
+		t.record(card, e)
+		spent, err := t.spent(b.Limit.Currency)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("budget %s: %w", b.Name, err))
+			continue
+		}
+		pct := percent(spent, b.Limit)
+		for _, th := range b.thresholds() {
+			if pct >= th && !t.fired(th) {
+				alerts = append(alerts, Alert{
+					Budget: b.Name, Period: period, Threshold: th,
+					Spent: spent, Limit: b.Limit, Card: card, Event: e,
+				})
+			}
+		}
+	}
+
+	for _, a := range alerts {
+		if err := en.notify(ctx, a); err != nil {
+			errs = append(errs, fmt.Errorf("budget %s: notifying %d%% alert: %w", a.Budget, a.Threshold, err))
+			continue
+		}
+		t := en.state.tally(a.Budget, a.Period)
+		t.Fired = append(t.Fired, a.Threshold)
+	}
+	en.state.prune(en.state.oldestKept(period))
+	if err := en.store().Save(ctx, en.state); err != nil {
+		errs = append(errs, err)
+	}
+	return alerts, errors.Join(errs...)
+}
+
+// Usage returns the spending of every budget in the month containing now.
+func (en *Engine) Usage(ctx context.Context, now time.Time) ([]Usage, error) {
+	en.mu.Lock()
+	defer en.mu.Unlock()
+	if err := en.load(ctx); err != nil {
+		return nil, err
+	}
+	period := now.In(en.location()).Format(periodLayout)
+	out := make([]Usage, 0, len(en.budgets))
+	for _, b := range en.budgets {
+		spent := gnosispay.NewMoney(0, b.Limit.Currency)
+		if t := en.state.Tallies[tallyKey(b.Name, period)]; t != nil {
+			var err error
+			if spent, err = t.spent(b.Limit.Currency); err != nil {
+				return nil, fmt.Errorf("budget %s: %w", b.Name, err)
+			}
+		}
+		out = append(out, Usage{Budget: b.Name, Period: period, Spent: spent, Limit: b.Limit, Percent: percent(spent, b.Limit)})
+	}
+	return out, nil
+}
+
+func (en *Engine) notify(ctx context.Context, a Alert) error {
+	var errs []error
+	for _, n := range en.Notifiers {
+		if err := n.Notify(ctx, a); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (en *Engine) load(ctx context.Context) error {
+	if en.state != nil {
+		return nil
+	}
+	st, err := en.store().Load(ctx)
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		st = &State{}
+	}
+	if st.Tallies == nil {
+		st.Tallies = map[string]*Tally{}
+	}
+	en.state = st
+	return nil
+}
+
+func (en *Engine) store() Store {
+	if en.Store == nil {
+		en.Store = &MemoryStore{}
+	}
+	return en.Store
+}
+
+func (en *Engine) location() *time.Location {
+	if en.Location == nil {
+		return time.UTC
+	}
+	return en.Location
+}
+
+// percent returns spent as a whole percentage of limit, rounded down.
+func percent(spent, limit gnosispay.Money) int {
+	if spent.Sign() <= 0 {
+		return 0
+	}
+	n := new(big.Int).Mul(spent.MinorUnits(), big.NewInt(100))
+	n.Quo(n, limit.MinorUnits())
+	if !n.IsInt64() || n.Int64() > 1<<31-1 {
+		return 1<<31 - 1
+	}
+	return int(n.Int64())
+}
+
+// State is the persistent state of an Engine.
+type State struct {
+	// Tallies are keyed by budget name and month.
+	Tallies map[string]*Tally `json:"tallies"`
+}
+
+// Tally is the running total of one budget in one month.
+type Tally struct {
+	Budget string `json:"budget"`
+	Period string `json:"period"`
+
+	// Entries holds each observed event, keyed by card and creation time;
+	// keys of one card sort chronologically.
+	Entries map[string]gnosispay.CardEvent `json:"entries"`
+
+	// Fired lists the thresholds already alerted.
+	Fired []int `json:"fired,omitempty"`
+}
+
+// entryLayout is fixed-width so entry keys sort chronologically.
+const entryLayout = "2006-01-02T15:04:05.000000000Z"
+
+// periodLayout formats months; periods sort chronologically as strings.
+const periodLayout = "2006-01"
+
+func tallyKey(budget, period string) string {
+	return budget + "|" + period
+}
+
+func (s *State) tally(budget, period string) *Tally {
+	k := tallyKey(budget, period)
+	t := s.Tallies[k]
+	if t == nil {
+		t = &Tally{Budget: budget, Period: period, Entries: map[string]gnosispay.CardEvent{}}
+		s.Tallies[k] = t
+	}
+	return t
+}
+
+// oldestKept returns the oldest period kept once period is observed.
+func (s *State) oldestKept(period string) string {
+	newest := period
+	for _, t := range s.Tallies {
+		if t.Period > newest {
+			newest = t.Period
+		}
+	}
+	m, err := time.Parse(periodLayout, newest)
+	if err != nil {
+		return ""
+	}
+	return m.AddDate(0, 1-keptPeriods, 0).Format(periodLayout)
+}
+
+// prune drops the tallies of periods before oldest.
+func (s *State) prune(oldest string) {
+	for k, t := range s.Tallies {
+		if t.Period < oldest {
+			delete(s.Tallies, k)
+		}
+	}
+}
+
+// record stores an event of card, replacing an earlier version of it.
+func (t *Tally) record(card string, e gnosispay.CardEvent) {
+	t.Entries[card+"|"+e.CreatedAt.UTC().Format(entryLayout)] = e
+}
+
+// spent sums the spending of the tally's events in currency c. A reversal
+// event is matched against the payments of its own card, and left out when
+// the payment already shows the reversal.
+func (t *Tally) spent(c gnosispay.Currency) (gnosispay.Money, error) {
+	byCard := map[string][]gnosispay.CardEvent{}
+	for k, e := range t.Entries {
+		card := k[:strings.LastIndexByte(k, '|')]
+		byCard[card] = append(byCard[card], e)
+	}
+	sum := gnosispay.NewMoney(0, c)
+	for _, events := range byCard {
+		for _, e := range events {
+			if e.DoubleCounted(events) {
+				continue
+			}
+			m, err := e.SignedAmount()
+			if err != nil {
+				return gnosispay.Money{}, fmt.Errorf("event at %s: %w", e.CreatedAt.Format(time.RFC3339), err)
+			}
+			if sum, err = sum.Sub(m); err != nil {
+				return gnosispay.Money{}, fmt.Errorf("event at %s: %w", e.CreatedAt.Format(time.RFC3339), err)
+			}
+		}
+	}
+	return sum, nil
+}
+
+func (t *Tally) fired(threshold int) bool {
+	for _, f := range t.Fired {
+		if f == threshold {
+			return true
+		}
+	}
+	return false
+}
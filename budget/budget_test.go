@@ -0,0 +1,185 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/internal/eventtest"
+	"github.com/guarilha/go-gnosispay/mcc"
+)
+
+func eurMoney(minor int64) gnosispay.Money { return gnosispay.NewMoney(minor, *eventtest.EUR) }
+
+// at returns the time of day d of January 2025 at 12:m.
+func at(d, m int) time.Time {
+	return time.Date(2025, 1, d, 12, m, 0, 0, time.UTC)
+}
+
+// recorder collects alerts as "budget/threshold".
+type recorder struct{ got []string }
+
+func (r *recorder) Notify(ctx context.Context, a Alert) error {
+	r.got = append(r.got, fmt.Sprintf("%s/%d", a.Budget, a.Threshold))
+	return nil
+}
+
+func TestEngine_Observe(t *testing.T) {
+	ctx := context.Background()
+	en, err := NewEngine(
+		Budget{Name: "groceries", Category: mcc.Groceries, Limit: eurMoney(10000)},
+		Budget{Name: "card-2", Card: "card-2", Limit: eurMoney(5000), Thresholds: []int{50}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &recorder{}
+	en.Notifiers = []Notifier{rec}
+
+	steps := []struct {
+		name  string
+		card  string
+		event gnosispay.CardEvent
+		want  []string
+		spent string
+	}{
+		{"pending payment below threshold", "card-1", eventtest.Payment(at(2, 0), "7000", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE"), eventtest.Pending()), nil, "70.00 EUR"},
+		{"other category ignored", "card-1", eventtest.Payment(at(2, 1), "9000", eventtest.EUR, eventtest.MCC("5812"), eventtest.Merchant("Cafe")), nil, "70.00 EUR"},
+		{"clears at a higher amount", "card-1", eventtest.Payment(at(2, 0), "8000", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE")), []string{"groceries/80"}, "80.00 EUR"},
+		{"redelivery does not fire again", "card-1", eventtest.Payment(at(2, 0), "8000", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE")), nil, "80.00 EUR"},
+		{"declined payment ignored", "card-1", eventtest.Payment(at(3, 0), "9000", eventtest.EUR, eventtest.Status(gnosispay.CardEventInsufficientFunds), eventtest.MCC("5411"), eventtest.Merchant("REWE")), nil, "80.00 EUR"},
+		{"other currency ignored", "card-1", eventtest.Payment(at(3, 1), "9000", eventtest.GBP, eventtest.MCC("5411"), eventtest.Merchant("Tesco")), nil, "80.00 EUR"},
+		{"refund subtracts", "card-1", eventtest.Refund(at(4, 0), "3000", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE")), nil, "50.00 EUR"},
+		{"second card counts in both", "card-2", eventtest.Payment(at(5, 0), "3000", eventtest.EUR, eventtest.MCC("5499"), eventtest.Merchant("Spar")), []string{"card-2/50"}, "80.00 EUR"},
+		{"reversal undoes the payment", "card-2", eventtest.Reversal(at(5, 5), "3000", eventtest.EUR, eventtest.MCC("5499"), eventtest.Merchant("Spar")), nil, "50.00 EUR"},
+		{"reversed status does not credit twice", "card-2", eventtest.Payment(at(5, 0), "3000", eventtest.EUR, eventtest.Status(gnosispay.CardEventReversed), eventtest.MCC("5499"), eventtest.Merchant("Spar")), nil, "50.00 EUR"},
+		{"crosses 100%", "card-1", eventtest.Payment(at(6, 0), "5000", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE")), []string{"groceries/100"}, "100.00 EUR"},
+		{"next month starts over", "card-1", eventtest.Payment(at(40, 0), "9000", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE")), []string{"groceries/80"}, ""},
+	}
+	for _, s := range steps {
+		rec.got = nil
+		alerts, err := en.Observe(ctx, s.card, s.event)
+		if err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if strings.Join(rec.got, ",") != strings.Join(s.want, ",") || len(alerts) != len(s.want) {
+			t.Errorf("%s: alerts = %v, want %v", s.name, rec.got, s.want)
+		}
+		if s.spent == "" {
+			continue
+		}
+		usage, err := en.Usage(ctx, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := usage[0].Spent.String(); got != s.spent {
+			t.Errorf("%s: groceries spent = %s, want %s", s.name, got, s.spent)
+		}
+	}
+
+	usage, _ := en.Usage(ctx, time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC))
+	if usage[0].Percent != 100 || usage[1].Spent.String() != "0.00 EUR" || usage[1].Period != "2025-01" {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestEngine_retriesFailedNotifications(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "budgets.json")
+	b := Budget{Name: "all", Limit: eurMoney(1000), Thresholds: []int{100}}
+
+	en, _ := NewEngine(b)
+	en.Store = &FileStore{Path: path}
+	en.Notifiers = []Notifier{NotifierFunc(func(context.Context, Alert) error { return errors.New("relay down") })}
+	e := eventtest.Payment(at(2, 0), "1000", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE"))
+	if _, err := en.Observe(ctx, "card-1", e); err == nil || !strings.Contains(err.Error(), "relay down") {
+		t.Fatalf("Observe error = %v, want the notifier error", err)
+	}
+
+	// A new engine resumes from the file and retries on redelivery.
+	en, _ = NewEngine(b)
+	en.Store = &FileStore{Path: path}
+	rec := &recorder{}
+	en.Notifiers = []Notifier{rec}
+	if _, err := en.Observe(ctx, "card-1", e); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := en.Observe(ctx, "card-1", e); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(rec.got, ",") != "all/100" {
+		t.Errorf("alerts after restart = %v, want one all/100", rec.got)
+	}
+}
+
+func TestEngine_prunesOldMonths(t *testing.T) {
+	ctx := context.Background()
+	en, _ := NewEngine(Budget{Name: "all", Limit: eurMoney(10000)})
+	for _, day := range []int{2, 33, 61} { // January, February, March.
+		if _, err := en.Observe(ctx, "card-1", eventtest.Payment(at(day, 0), "100", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var periods []string
+	for _, tally := range en.state.Tallies {
+		periods = append(periods, tally.Period)
+	}
+	if len(periods) != 2 || en.state.Tallies[tallyKey("all", "2025-01")] != nil {
+		t.Errorf("tallies = %v, want February and March", periods)
+	}
+
+	// A late January event is too old to count.
+	if _, err := en.Observe(ctx, "card-1", eventtest.Payment(at(3, 0), "100", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE"))); err != nil {
+		t.Fatal(err)
+	}
+	if en.state.Tallies[tallyKey("all", "2025-01")] != nil {
+		t.Error("an event of a pruned month brought its tally back")
+	}
+}
+
+func TestEngine_invalidAmount(t *testing.T) {
+	ctx := context.Background()
+	en, _ := NewEngine(Budget{Name: "all", Limit: eurMoney(10000)})
+	if _, err := en.Observe(ctx, "card-1", eventtest.Payment(at(2, 0), "12.50", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE"))); err == nil {
+		t.Fatal("Observe() succeeded with an invalid amount")
+	}
+	if _, err := en.Usage(ctx, time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Usage() succeeded with an invalid amount")
+	}
+}
+
+func TestEngine_Location(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	en, _ := NewEngine(Budget{Name: "all", Limit: eurMoney(1000)})
+	en.Location = berlin
+	e := eventtest.Payment(at(31, 0), "100", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE"))
+	e.CreatedAt = time.Date(2025, 1, 31, 23, 30, 0, 0, time.UTC)
+	alerts, _ := en.Observe(context.Background(), "card-1", e)
+	if len(alerts) != 0 {
+		t.Fatalf("alerts = %v", alerts)
+	}
+	usage, _ := en.Usage(context.Background(), time.Date(2025, 2, 1, 12, 0, 0, 0, berlin))
+	if usage[0].Period != "2025-02" || usage[0].Spent.String() != "1.00 EUR" {
+		t.Errorf("usage = %+v", usage[0])
+	}
+}
+
+func TestNewEngine_invalid(t *testing.T) {
+	tests := map[string][]Budget{
+		"no name":            {{Limit: eurMoney(100)}},
+		"zero limit":         {{Name: "a", Limit: eurMoney(0)}},
+		"no currency":        {{Name: "a", Limit: gnosispay.NewMoney(100, gnosispay.Currency{})}},
+		"negative threshold": {{Name: "a", Limit: eurMoney(100), Thresholds: []int{-1}}},
+		"duplicate":          {{Name: "a", Limit: eurMoney(100)}, {Name: "a", Limit: eurMoney(100)}},
+	}
+	for name, budgets := range tests {
+		if _, err := NewEngine(budgets...); err == nil {
+			t.Errorf("%s: NewEngine succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package budget
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Notifier delivers alerts.
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// NotifierFunc adapts a function to a Notifier.
+type NotifierFunc func(ctx context.Context, a Alert) error
+
+func (f NotifierFunc) Notify(ctx context.Context, a Alert) error {
+	return f(ctx, a)
+}
+
+// WriterNotifier writes one line per alert, e.g. to os.Stdout.
+type WriterNotifier struct {
+	W  io.Writer
+	mu sync.Mutex
+}
+
+func (n *WriterNotifier) Notify(ctx context.Context, a Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, err := fmt.Fprintln(n.W, a)
+	return err
+}
+
+// WebhookNotifier POSTs each alert as JSON to URL and expects a 2xx
+// response.
+type WebhookNotifier struct {
+	URL    string
+	Header http.Header  // Extra request headers, e.g. Authorization.
+	Client *http.Client // Defaults to a client with a 10 second timeout.
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range n.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s: unexpected status %s", n.URL, resp.Status)
+	}
+	return nil
+}
+
+// SMTPNotifier mails each alert through an SMTP server, typically a local
+// relay.
+type SMTPNotifier struct {
+	Addr string // host:port
+	From string
+	To   []string
+	Auth smtp.Auth // Optional.
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, a Alert) error {
+	if len(n.To) == 0 {
+		return fmt.Errorf("smtp: no recipients")
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&msg, "Subject: Budget %s reached %d%%\r\n", a.Budget, a.Threshold)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n", a)
+	if a.Event.Merchant != nil && a.Event.Merchant.Name != "" {
+		fmt.Fprintf(&msg, "Last charge: %s on %s\r\n", a.Event.Merchant.Name, a.Event.CreatedAt.Format(time.RFC3339))
+	}
+	return smtp.SendMail(n.Addr, n.Auth, n.From, n.To, []byte(msg.String()))
+}
@@ -0,0 +1,122 @@
+package budget
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guarilha/go-gnosispay/internal/eventtest"
+)
+
+func testAlert() Alert {
+	return Alert{
+		Budget: "groceries", Period: "2025-01", Threshold: 80,
+		Spent: eurMoney(8000), Limit: eurMoney(10000), Card: "card-1",
+		Event: eventtest.Payment(at(2, 0), "8000", eventtest.EUR, eventtest.MCC("5411"), eventtest.Merchant("REWE")),
+	}
+}
+
+func TestWriterNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&WriterNotifier{W: &buf}).Notify(context.Background(), testAlert()); err != nil {
+		t.Fatal(err)
+	}
+	if want := "budget groceries 2025-01: 80% reached (80.00 EUR of 100.00 EUR)\n"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Alert
+	var auth string
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request = %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	n := &WebhookNotifier{URL: srv.URL, Header: http.Header{"Authorization": {"Bearer secret"}}}
+	if err := n.Notify(context.Background(), testAlert()); err != nil {
+		t.Fatal(err)
+	}
+	if got.Budget != "groceries" || got.Spent.String() != "80.00 EUR" || auth != "Bearer secret" {
+		t.Errorf("received %+v with auth %q", got, auth)
+	}
+
+	status = http.StatusBadGateway
+	if err := n.Notify(context.Background(), testAlert()); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("error = %v, want the 502 status", err)
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	data := make(chan string, 1)
+	go serveSMTP(ln, data)
+
+	n := &SMTPNotifier{Addr: ln.Addr().String(), From: "alerts@example.com", To: []string{"finance@example.com"}}
+	if err := n.Notify(context.Background(), testAlert()); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-data
+	for _, want := range []string{"To: finance@example.com", "Subject: Budget groceries reached 80%", "80.00 EUR of 100.00 EUR", "Last charge: REWE"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message lacks %q:\n%s", want, msg)
+		}
+	}
+}
+
+// serveSMTP accepts one session and sends the message body to data.
+func serveSMTP(ln net.Listener, data chan<- string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var msg strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				msg.WriteString(l)
+			}
+			data <- msg.String()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store persists an Engine's running totals between runs.
+type Store interface {
+	// Load returns the saved state, or nil if there is none.
+	Load(ctx context.Context) (*State, error)
+	Save(ctx context.Context, st *State) error
+}
+
+// MemoryStore keeps the state in memory. It is the default store of an
+// Engine.
+type MemoryStore struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (s *MemoryStore) Load(ctx context.Context) (*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data == nil {
+		return nil, nil
+	}
+	var st State
+	if err := json.Unmarshal(s.data, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (s *MemoryStore) Save(ctx context.Context, st *State) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = data
+	return nil
+}
+
+// FileStore keeps the state in a JSON file.
+type FileStore struct {
+	Path string
+}
+
+func (s *FileStore) Load(ctx context.Context) (*State, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("reading budget state %s: %w", s.Path, err)
+	}
+	return &st, nil
+}
+
+// Save replaces the file atomically so a crash never leaves a partial state
+// behind.
+func (s *FileStore) Save(ctx context.Context, st *State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 )
 
 // CardService handles communication with the card related
@@ -16,12 +18,88 @@ type CardService struct {
 // ListTransactionsOptions specifies the optional parameters to the
 // CardService.ListTransactions method.
 type ListTransactionsOptions struct {
-	CardTokens          string `url:"cardTokens,omitempty"`
-	Before              string `url:"before,omitempty"`
-	After               string `url:"after,omitempty"`
-	BillingCurrency     string `url:"billingCurrency,omitempty"`
-	TransactionCurrency string `url:"transactionCurrency,omitempty"`
-	MCC                 string `url:"mcc,omitempty"`
+	// CardTokens restricts the results to the given card IDs.
+	CardTokens []string
+
+	// Before and After bound the creation time of the returned events.
+	// Zero values are not sent.
+	Before time.Time
+	After  time.Time
+
+	// BillingCurrency and TransactionCurrency filter by ISO 4217 code.
+	BillingCurrency     CurrencyCode
+	TransactionCurrency CurrencyCode
+
+	// MCC restricts the results to the given 4-digit merchant category codes.
+	MCC []string
+}
+
+// Validate checks the options for values the API would reject.
+func (o *ListTransactionsOptions) Validate() error {
+	for _, id := range o.CardTokens {
+		if id == "" {
+			return fmt.Errorf("card token cannot be empty")
+		}
+		if strings.Contains(id, ",") {
+			return fmt.Errorf("invalid card token %q: must not contain a comma", id)
+		}
+	}
+	if !o.Before.IsZero() && !o.After.IsZero() && !o.After.Before(o.Before) {
+		return fmt.Errorf("after (%s) must be earlier than before (%s)",
+			o.After.Format(time.RFC3339), o.Before.Format(time.RFC3339))
+	}
+	if o.BillingCurrency != "" {
+		if err := o.BillingCurrency.Validate(); err != nil {
+			return fmt.Errorf("billing currency: %w", err)
+		}
+	}
+	if o.TransactionCurrency != "" {
+		if err := o.TransactionCurrency.Validate(); err != nil {
+			return fmt.Errorf("transaction currency: %w", err)
+		}
+	}
+	for _, mcc := range o.MCC {
+		if !isMCC(mcc) {
+			return fmt.Errorf("invalid MCC %q: must be 4 digits", mcc)
+		}
+	}
+	return nil
+}
+
+// values encodes the options as URL query parameters.
+func (o *ListTransactionsOptions) values() url.Values {
+	v := url.Values{}
+	if len(o.CardTokens) > 0 {
+		v.Set("cardTokens", strings.Join(o.CardTokens, ","))
+	}
+	if !o.Before.IsZero() {
+		v.Set("before", o.Before.UTC().Format(time.RFC3339))
+	}
+	if !o.After.IsZero() {
+		v.Set("after", o.After.UTC().Format(time.RFC3339))
+	}
+	if o.BillingCurrency != "" {
+		v.Set("billingCurrency", o.BillingCurrency.String())
+	}
+	if o.TransactionCurrency != "" {
+		v.Set("transactionCurrency", o.TransactionCurrency.String())
+	}
+	if len(o.MCC) > 0 {
+		v.Set("mcc", strings.Join(o.MCC, ","))
+	}
+	return v
+}
+
+func isMCC(s string) bool {
+	if len(s) != 4 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
 }
 
 // List returns all cards associated with the authenticated user.
@@ -114,27 +192,10 @@ func (s *CardService) ReportStolen(ctx context.Context, cardID string) error {
 func (s *CardService) ListTransactions(ctx context.Context, opts *ListTransactionsOptions) ([]CardEvent, error) {
 	path := "/transactions"
 	if opts != nil {
-		v := url.Values{}
-		if opts.CardTokens != "" {
-			v.Set("cardTokens", opts.CardTokens)
-		}
-		if opts.Before != "" {
-			v.Set("before", opts.Before)
-		}
-		if opts.After != "" {
-			v.Set("after", opts.After)
+		if err := opts.Validate(); err != nil {
+			return nil, err
 		}
-		if opts.BillingCurrency != "" {
-			v.Set("billingCurrency", opts.BillingCurrency)
-		}
-		if opts.TransactionCurrency != "" {
-			v.Set("transactionCurrency", opts.TransactionCurrency)
-		}
-		if opts.MCC != "" {
-			v.Set("mcc", opts.MCC)
-		}
-
-		if len(v) > 0 {
+		if v := opts.values(); len(v) > 0 {
 			path = fmt.Sprintf("%s?%s", path, v.Encode())
 		}
 	}
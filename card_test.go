@@ -0,0 +1,169 @@
+package gnosispay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListTransactionsOptions_Validate(t *testing.T) {
+	jan := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		opts    ListTransactionsOptions
+		wantErr bool
+	}{
+		{
+			name:    "empty options",
+			opts:    ListTransactionsOptions{},
+			wantErr: false,
+		},
+		{
+			name: "all fields set",
+			opts: ListTransactionsOptions{
+				CardTokens:          []string{"card-1", "card-2"},
+				After:               jan,
+				Before:              feb,
+				BillingCurrency:     EUR,
+				TransactionCurrency: USD,
+				MCC:                 []string{"5411", "5812"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "empty card token",
+			opts:    ListTransactionsOptions{CardTokens: []string{""}},
+			wantErr: true,
+		},
+		{
+			name:    "card token with comma",
+			opts:    ListTransactionsOptions{CardTokens: []string{"a,b"}},
+			wantErr: true,
+		},
+		{
+			name:    "after not earlier than before",
+			opts:    ListTransactionsOptions{After: feb, Before: jan},
+			wantErr: true,
+		},
+		{
+			name:    "lower-case currency",
+			opts:    ListTransactionsOptions{BillingCurrency: "eur"},
+			wantErr: true,
+		},
+		{
+			name:    "short currency",
+			opts:    ListTransactionsOptions{TransactionCurrency: "EU"},
+			wantErr: true,
+		},
+		{
+			name:    "non-numeric MCC",
+			opts:    ListTransactionsOptions{MCC: []string{"54a1"}},
+			wantErr: true,
+		},
+		{
+			name:    "short MCC",
+			opts:    ListTransactionsOptions{MCC: []string{"541"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListTransactionsOptions_values(t *testing.T) {
+	berlin := time.FixedZone("CET", 60*60)
+
+	tests := []struct {
+		name string
+		opts ListTransactionsOptions
+		want string
+	}{
+		{
+			name: "empty options",
+			opts: ListTransactionsOptions{},
+			want: "",
+		},
+		{
+			name: "card tokens are comma separated",
+			opts: ListTransactionsOptions{CardTokens: []string{"card-1", "card-2"}},
+			want: "cardTokens=card-1%2Ccard-2",
+		},
+		{
+			name: "times are formatted as RFC 3339 in UTC",
+			opts: ListTransactionsOptions{
+				After:  time.Date(2025, 1, 1, 1, 0, 0, 0, berlin),
+				Before: time.Date(2025, 2, 1, 12, 30, 0, 0, time.UTC),
+			},
+			want: "after=2025-01-01T00%3A00%3A00Z&before=2025-02-01T12%3A30%3A00Z",
+		},
+		{
+			name: "currencies and MCCs",
+			opts: ListTransactionsOptions{
+				BillingCurrency:     EUR,
+				TransactionCurrency: GBP,
+				MCC:                 []string{"5411", "5812"},
+			},
+			want: "billingCurrency=EUR&mcc=5411%2C5812&transactionCurrency=GBP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.values().Encode(); got != tt.want {
+				t.Errorf("values() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCardService_ListTransactions(t *testing.T) {
+	var gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		json.NewEncoder(w).Encode([]CardEvent{{Kind: "Payment", Mcc: "5411"}})
+	}))
+	defer server.Close()
+
+	client, _ := New(nil, SetBaseURL(server.URL))
+
+	t.Run("sends encoded filters", func(t *testing.T) {
+		events, err := client.Cards.ListTransactions(context.Background(), &ListTransactionsOptions{
+			CardTokens: []string{"card-1"},
+			MCC:        []string{"5411"},
+		})
+		if err != nil {
+			t.Fatalf("ListTransactions() error = %v", err)
+		}
+		if want := "cardTokens=card-1&mcc=5411"; gotQuery != want {
+			t.Errorf("ListTransactions() query = %v, want %v", gotQuery, want)
+		}
+		if len(events) != 1 || events[0].Mcc != "5411" {
+			t.Errorf("ListTransactions() events = %+v", events)
+		}
+	})
+
+	t.Run("rejects invalid filters before sending", func(t *testing.T) {
+		gotQuery = "untouched"
+		_, err := client.Cards.ListTransactions(context.Background(), &ListTransactionsOptions{
+			MCC: []string{"food"},
+		})
+		if err == nil {
+			t.Fatal("ListTransactions() expected error")
+		}
+		if gotQuery != "untouched" {
+			t.Errorf("ListTransactions() sent a request with invalid filters")
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package gnosispay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const defaultBatchConcurrency = 4
+
+// BatchOptions configures the CardService batch methods.
+type BatchOptions struct {
+	// Concurrency is the number of cards processed at once. Defaults to 4.
+	// Requests still go through the client's rate limit, if one is set.
+	Concurrency int
+
+	// DryRun fetches each card's status and checks the transition, but
+	// does not change any card.
+	DryRun bool
+}
+
+// BatchResult is the outcome of a batch operation for one card.
+type BatchResult struct {
+	CardID string
+
+	// Status is the card's status before any change was made. It is nil if
+	// the status could not be fetched.
+	Status *CardStatus
+
+	// Changed reports whether the card was moved to the target state, or
+	// would have been in a dry run. It is false for cards already in the
+	// target state.
+	Changed bool
+
+	Err error
+}
+
+// BatchError is returned by the batch methods when one or more cards
+// failed. It unwraps to the per-card errors.
+type BatchError struct {
+	Total  int
+	Failed []BatchResult
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, r := range e.Failed {
+		msgs[i] = fmt.Sprintf("card %s: %v", r.CardID, r.Err)
+	}
+	return fmt.Sprintf("%d of %d cards failed: %s", len(e.Failed), e.Total, strings.Join(msgs, "; "))
+}
+
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, r := range e.Failed {
+		errs[i] = r.Err
+	}
+	return errs
+}
+
+// FreezeMany freezes the given cards. Cards that are already frozen are
+// left alone; cards that cannot be frozen fail with a *TransitionError.
+//
+// The results are in the order of cardIDs. If any card failed the error is
+// a *BatchError; the other cards are processed regardless.
+func (s *CardService) FreezeMany(ctx context.Context, cardIDs []string, opts *BatchOptions) ([]BatchResult, error) {
+	return s.transitionMany(ctx, cardIDs, CardStateFrozen, opts)
+}
+
+// UnfreezeMany unfreezes the given cards, as FreezeMany freezes them.
+func (s *CardService) UnfreezeMany(ctx context.Context, cardIDs []string, opts *BatchOptions) ([]BatchResult, error) {
+	return s.transitionMany(ctx, cardIDs, CardStateActive, opts)
+}
+
+// GetStatusMany retrieves the status of the given cards. The results are
+// in the order of cardIDs. If any card failed the error is a *BatchError.
+// DryRun has no effect.
+func (s *CardService) GetStatusMany(ctx context.Context, cardIDs []string, opts *BatchOptions) ([]BatchResult, error) {
+	return runBatch(ctx, cardIDs, opts, func(ctx context.Context, r *BatchResult) error {
+		var err error
+		r.Status, err = s.GetStatus(ctx, r.CardID)
+		return err
+	})
+}
+
+func (s *CardService) transitionMany(ctx context.Context, cardIDs []string, target CardState, opts *BatchOptions) ([]BatchResult, error) {
+	dryRun := opts != nil && opts.DryRun
+	return runBatch(ctx, cardIDs, opts, func(ctx context.Context, r *BatchResult) error {
+		var err error
+		if r.Status, err = s.GetStatus(ctx, r.CardID); err != nil {
+			return err
+		}
+		from := r.Status.State()
+		if from == target {
+			return nil
+		}
+		action, err := TransitionAction(from, target)
+		if err != nil {
+			return err
+		}
+		if !dryRun {
+			switch action {
+			case CardActionFreeze:
+				err = s.Freeze(ctx, r.CardID)
+			case CardActionUnfreeze:
+				err = s.Unfreeze(ctx, r.CardID)
+			default:
+				err = fmt.Errorf("unexpected card action %q", action)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		r.Changed = true
+		return nil
+	})
+}
+
+// runBatch calls fn for every card with at most opts.Concurrency calls in
+// flight. Cards not yet started when ctx is done fail with ctx.Err().
+func runBatch(ctx context.Context, cardIDs []string, opts *BatchOptions, fn func(context.Context, *BatchResult) error) ([]BatchResult, error) {
+	n := defaultBatchConcurrency
+	if opts != nil && opts.Concurrency > 0 {
+		n = opts.Concurrency
+	}
+
+	results := make([]BatchResult, len(cardIDs))
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for i, id := range cardIDs {
+		r := &results[i]
+		r.CardID = id
+		if id == "" {
+			r.Err = errors.New("card ID cannot be empty")
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			r.Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			r.Err = fn(ctx, r)
+		}()
+	}
+	wg.Wait()
+
+	var failed []BatchResult
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) > 0 {
+		return results, &BatchError{Total: len(results), Failed: failed}
+	}
+	return results, nil
+}
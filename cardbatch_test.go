@@ -0,0 +1,152 @@
+package gnosispay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// batchServer serves card statuses from a map and records mutations.
+type batchServer struct {
+	mu       sync.Mutex
+	statuses map[string]CardStatus
+	calls    []string
+
+	inFlight, maxInFlight atomic.Int32
+}
+
+func (b *batchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := b.inFlight.Add(1)
+	defer b.inFlight.Add(-1)
+	for {
+		m := b.maxInFlight.Load()
+		if n <= m || b.maxInFlight.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/cards/"), "/")
+	id, action := parts[0], parts[1]
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st, ok := b.statuses[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ApiError{Message: "card not found"})
+		return
+	}
+	if r.Method == http.MethodGet {
+		json.NewEncoder(w).Encode(st)
+		return
+	}
+	b.calls = append(b.calls, id+" "+action)
+	st.IsFrozen = action == "freeze"
+	b.statuses[id] = st
+}
+
+func TestCardService_FreezeMany(t *testing.T) {
+	active := CardStatus{ActivatedAt: "2025-01-01"}
+	frozen := CardStatus{ActivatedAt: "2025-01-01", IsFrozen: true}
+	stolen := CardStatus{ActivatedAt: "2025-01-01", IsStolen: true}
+
+	newServer := func() (*batchServer, *Client) {
+		b := &batchServer{statuses: map[string]CardStatus{
+			"a": active, "b": frozen, "c": stolen, "d": active, "e": active,
+		}}
+		server := httptest.NewServer(b)
+		t.Cleanup(server.Close)
+		client, _ := New(nil, SetBaseURL(server.URL))
+		return b, client
+	}
+	ids := []string{"a", "b", "c", "missing", "d", "e"}
+
+	t.Run("reports per-card results", func(t *testing.T) {
+		b, client := newServer()
+		results, err := client.Cards.FreezeMany(context.Background(), ids, &BatchOptions{Concurrency: 2})
+
+		var berr *BatchError
+		if !errors.As(err, &berr) || berr.Total != 6 || len(berr.Failed) != 2 {
+			t.Fatalf("FreezeMany() error = %v, want *BatchError with 2 of 6 failed", err)
+		}
+		var terr *TransitionError
+		if !errors.As(err, &terr) || terr.From != CardStateStolen {
+			t.Errorf("FreezeMany() error does not unwrap to the stolen card's *TransitionError")
+		}
+
+		wantChanged := []bool{true, false, false, false, true, true}
+		for i, r := range results {
+			if r.CardID != ids[i] {
+				t.Errorf("results[%d].CardID = %q, want %q", i, r.CardID, ids[i])
+			}
+			if r.Changed != wantChanged[i] {
+				t.Errorf("results[%d].Changed = %v, want %v", i, r.Changed, wantChanged[i])
+			}
+			if (r.Err != nil) != (ids[i] == "c" || ids[i] == "missing") {
+				t.Errorf("results[%d].Err = %v", i, r.Err)
+			}
+		}
+		if len(b.calls) != 3 {
+			t.Errorf("freeze calls = %v, want a, d and e", b.calls)
+		}
+		if m := b.maxInFlight.Load(); m > 2 {
+			t.Errorf("max concurrent requests = %d, want at most 2", m)
+		}
+	})
+
+	t.Run("dry run changes nothing", func(t *testing.T) {
+		b, client := newServer()
+		results, err := client.Cards.FreezeMany(context.Background(), []string{"a", "b"}, &BatchOptions{DryRun: true})
+		if err != nil {
+			t.Fatalf("FreezeMany() error = %v", err)
+		}
+		if !results[0].Changed || results[1].Changed {
+			t.Errorf("FreezeMany() results = %+v", results)
+		}
+		if len(b.calls) != 0 {
+			t.Errorf("dry run made calls %v", b.calls)
+		}
+	})
+
+	t.Run("unfreeze", func(t *testing.T) {
+		b, client := newServer()
+		if _, err := client.Cards.UnfreezeMany(context.Background(), []string{"a", "b"}, nil); err != nil {
+			t.Fatalf("UnfreezeMany() error = %v", err)
+		}
+		if len(b.calls) != 1 || b.calls[0] != "b unfreeze" {
+			t.Errorf("unfreeze calls = %v, want [b unfreeze]", b.calls)
+		}
+	})
+
+	t.Run("status", func(t *testing.T) {
+		_, client := newServer()
+		results, err := client.Cards.GetStatusMany(context.Background(), []string{"b", "c"}, nil)
+		if err != nil {
+			t.Fatalf("GetStatusMany() error = %v", err)
+		}
+		if results[0].Status.State() != CardStateFrozen || results[1].Status.State() != CardStateStolen {
+			t.Errorf("GetStatusMany() results = %+v", results)
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		b, client := newServer()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := client.Cards.FreezeMany(ctx, []string{"a", "d"}, nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("FreezeMany() error = %v, want context.Canceled", err)
+		}
+		if len(b.calls) != 0 {
+			t.Errorf("cancelled batch made calls %v", b.calls)
+		}
+	})
+}
@@ -0,0 +1,265 @@
+package gnosispay
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CardEventKind is the kind of a card event.
+type CardEventKind string
+
+// Card event kinds.
+const (
+	CardEventPayment  CardEventKind = "Payment"
+	CardEventRefund   CardEventKind = "Refund"
+	CardEventReversal CardEventKind = "Reversal"
+)
+
+var cardEventKinds = []CardEventKind{CardEventPayment, CardEventRefund, CardEventReversal}
+
+// IsCredit reports whether events of kind k move money back to the
+// cardholder.
+func (k CardEventKind) IsCredit() bool {
+	return k == CardEventRefund || k == CardEventReversal
+}
+
+// UnmarshalJSON decodes a kind, matching the known kinds case-insensitively.
+// Unknown kinds are kept as they are.
+func (k *CardEventKind) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*k = CardEventKind(canonical(s, cardEventKinds))
+	return nil
+}
+
+// CardEventStatus is the outcome of a payment. Refunds and reversals carry
+// no status.
+type CardEventStatus string
+
+// Card event statuses. All but Approved, PartialReversal and Reversal mean
+// the payment was declined, for the reason the status names.
+const (
+	CardEventApproved              CardEventStatus = "Approved"
+	CardEventPartialReversal       CardEventStatus = "PartialReversal" // Approved; part of the amount was returned.
+	CardEventReversed              CardEventStatus = "Reversal"        // Approved, then fully undone.
+	CardEventIncorrectPIN          CardEventStatus = "IncorrectPin"
+	CardEventInsufficientFunds     CardEventStatus = "InsufficientFunds"
+	CardEventInvalidAmount         CardEventStatus = "InvalidAmount"
+	CardEventPINTriesExceeded      CardEventStatus = "PinEntryTriesExceeded"
+	CardEventIncorrectSecurityCode CardEventStatus = "IncorrectSecurityCode"
+	CardEventOther                 CardEventStatus = "Other"
+)
+
+var cardEventStatuses = []CardEventStatus{
+	CardEventApproved, CardEventPartialReversal, CardEventReversed,
+	CardEventIncorrectPIN, CardEventInsufficientFunds, CardEventInvalidAmount,
+	CardEventPINTriesExceeded, CardEventIncorrectSecurityCode, CardEventOther,
+}
+
+// declineReasons describes the statuses of declined payments.
+var declineReasons = map[CardEventStatus]string{
+	CardEventIncorrectPIN:          "incorrect PIN",
+	CardEventInsufficientFunds:     "insufficient funds",
+	CardEventInvalidAmount:         "invalid amount",
+	CardEventPINTriesExceeded:      "too many PIN attempts",
+	CardEventIncorrectSecurityCode: "incorrect security code",
+	CardEventOther:                 "declined",
+}
+
+// Declined reports whether s means the payment was declined. An empty
+// status, as on refunds and reversals, is not a decline.
+func (s CardEventStatus) Declined() bool {
+	switch s {
+	case "", CardEventApproved, CardEventPartialReversal, CardEventReversed:
+		return false
+	}
+	return true
+}
+
+// DeclineReason describes why a payment with status s was declined, or
+// returns "" if it was not.
+func (s CardEventStatus) DeclineReason() string {
+	if !s.Declined() {
+		return ""
+	}
+	if r, ok := declineReasons[s]; ok {
+		return r
+	}
+	return fmt.Sprintf("declined (%s)", string(s))
+}
+
+// UnmarshalJSON decodes a status, matching the known statuses
+// case-insensitively. Unknown statuses are kept as they are.
+func (s *CardEventStatus) UnmarshalJSON(data []byte) error {
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = CardEventStatus(canonical(v, cardEventStatuses))
+	return nil
+}
+
+func canonical[T ~string](s string, known []T) string {
+	for _, k := range known {
+		if strings.EqualFold(s, string(k)) {
+			return string(k)
+		}
+	}
+	return s
+}
+
+// UnmarshalJSON decodes an event, treating a zero clearing time the same
+// as a missing one.
+func (e *CardEvent) UnmarshalJSON(data []byte) error {
+	type plain CardEvent
+	if err := json.Unmarshal(data, (*plain)(e)); err != nil {
+		return err
+	}
+	if e.ClearedAt != nil && e.ClearedAt.IsZero() {
+		e.ClearedAt = nil
+	}
+	return nil
+}
+
+// ID returns an identifier for the event that stays the same across
+// fetches and when a pending event clears. Unlike Key, it leaves out the
+// transaction hashes and amounts, which may change on clearing, and is
+// derived from the creation time, kind and ThreadID.
+func (e CardEvent) ID() string {
+	h := sha256.New()
+	h.Write([]byte(e.CreatedAt.UTC().Format(time.RFC3339Nano)))
+	h.Write([]byte{0})
+	h.Write([]byte(e.Kind))
+	h.Write([]byte{0})
+	h.Write([]byte(e.ThreadID))
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// Declined reports whether the event is a declined payment.
+func (e CardEvent) Declined() bool {
+	return e.Kind == CardEventPayment && e.Status.Declined()
+}
+
+// Counts reports whether the event moved money: approved payments, refunds
+// and reversals do, declined and reversed payments do not. Events of
+// unknown kinds count like payments.
+func (e CardEvent) Counts() bool {
+	switch e.Kind {
+	case CardEventRefund, CardEventReversal:
+		return true
+	}
+	return !e.Status.Declined() && e.Status != CardEventReversed
+}
+
+// SignedAmount returns the billing amount from the cardholder's point of
+// view: negative for payments, positive for refunds and reversals, and
+// zero for events that did not move money (see Counts).
+//
+// A reversal may be reported as a Reversal event, as the payment's
+// Reversal status, or both. Summing SignedAmount over events nets out in
+// the first two cases; use NetAmounts when both may occur.
+func (e CardEvent) SignedAmount() (Money, error) {
+	m, err := e.BillingMoney()
+	if err != nil {
+		return Money{}, err
+	}
+	switch {
+	case !e.Counts():
+		return NewMoney(0, m.Currency), nil
+	case e.Kind.IsCredit():
+		return m.Abs(), nil
+	default:
+		return m.Abs().Neg(), nil
+	}
+}
+
+// Original returns the payment a refund or reversal belongs to, searching
+// events. Events of the same thread are linked by ThreadID; without one,
+// the original is the latest earlier payment at the same merchant, of the
+// same amount for reversals and of at least the refunded amount for
+// refunds. It returns false for payments and when nothing matches.
+func (e CardEvent) Original(events []CardEvent) (CardEvent, bool) {
+	if !e.Kind.IsCredit() {
+		return CardEvent{}, false
+	}
+	amount, amountErr := e.BillingMoney()
+
+	var best CardEvent
+	found := false
+	for _, c := range events {
+		if c.Kind != CardEventPayment || c.CreatedAt.After(e.CreatedAt) {
+			continue
+		}
+		if e.ThreadID != "" || c.ThreadID != "" {
+			if c.ThreadID != e.ThreadID {
+				continue
+			}
+		} else {
+			if amountErr != nil || !sameMerchant(c, e) {
+				continue
+			}
+			paid, err := c.BillingMoney()
+			if err != nil {
+				continue
+			}
+			cmp, err := paid.Abs().Cmp(amount.Abs())
+			if err != nil || cmp < 0 || (e.Kind == CardEventReversal && cmp != 0) {
+				continue
+			}
+		}
+		if !found || c.CreatedAt.After(best.CreatedAt) {
+			best, found = c, true
+		}
+	}
+	return best, found
+}
+
+func sameMerchant(a, b CardEvent) bool {
+	if a.Merchant == nil || b.Merchant == nil {
+		return false
+	}
+	norm := func(s string) string { return strings.ToUpper(strings.Join(strings.Fields(s), " ")) }
+	return norm(a.Merchant.Name) != "" && norm(a.Merchant.Name) == norm(b.Merchant.Name)
+}
+
+// DoubleCounted reports whether e is a Reversal event whose original
+// payment, found among events, already has the Reversal status. Summing
+// SignedAmount over both would credit the reversal twice.
+func (e CardEvent) DoubleCounted(events []CardEvent) bool {
+	if e.Kind != CardEventReversal {
+		return false
+	}
+	orig, ok := e.Original(events)
+	return ok && orig.Status == CardEventReversed
+}
+
+// NetAmounts sums SignedAmount per billing currency, skipping events that
+// are DoubleCounted.
+func NetAmounts(events []CardEvent) (map[CurrencyCode]Money, error) {
+	totals := make(map[CurrencyCode]Money)
+	for _, e := range events {
+		if e.DoubleCounted(events) {
+			continue
+		}
+		m, err := e.SignedAmount()
+		if err != nil {
+			return nil, fmt.Errorf("event at %s: %w", e.CreatedAt.Format(time.RFC3339), err)
+		}
+		code := CurrencyCode(m.Currency.Code)
+		sum, ok := totals[code]
+		if !ok {
+			totals[code] = m
+			continue
+		}
+		if totals[code], err = sum.Add(m); err != nil {
+			return nil, err
+		}
+	}
+	return totals, nil
+}
@@ -0,0 +1,216 @@
+package gnosispay
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCardEvent_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		json        string
+		wantKind    CardEventKind
+		wantStatus  CardEventStatus
+		wantCleared bool
+	}{
+		{"canonical", `{"kind":"Payment","status":"Approved","clearedAt":"2025-01-02T00:00:00Z"}`, CardEventPayment, CardEventApproved, true},
+		{"other case", `{"kind":"refund","status":"INSUFFICIENTFUNDS"}`, CardEventRefund, CardEventInsufficientFunds, false},
+		{"unknown values are kept", `{"kind":"Chargeback","status":"Disputed"}`, "Chargeback", "Disputed", false},
+		{"null clearing time", `{"kind":"Payment","clearedAt":null}`, CardEventPayment, "", false},
+		{"zero clearing time", `{"kind":"Payment","clearedAt":"0001-01-01T00:00:00Z"}`, CardEventPayment, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e CardEvent
+			if err := json.Unmarshal([]byte(tt.json), &e); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if e.Kind != tt.wantKind || e.Status != tt.wantStatus {
+				t.Errorf("Unmarshal() kind, status = %q, %q, want %q, %q", e.Kind, e.Status, tt.wantKind, tt.wantStatus)
+			}
+			if (e.ClearedAt != nil) != tt.wantCleared {
+				t.Errorf("Unmarshal() ClearedAt = %v, want set %v", e.ClearedAt, tt.wantCleared)
+			}
+		})
+	}
+}
+
+func TestCardEvent_ID(t *testing.T) {
+	eur := &Currency{Code: "EUR", Decimals: 2}
+	pending := CardEvent{
+		CreatedAt:       time.Date(2025, 1, 3, 9, 15, 0, 0, time.UTC),
+		Kind:            CardEventPayment,
+		Status:          CardEventApproved,
+		ThreadID:        "thread-1",
+		IsPending:       true,
+		BillingAmount:   "4000",
+		BillingCurrency: eur,
+	}
+	cleared := pending
+	cleared.IsPending = false
+	cleared.ClearedAt = &time.Time{}
+	cleared.BillingAmount = "4235"
+	cleared.Transactions = []Transaction{{Hash: "0xabc"}}
+	cleared.CreatedAt = pending.CreatedAt.In(time.FixedZone("CET", 3600))
+	if pending.ID() != cleared.ID() {
+		t.Errorf("ID() changed when the event cleared")
+	}
+	if pending.Key() == cleared.Key() {
+		t.Errorf("Key() = %q for both; the test no longer covers a changing key", pending.Key())
+	}
+
+	refund := pending
+	refund.Kind = CardEventRefund
+	other := pending
+	other.ThreadID = "thread-2"
+	later := pending
+	later.CreatedAt = later.CreatedAt.Add(time.Millisecond)
+	seen := map[string]bool{}
+	for _, e := range []CardEvent{pending, refund, other, later} {
+		if seen[e.ID()] {
+			t.Errorf("ID() collision for %+v", e)
+		}
+		seen[e.ID()] = true
+	}
+}
+
+func TestCardEventStatus_DeclineReason(t *testing.T) {
+	tests := []struct {
+		status       CardEventStatus
+		wantDeclined bool
+		wantReason   string
+	}{
+		{"", false, ""},
+		{CardEventApproved, false, ""},
+		{CardEventPartialReversal, false, ""},
+		{CardEventReversed, false, ""},
+		{CardEventIncorrectPIN, true, "incorrect PIN"},
+		{CardEventInsufficientFunds, true, "insufficient funds"},
+		{CardEventOther, true, "declined"},
+		{"CardExpired", true, "declined (CardExpired)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.Declined(); got != tt.wantDeclined {
+			t.Errorf("%q.Declined() = %v, want %v", tt.status, got, tt.wantDeclined)
+		}
+		if got := tt.status.DeclineReason(); got != tt.wantReason {
+			t.Errorf("%q.DeclineReason() = %q, want %q", tt.status, got, tt.wantReason)
+		}
+	}
+}
+
+func cardEvent(minute int, kind CardEventKind, status CardEventStatus, merchant, amount string) CardEvent {
+	return CardEvent{
+		Kind:            kind,
+		Status:          status,
+		CreatedAt:       time.Date(2025, 1, 1, 12, minute, 0, 0, time.UTC),
+		Merchant:        &Merchant{Name: merchant},
+		BillingAmount:   amount,
+		BillingCurrency: &Currency{Code: "EUR", Decimals: 2},
+	}
+}
+
+func TestCardEvent_SignedAmount(t *testing.T) {
+	tests := []struct {
+		name  string
+		event CardEvent
+		want  string
+	}{
+		{"payment", cardEvent(0, CardEventPayment, CardEventApproved, "Rewe", "1250"), "-12.50"},
+		{"partially reversed payment", cardEvent(0, CardEventPayment, CardEventPartialReversal, "Rewe", "1000"), "-10.00"},
+		{"declined payment", cardEvent(0, CardEventPayment, CardEventInsufficientFunds, "Rewe", "1250"), "0.00"},
+		{"reversed payment", cardEvent(0, CardEventPayment, CardEventReversed, "Rewe", "1250"), "0.00"},
+		{"refund", cardEvent(0, CardEventRefund, "", "Rewe", "1250"), "12.50"},
+		{"negative refund amount", cardEvent(0, CardEventRefund, "", "Rewe", "-1250"), "12.50"},
+		{"reversal", cardEvent(0, CardEventReversal, "", "Rewe", "1250"), "12.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.event.SignedAmount()
+			if err != nil {
+				t.Fatalf("SignedAmount() error = %v", err)
+			}
+			if got.Decimal() != tt.want {
+				t.Errorf("SignedAmount() = %s, want %s", got.Decimal(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCardEvent_Original(t *testing.T) {
+	coffee := cardEvent(0, CardEventPayment, CardEventApproved, "Café Einstein", "450")
+	shoes := cardEvent(1, CardEventPayment, CardEventApproved, "Zalando", "8999")
+	shoes2 := cardEvent(2, CardEventPayment, CardEventApproved, "Zalando", "4999")
+	threaded := cardEvent(3, CardEventPayment, CardEventApproved, "Zalando", "2000")
+	threaded.ThreadID = "thread-1"
+
+	refund := cardEvent(10, CardEventRefund, "", "ZALANDO", "4999")
+	partial := cardEvent(11, CardEventRefund, "", "Zalando", "6000")
+	reversal := cardEvent(12, CardEventReversal, "", "Café  Einstein", "450")
+	linked := cardEvent(13, CardEventRefund, "", "Zalando SE", "2000")
+	linked.ThreadID = "thread-1"
+	orphan := cardEvent(14, CardEventReversal, "", "Zalando", "1")
+	early := cardEvent(-1, CardEventRefund, "", "Zalando", "100")
+
+	events := []CardEvent{coffee, shoes, shoes2, threaded, refund, partial, reversal, linked, orphan, early}
+	tests := []struct {
+		name  string
+		event CardEvent
+		want  *CardEvent
+	}{
+		{"refund matches the latest payment covering it", refund, &shoes2},
+		{"partial refund skips smaller payments", partial, &shoes},
+		{"reversal matches amount and normalized merchant", reversal, &coffee},
+		{"thread ID wins over merchant", linked, &threaded},
+		{"reversal without a matching amount", orphan, nil},
+		{"refund before any payment", early, nil},
+		{"payments have no original", shoes, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.event.Original(events)
+			if ok != (tt.want != nil) {
+				t.Fatalf("Original() ok = %v, want %v", ok, tt.want != nil)
+			}
+			if ok && !got.CreatedAt.Equal(tt.want.CreatedAt) {
+				t.Errorf("Original() = event at %s, want %s", got.CreatedAt, tt.want.CreatedAt)
+			}
+		})
+	}
+}
+
+func TestNetAmounts(t *testing.T) {
+	gbp := cardEvent(9, CardEventPayment, CardEventApproved, "Pret", "500")
+	gbp.BillingCurrency = &Currency{Code: "GBP", Decimals: 2}
+
+	events := []CardEvent{
+		// Reversed by an event only: nets to zero.
+		cardEvent(0, CardEventPayment, CardEventApproved, "BVG", "300"),
+		cardEvent(1, CardEventReversal, "", "BVG", "300"),
+		// Reversed by status and event: the event is not credited again.
+		cardEvent(2, CardEventPayment, CardEventReversed, "DB", "2000"),
+		cardEvent(3, CardEventReversal, "", "DB", "2000"),
+		// Partly refunded.
+		cardEvent(4, CardEventPayment, CardEventApproved, "Zalando", "8999"),
+		cardEvent(5, CardEventRefund, "", "Zalando", "2999"),
+		// Declined.
+		cardEvent(6, CardEventPayment, CardEventIncorrectPIN, "Rewe", "1000"),
+		gbp,
+	}
+
+	totals, err := NetAmounts(events)
+	if err != nil {
+		t.Fatalf("NetAmounts() error = %v", err)
+	}
+	if got := totals[EUR].Decimal(); got != "-60.00" {
+		t.Errorf("NetAmounts() EUR = %s, want -60.00", got)
+	}
+	if got := totals[GBP].Decimal(); got != "-5.00" {
+		t.Errorf("NetAmounts() GBP = %s, want -5.00", got)
+	}
+}
@@ -0,0 +1,250 @@
+package gnosispay
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/guarilha/go-gnosispay/wallet"
+)
+
+// CardLimits are the spending and ATM withdrawal limits of a card. All
+// four limits are required and must share one currency.
+type CardLimits struct {
+	Daily      Money
+	Monthly    Money
+	ATMDaily   Money
+	ATMMonthly Money
+}
+
+// cardLimitsJSON is the wire format of CardLimits: amounts in minor units
+// alongside a single ISO 4217 currency code.
+type cardLimitsJSON struct {
+	Currency   CurrencyCode `json:"currency"`
+	Daily      string       `json:"dailyLimit"`
+	Monthly    string       `json:"monthlyLimit"`
+	ATMDaily   string       `json:"atmDailyLimit"`
+	ATMMonthly string       `json:"atmMonthlyLimit"`
+}
+
+// Validate checks that every limit is set, non-negative and in the same
+// currency, and that no daily limit exceeds its monthly counterpart.
+func (l *CardLimits) Validate() error {
+	named := []struct {
+		name string
+		m    Money
+	}{
+		{"daily limit", l.Daily},
+		{"monthly limit", l.Monthly},
+		{"ATM daily limit", l.ATMDaily},
+		{"ATM monthly limit", l.ATMMonthly},
+	}
+	for _, n := range named {
+		if n.m.Currency.Code == "" {
+			return fmt.Errorf("%s is not set", n.name)
+		}
+		if err := CurrencyCode(n.m.Currency.Code).Validate(); err != nil {
+			return fmt.Errorf("%s: %w", n.name, err)
+		}
+		if !n.m.SameCurrency(l.Daily) {
+			return fmt.Errorf("%s is in %s, want %s like the daily limit", n.name, n.m.Currency.Code, l.Daily.Currency.Code)
+		}
+		if n.m.Sign() < 0 {
+			return fmt.Errorf("%s cannot be negative", n.name)
+		}
+	}
+	if c, _ := l.Daily.Cmp(l.Monthly); c > 0 {
+		return fmt.Errorf("daily limit %s exceeds monthly limit %s", l.Daily, l.Monthly)
+	}
+	if c, _ := l.ATMDaily.Cmp(l.ATMMonthly); c > 0 {
+		return fmt.Errorf("ATM daily limit %s exceeds ATM monthly limit %s", l.ATMDaily, l.ATMMonthly)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the limits in the API's format.
+func (l CardLimits) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cardLimitsJSON{
+		Currency:   CurrencyCode(l.Daily.Currency.Code),
+		Daily:      l.Daily.MinorUnits().String(),
+		Monthly:    l.Monthly.MinorUnits().String(),
+		ATMDaily:   l.ATMDaily.MinorUnits().String(),
+		ATMMonthly: l.ATMMonthly.MinorUnits().String(),
+	})
+}
+
+// UnmarshalJSON decodes limits in the API's format. A limit that is absent
+// or empty is left unset.
+func (l *CardLimits) UnmarshalJSON(data []byte) error {
+	var v cardLimitsJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	cur := v.Currency.Currency()
+	var parsed CardLimits
+	for _, f := range []struct {
+		dst *Money
+		src string
+	}{
+		{&parsed.Daily, v.Daily},
+		{&parsed.Monthly, v.Monthly},
+		{&parsed.ATMDaily, v.ATMDaily},
+		{&parsed.ATMMonthly, v.ATMMonthly},
+	} {
+		if f.src == "" {
+			continue
+		}
+		m, err := ParseMinorUnits(f.src, cur)
+		if err != nil {
+			return err
+		}
+		*f.dst = m
+	}
+	*l = parsed
+	return nil
+}
+
+// CardLimitsTransactionData is what the Safe owner must sign to change a
+// card's limits.
+type CardLimitsTransactionData struct {
+	// RequiresSignature reports whether the change has to be authorized
+	// on-chain. Lowering limits usually takes effect without one.
+	RequiresSignature bool `json:"requiresSignature"`
+
+	// TypedData is the EIP-712 payload to sign as JSON, set when
+	// RequiresSignature is true. wallet.SignTypedData signs it as is.
+	TypedData json.RawMessage `json:"typedData,omitempty"`
+}
+
+// CardLimitsChange is the outcome of a limits change.
+type CardLimitsChange struct {
+	// Limits are the limits in effect after the request.
+	Limits CardLimits `json:"limits"`
+
+	// Pending is the Safe transaction queued in the delay module when the
+	// change had to be authorized on-chain. The new limits apply once it is
+	// executed, after its ReadyAt time. It is nil when the change applied
+	// immediately.
+	Pending *DelayTransaction `json:"delayTransaction,omitempty"`
+}
+
+// GetLimits retrieves the spending and ATM limits of a card.
+func (s *CardService) GetLimits(ctx context.Context, cardID string) (*CardLimits, error) {
+	path := fmt.Sprintf("/api/v1/cards/%s/limits", cardID)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var limits CardLimits
+	if err := s.client.Do(ctx, req, &limits); err != nil {
+		return nil, err
+	}
+
+	return &limits, nil
+}
+
+// GetLimitsTransactionData retrieves the data the Safe owner must sign to
+// change a card's limits, if any.
+func (s *CardService) GetLimitsTransactionData(ctx context.Context, cardID string, limits CardLimits) (*CardLimitsTransactionData, error) {
+	if err := limits.Validate(); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("/api/v1/cards/%s/limits/transaction-data", cardID)
+	req, err := s.client.NewRequest(ctx, http.MethodPost, path, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	var data CardLimitsTransactionData
+	if err := s.client.Do(ctx, req, &data); err != nil {
+		return nil, err
+	}
+	if data.RequiresSignature && len(data.TypedData) == 0 {
+		return nil, fmt.Errorf("limits change requires a signature but no typed data was returned")
+	}
+
+	return &data, nil
+}
+
+// SetLimits submits new limits for a card. signature is the Safe owner's
+// signature over the typed data from GetLimitsTransactionData, or empty if
+// none is required.
+func (s *CardService) SetLimits(ctx context.Context, cardID string, limits CardLimits, signature string) (*CardLimitsChange, error) {
+	if err := limits.Validate(); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("/api/v1/cards/%s/limits", cardID)
+	req, err := s.client.NewRequest(ctx, http.MethodPut, path, struct {
+		Limits    CardLimits `json:"limits"`
+		Signature string     `json:"signature,omitempty"`
+	}{
+		Limits:    limits,
+		Signature: signature,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var change CardLimitsChange
+	if err := s.client.Do(ctx, req, &change); err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// SetLimitsWithPrivateKey changes a card's limits, signing the change with
+// the Safe owner's private key when it must be authorized on-chain.
+func (s *CardService) SetLimitsWithPrivateKey(ctx context.Context, cardID string, limits CardLimits, privateKey *ecdsa.PrivateKey) (*CardLimitsChange, error) {
+	data, err := s.GetLimitsTransactionData(ctx, cardID, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	var signature string
+	if data.RequiresSignature {
+		signed, err := wallet.SignTypedData(data.TypedData, privateKey)
+		if err != nil {
+			return nil, err
+		}
+		signature = wallet.SignatureToString(signed)
+	}
+
+	return s.SetLimits(ctx, cardID, limits, signature)
+}
+
+// GetControls retrieves the transaction type toggles of a card.
+func (s *CardService) GetControls(ctx context.Context, cardID string) (*CardControls, error) {
+	path := fmt.Sprintf("/api/v1/cards/%s/controls", cardID)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var controls CardControls
+	if err := s.client.Do(ctx, req, &controls); err != nil {
+		return nil, err
+	}
+
+	return &controls, nil
+}
+
+// SetControls replaces the transaction type toggles of a card. They take
+// effect immediately and need no on-chain authorization.
+func (s *CardService) SetControls(ctx context.Context, cardID string, controls CardControls) (*CardControls, error) {
+	path := fmt.Sprintf("/api/v1/cards/%s/controls", cardID)
+	req, err := s.client.NewRequest(ctx, http.MethodPut, path, controls)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated CardControls
+	if err := s.client.Do(ctx, req, &updated); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
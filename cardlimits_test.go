@@ -0,0 +1,277 @@
+package gnosispay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+func eurLimits(daily, monthly, atmDaily, atmMonthly int64) CardLimits {
+	eur := EUR.Currency()
+	return CardLimits{
+		Daily:      NewMoney(daily, eur),
+		Monthly:    NewMoney(monthly, eur),
+		ATMDaily:   NewMoney(atmDaily, eur),
+		ATMMonthly: NewMoney(atmMonthly, eur),
+	}
+}
+
+func TestCardLimits_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  CardLimits
+		wantErr bool
+	}{
+		{"valid", eurLimits(50000, 500000, 20000, 100000), false},
+		{"zero limits", eurLimits(0, 0, 0, 0), false},
+		{"unset limit", CardLimits{Daily: NewMoney(1, EUR.Currency())}, true},
+		{"negative limit", eurLimits(-1, 500000, 20000, 100000), true},
+		{"daily above monthly", eurLimits(600000, 500000, 20000, 100000), true},
+		{"ATM daily above ATM monthly", eurLimits(50000, 500000, 200000, 100000), true},
+		{"mixed currencies", func() CardLimits {
+			l := eurLimits(50000, 500000, 20000, 100000)
+			l.ATMMonthly = NewMoney(100000, GBP.Currency())
+			return l
+		}(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.limits.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCardLimits_JSON(t *testing.T) {
+	limits := eurLimits(50000, 500000, 20000, 100000)
+
+	b, err := json.Marshal(limits)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"currency":"EUR","dailyLimit":"50000","monthlyLimit":"500000","atmDailyLimit":"20000","atmMonthlyLimit":"100000"}`
+	if string(b) != want {
+		t.Errorf("Marshal() = %s, want %s", b, want)
+	}
+
+	var got CardLimits
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !got.Daily.Equal(limits.Daily) || !got.ATMMonthly.Equal(limits.ATMMonthly) {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, limits)
+	}
+
+	if err := json.Unmarshal([]byte(`{"currency":"EUR","dailyLimit":"5.5"}`), &got); err == nil {
+		t.Error("Unmarshal() of a decimal amount expected error")
+	}
+
+	// Limits the API leaves out are unset rather than an error.
+	got = CardLimits{}
+	if err := json.Unmarshal([]byte(`{"currency":"EUR","dailyLimit":"5000","monthlyLimit":"100000"}`), &got); err != nil {
+		t.Fatalf("Unmarshal() without ATM limits error = %v", err)
+	}
+	if got.Daily.String() != "50.00 EUR" || got.ATMDaily.Currency.Code != "" || got.ATMMonthly.Currency.Code != "" {
+		t.Errorf("Unmarshal() without ATM limits = %+v", got)
+	}
+}
+
+func limitsTypedData() *apitypes.TypedData {
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"SetAllowance": {
+				{Name: "key", Type: "bytes32"},
+				{Name: "balance", Type: "uint128"},
+				{Name: "period", Type: "uint64"},
+			},
+		},
+		PrimaryType: "SetAllowance",
+		Domain: apitypes.TypedDataDomain{
+			Name:              "Gnosis Pay",
+			ChainId:           math.NewHexOrDecimal256(100),
+			VerifyingContract: "0x1111111111111111111111111111111111111111",
+		},
+		Message: apitypes.TypedDataMessage{
+			"key":     "0x" + strings.Repeat("ab", 32),
+			"balance": "60000",
+			"period":  "86400",
+		},
+	}
+}
+
+// limitsServer mocks the limits and controls endpoints of card-1. Raising
+// the daily limit requires a signature by owner.
+type limitsServer struct {
+	t        *testing.T
+	owner    common.Address
+	limits   CardLimits
+	controls CardControls
+	pending  *DelayTransaction
+}
+
+func (s *limitsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method + " " + r.URL.Path {
+	case "GET /api/v1/cards/card-1/limits":
+		json.NewEncoder(w).Encode(s.limits)
+	case "POST /api/v1/cards/card-1/limits/transaction-data":
+		var l CardLimits
+		json.NewDecoder(r.Body).Decode(&l)
+		if c, _ := l.Daily.Cmp(s.limits.Daily); c > 0 {
+			typed, _ := json.Marshal(limitsTypedData())
+			json.NewEncoder(w).Encode(CardLimitsTransactionData{RequiresSignature: true, TypedData: typed})
+			return
+		}
+		json.NewEncoder(w).Encode(CardLimitsTransactionData{})
+	case "PUT /api/v1/cards/card-1/limits":
+		var body struct {
+			Limits    CardLimits `json:"limits"`
+			Signature string     `json:"signature"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if c, _ := body.Limits.Daily.Cmp(s.limits.Daily); c <= 0 {
+			s.limits = body.Limits
+			json.NewEncoder(w).Encode(CardLimitsChange{Limits: s.limits})
+			return
+		}
+		if !s.signedByOwner(body.Signature) {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ApiError{Message: "invalid signature"})
+			return
+		}
+		s.pending = &DelayTransaction{Id: "delay-1", Status: "QUEUED", ReadyAt: time.Date(2025, 1, 1, 0, 3, 0, 0, time.UTC)}
+		json.NewEncoder(w).Encode(CardLimitsChange{Limits: s.limits, Pending: s.pending})
+	case "GET /api/v1/cards/card-1/controls":
+		json.NewEncoder(w).Encode(s.controls)
+	case "PUT /api/v1/cards/card-1/controls":
+		json.NewDecoder(r.Body).Decode(&s.controls)
+		json.NewEncoder(w).Encode(s.controls)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *limitsServer) signedByOwner(signature string) bool {
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != 65 {
+		return false
+	}
+	hash, _, err := apitypes.TypedDataAndHash(*limitsTypedData())
+	if err != nil {
+		s.t.Fatalf("TypedDataAndHash() error = %v", err)
+	}
+	sig[64] -= 27
+	pub, err := crypto.SigToPub(hash, sig)
+	return err == nil && crypto.PubkeyToAddress(*pub) == s.owner
+}
+
+func newLimitsClient(t *testing.T) (*limitsServer, *Client) {
+	t.Helper()
+	s := &limitsServer{t: t, limits: eurLimits(50000, 500000, 20000, 100000)}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	client, _ := New(nil, SetBaseURL(server.URL))
+	return s, client
+}
+
+func TestCardService_Limits(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	ctx := context.Background()
+
+	t.Run("get", func(t *testing.T) {
+		s, client := newLimitsClient(t)
+		limits, err := client.Cards.GetLimits(ctx, "card-1")
+		if err != nil {
+			t.Fatalf("GetLimits() error = %v", err)
+		}
+		if !limits.Monthly.Equal(s.limits.Monthly) || limits.Daily.Format() != "€500.00" {
+			t.Errorf("GetLimits() = %+v", limits)
+		}
+	})
+
+	t.Run("lowering applies without a signature", func(t *testing.T) {
+		s, client := newLimitsClient(t)
+		change, err := client.Cards.SetLimitsWithPrivateKey(ctx, "card-1", eurLimits(10000, 500000, 20000, 100000), key)
+		if err != nil {
+			t.Fatalf("SetLimitsWithPrivateKey() error = %v", err)
+		}
+		if change.Pending != nil || !change.Limits.Daily.Equal(NewMoney(10000, EUR.Currency())) {
+			t.Errorf("SetLimitsWithPrivateKey() = %+v", change)
+		}
+		if !s.limits.Daily.Equal(change.Limits.Daily) {
+			t.Errorf("server daily limit = %s", s.limits.Daily)
+		}
+	})
+
+	t.Run("raising is signed and queued in the delay module", func(t *testing.T) {
+		s, client := newLimitsClient(t)
+		s.owner = crypto.PubkeyToAddress(key.PublicKey)
+		change, err := client.Cards.SetLimitsWithPrivateKey(ctx, "card-1", eurLimits(60000, 500000, 20000, 100000), key)
+		if err != nil {
+			t.Fatalf("SetLimitsWithPrivateKey() error = %v", err)
+		}
+		if change.Pending == nil || change.Pending.Id != "delay-1" {
+			t.Errorf("SetLimitsWithPrivateKey() pending = %+v", change.Pending)
+		}
+		if !change.Limits.Daily.Equal(NewMoney(50000, EUR.Currency())) {
+			t.Errorf("limits in effect = %s, want the old limit until the delay passes", change.Limits.Daily)
+		}
+	})
+
+	t.Run("raising with the wrong key is refused", func(t *testing.T) {
+		s, client := newLimitsClient(t)
+		s.owner = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		if _, err := client.Cards.SetLimitsWithPrivateKey(ctx, "card-1", eurLimits(60000, 500000, 20000, 100000), key); err == nil {
+			t.Error("SetLimitsWithPrivateKey() expected error")
+		}
+	})
+
+	t.Run("invalid limits are not sent", func(t *testing.T) {
+		s, client := newLimitsClient(t)
+		if _, err := client.Cards.SetLimits(ctx, "card-1", eurLimits(600000, 500000, 20000, 100000), ""); err == nil {
+			t.Error("SetLimits() expected error")
+		}
+		if !s.limits.Daily.Equal(NewMoney(50000, EUR.Currency())) {
+			t.Errorf("server daily limit changed to %s", s.limits.Daily)
+		}
+	})
+}
+
+func TestCardService_Controls(t *testing.T) {
+	s, client := newLimitsClient(t)
+	s.controls = CardControls{ECommerce: true, Contactless: true, ForeignTransactions: true}
+	ctx := context.Background()
+
+	controls, err := client.Cards.GetControls(ctx, "card-1")
+	if err != nil {
+		t.Fatalf("GetControls() error = %v", err)
+	}
+	if *controls != s.controls {
+		t.Errorf("GetControls() = %+v, want %+v", controls, s.controls)
+	}
+
+	controls.ForeignTransactions = false
+	updated, err := client.Cards.SetControls(ctx, "card-1", *controls)
+	if err != nil {
+		t.Fatalf("SetControls() error = %v", err)
+	}
+	if updated.ForeignTransactions || !updated.ECommerce || s.controls.ForeignTransactions {
+		t.Errorf("SetControls() = %+v, server %+v", updated, s.controls)
+	}
+}
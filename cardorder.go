@@ -0,0 +1,250 @@
+package gnosispay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxEmbossedNameLength is the longest name that fits on a card.
+	maxEmbossedNameLength = 26
+
+	defaultOrderPollInterval = 30 * time.Second
+)
+
+// Final reports whether an order in status s will not change any more.
+func (s CardOrderStatus) Final() bool {
+	switch s {
+	case CardOrderCardCreated, CardOrderFailedTransaction, CardOrderCancelled:
+		return true
+	}
+	return false
+}
+
+// Validate checks the address for missing fields and a malformed country.
+func (a *ShippingAddress) Validate() error {
+	switch {
+	case a.Address1 == "":
+		return fmt.Errorf("shipping address line 1 cannot be empty")
+	case a.City == "":
+		return fmt.Errorf("shipping city cannot be empty")
+	case a.PostalCode == "":
+		return fmt.Errorf("shipping postal code cannot be empty")
+	}
+	if len(a.Country) != 2 || a.Country[0] < 'A' || a.Country[0] > 'Z' || a.Country[1] < 'A' || a.Country[1] > 'Z' {
+		return fmt.Errorf("invalid shipping country %q: must be an upper-case ISO 3166-1 alpha-2 code", a.Country)
+	}
+	return nil
+}
+
+// CardOrderRequest specifies a physical card to order with
+// CardService.OrderCard.
+type CardOrderRequest struct {
+	// EmbossedName is the name printed on the card, at most 26 characters
+	// of letters, spaces, hyphens, apostrophes and dots.
+	EmbossedName string `json:"embossedName"`
+
+	ShippingAddress *ShippingAddress `json:"shippingAddress"`
+
+	// CouponCode is an optional discount code.
+	CouponCode string `json:"couponCode,omitempty"`
+}
+
+// Validate checks the request for values the API would reject.
+func (r *CardOrderRequest) Validate() error {
+	if err := validateEmbossedName(r.EmbossedName); err != nil {
+		return err
+	}
+	if r.ShippingAddress == nil {
+		return fmt.Errorf("shipping address is required for a physical card")
+	}
+	return r.ShippingAddress.Validate()
+}
+
+func validateEmbossedName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name on card cannot be empty")
+	}
+	if len(name) > maxEmbossedNameLength {
+		return fmt.Errorf("invalid name on card %q: longer than %d characters", name, maxEmbossedNameLength)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !('A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || c == ' ' || c == '-' || c == '\'' || c == '.') {
+			return fmt.Errorf("invalid name on card %q: unsupported character %q", name, c)
+		}
+	}
+	return nil
+}
+
+// OrderCard places an order for a physical card. The order starts out
+// waiting for its payment transaction; see AttachOrderTransaction.
+func (s *CardService) OrderCard(ctx context.Context, order *CardOrderRequest) (*CardOrder, error) {
+	if err := order.Validate(); err != nil {
+		return nil, err
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodPost, "/api/v1/order/create", order)
+	if err != nil {
+		return nil, err
+	}
+
+	var created CardOrder
+	if err := s.client.Do(ctx, req, &created); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// ListOrders retrieves the card orders of the authenticated user.
+func (s *CardService) ListOrders(ctx context.Context) ([]CardOrder, error) {
+	req, err := s.client.NewRequest(ctx, http.MethodGet, "/api/v1/order", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []CardOrder
+	if err := s.client.Do(ctx, req, &orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// GetOrder retrieves a card order.
+func (s *CardService) GetOrder(ctx context.Context, orderID string) (*CardOrder, error) {
+	path := fmt.Sprintf("/api/v1/order/%s", orderID)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var order CardOrder
+	if err := s.client.Do(ctx, req, &order); err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// SetOrderShippingAddress changes where the card of a pending order is
+// delivered.
+func (s *CardService) SetOrderShippingAddress(ctx context.Context, orderID string, addr *ShippingAddress) (*CardOrder, error) {
+	if err := addr.Validate(); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("/api/v1/order/%s/shipping-address", orderID)
+	req, err := s.client.NewRequest(ctx, http.MethodPut, path, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var order CardOrder
+	if err := s.client.Do(ctx, req, &order); err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// AttachOrderTransaction links the on-chain payment for an order to it.
+func (s *CardService) AttachOrderTransaction(ctx context.Context, orderID, txHash string) error {
+	if txHash == "" {
+		return fmt.Errorf("transaction hash cannot be empty")
+	}
+	path := fmt.Sprintf("/api/v1/order/%s/attach-transaction", orderID)
+	body := struct {
+		TransactionHash string `json:"transactionHash"`
+	}{txHash}
+	req, err := s.client.NewRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Do(ctx, req, nil)
+}
+
+// ConfirmOrderPayment confirms the payment of an order that requires
+// confirmation, such as one fully paid by a coupon.
+func (s *CardService) ConfirmOrderPayment(ctx context.Context, orderID string) error {
+	path := fmt.Sprintf("/api/v1/order/%s/confirm-payment", orderID)
+	req, err := s.client.NewRequest(ctx, http.MethodPost, path, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Do(ctx, req, nil)
+}
+
+// CancelOrder cancels a card order that has not been fulfilled yet.
+func (s *CardService) CancelOrder(ctx context.Context, orderID string) error {
+	path := fmt.Sprintf("/api/v1/order/%s/cancel", orderID)
+	req, err := s.client.NewRequest(ctx, http.MethodPost, path, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Do(ctx, req, nil)
+}
+
+// CreateCardFromOrder issues the card for an order in the READY status.
+// The new card is inactive until it is activated, for instance with
+// Transition(ctx, card.Id, CardStateActive).
+func (s *CardService) CreateCardFromOrder(ctx context.Context, orderID string) (*Card, error) {
+	path := fmt.Sprintf("/api/v1/order/%s/card", orderID)
+	req, err := s.client.NewRequest(ctx, http.MethodPost, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var card Card
+	if err := s.client.Do(ctx, req, &card); err != nil {
+		return nil, err
+	}
+
+	return &card, nil
+}
+
+// CreateVirtualCard creates a virtual card, which can be used right away.
+func (s *CardService) CreateVirtualCard(ctx context.Context) (*Card, error) {
+	req, err := s.client.NewRequest(ctx, http.MethodPost, "/api/v1/cards/virtual", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var card Card
+	if err := s.client.Do(ctx, req, &card); err != nil {
+		return nil, err
+	}
+
+	return &card, nil
+}
+
+// WaitForOrder polls an order every interval until its status is final,
+// and returns it. Interval defaults to 30 seconds. The caller should
+// check whether the order ended with CardOrderCardCreated.
+func (s *CardService) WaitForOrder(ctx context.Context, orderID string, interval time.Duration) (*CardOrder, error) {
+	if interval <= 0 {
+		interval = defaultOrderPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		order, err := s.GetOrder(ctx, orderID)
+		if err != nil {
+			return nil, err
+		}
+		if order.Status.Final() {
+			return order, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return order, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package gnosispay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func validShippingAddress() *ShippingAddress {
+	return &ShippingAddress{Address1: "Hauptstraße 1", City: "Berlin", PostalCode: "10115", Country: "DE"}
+}
+
+func TestCardOrderRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(r *CardOrderRequest)
+		wantErr bool
+	}{
+		{"valid", func(r *CardOrderRequest) {}, false},
+		{"name with punctuation", func(r *CardOrderRequest) { r.EmbossedName = "Anne-Marie O'Neil Jr." }, false},
+		{"empty name", func(r *CardOrderRequest) { r.EmbossedName = "" }, true},
+		{"long name", func(r *CardOrderRequest) { r.EmbossedName = strings.Repeat("A", 27) }, true},
+		{"non-ASCII name", func(r *CardOrderRequest) { r.EmbossedName = "José" }, true},
+		{"missing address", func(r *CardOrderRequest) { r.ShippingAddress = nil }, true},
+		{"missing city", func(r *CardOrderRequest) { r.ShippingAddress.City = "" }, true},
+		{"missing postal code", func(r *CardOrderRequest) { r.ShippingAddress.PostalCode = "" }, true},
+		{"missing line 1", func(r *CardOrderRequest) { r.ShippingAddress.Address1 = "" }, true},
+		{"alpha-3 country", func(r *CardOrderRequest) { r.ShippingAddress.Country = "DEU" }, true},
+		{"lower-case country", func(r *CardOrderRequest) { r.ShippingAddress.Country = "de" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &CardOrderRequest{EmbossedName: "Jane Doe", ShippingAddress: validShippingAddress()}
+			tt.modify(r)
+			if err := r.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCardOrderStatus_Final(t *testing.T) {
+	for s, want := range map[CardOrderStatus]bool{
+		CardOrderPendingTransaction:   false,
+		CardOrderTransactionComplete:  false,
+		CardOrderConfirmationRequired: false,
+		CardOrderReady:                false,
+		CardOrderCardCreated:          true,
+		CardOrderFailedTransaction:    true,
+		CardOrderCancelled:            true,
+	} {
+		if got := s.Final(); got != want {
+			t.Errorf("%s.Final() = %v, want %v", s, got, want)
+		}
+	}
+}
+
+// orderServer is a mock of the card order endpoints holding one order.
+type orderServer struct {
+	mu    sync.Mutex
+	order CardOrder
+	polls int
+	// advance, if set, is called on every GET of the order.
+	advance func(o *CardOrder, polls int)
+
+	lastBody map[string]any
+}
+
+func (s *orderServer) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	respond := func(w http.ResponseWriter, v any) { json.NewEncoder(w).Encode(v) }
+	decode := func(r *http.Request) {
+		s.lastBody = nil
+		json.NewDecoder(r.Body).Decode(&s.lastBody)
+	}
+	lock := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if id := r.PathValue("id"); id != "" && id != s.order.Id {
+				w.WriteHeader(http.StatusNotFound)
+				respond(w, ApiError{Message: "order not found"})
+				return
+			}
+			h(w, r)
+		}
+	}
+
+	mux.HandleFunc("POST /api/v1/order/create", lock(func(w http.ResponseWriter, r *http.Request) {
+		var req CardOrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode order request: %v", err)
+		}
+		s.order = CardOrder{
+			Id: "order-1", Status: CardOrderPendingTransaction, EmbossedName: req.EmbossedName,
+			ShippingAddress: req.ShippingAddress, CouponCode: req.CouponCode, TotalAmountEUR: "30.00",
+		}
+		respond(w, s.order)
+	}))
+	mux.HandleFunc("GET /api/v1/order", lock(func(w http.ResponseWriter, r *http.Request) {
+		respond(w, []CardOrder{s.order})
+	}))
+	mux.HandleFunc("GET /api/v1/order/{id}", lock(func(w http.ResponseWriter, r *http.Request) {
+		s.polls++
+		if s.advance != nil {
+			s.advance(&s.order, s.polls)
+		}
+		respond(w, s.order)
+	}))
+	mux.HandleFunc("PUT /api/v1/order/{id}/shipping-address", lock(func(w http.ResponseWriter, r *http.Request) {
+		var addr ShippingAddress
+		json.NewDecoder(r.Body).Decode(&addr)
+		s.order.ShippingAddress = &addr
+		respond(w, s.order)
+	}))
+	mux.HandleFunc("POST /api/v1/order/{id}/attach-transaction", lock(func(w http.ResponseWriter, r *http.Request) {
+		decode(r)
+		s.order.TransactionHash, _ = s.lastBody["transactionHash"].(string)
+		s.order.Status = CardOrderTransactionComplete
+		respond(w, ApiGenericResponse{Ok: true})
+	}))
+	mux.HandleFunc("POST /api/v1/order/{id}/confirm-payment", lock(func(w http.ResponseWriter, r *http.Request) {
+		s.order.Status = CardOrderReady
+		respond(w, ApiGenericResponse{Ok: true})
+	}))
+	mux.HandleFunc("POST /api/v1/order/{id}/cancel", lock(func(w http.ResponseWriter, r *http.Request) {
+		s.order.Status = CardOrderCancelled
+		respond(w, ApiGenericResponse{Ok: true})
+	}))
+	mux.HandleFunc("POST /api/v1/order/{id}/card", lock(func(w http.ResponseWriter, r *http.Request) {
+		if s.order.Status != CardOrderReady {
+			w.WriteHeader(http.StatusConflict)
+			respond(w, ApiError{Message: "order is not ready"})
+			return
+		}
+		s.order.Status = CardOrderCardCreated
+		s.order.CardToken = "card-9"
+		respond(w, Card{Id: "card-9", LastFourDigits: "9999"})
+	}))
+	mux.HandleFunc("POST /api/v1/cards/virtual", lock(func(w http.ResponseWriter, r *http.Request) {
+		respond(w, Card{Id: "virtual-1", LastFourDigits: "1234"})
+	}))
+	return mux
+}
+
+func newOrderClient(t *testing.T) (*orderServer, *Client) {
+	t.Helper()
+	s := &orderServer{}
+	server := httptest.NewServer(s.handler(t))
+	t.Cleanup(server.Close)
+	client, _ := New(nil, SetBaseURL(server.URL))
+	return s, client
+}
+
+func TestCardService_OrderLifecycle(t *testing.T) {
+	s, client := newOrderClient(t)
+	ctx := context.Background()
+
+	order, err := client.Cards.OrderCard(ctx, &CardOrderRequest{
+		EmbossedName:    "Jane Doe",
+		ShippingAddress: validShippingAddress(),
+		CouponCode:      "WELCOME",
+	})
+	if err != nil {
+		t.Fatalf("OrderCard() error = %v", err)
+	}
+	if order.Id != "order-1" || order.Status != CardOrderPendingTransaction || order.EmbossedName != "Jane Doe" || order.CouponCode != "WELCOME" {
+		t.Errorf("OrderCard() = %+v", order)
+	}
+
+	moved := &ShippingAddress{Address1: "Rue de Rivoli 2", Address2: "3e étage", City: "Paris", PostalCode: "75001", Country: "FR"}
+	order, err = client.Cards.SetOrderShippingAddress(ctx, order.Id, moved)
+	if err != nil {
+		t.Fatalf("SetOrderShippingAddress() error = %v", err)
+	}
+	if *order.ShippingAddress != *moved {
+		t.Errorf("SetOrderShippingAddress() address = %+v, want %+v", order.ShippingAddress, moved)
+	}
+
+	if err := client.Cards.AttachOrderTransaction(ctx, order.Id, "0xabc"); err != nil {
+		t.Fatalf("AttachOrderTransaction() error = %v", err)
+	}
+	if s.lastBody["transactionHash"] != "0xabc" {
+		t.Errorf("AttachOrderTransaction() body = %v", s.lastBody)
+	}
+	if err := client.Cards.ConfirmOrderPayment(ctx, order.Id); err != nil {
+		t.Fatalf("ConfirmOrderPayment() error = %v", err)
+	}
+
+	orders, err := client.Cards.ListOrders(ctx)
+	if err != nil {
+		t.Fatalf("ListOrders() error = %v", err)
+	}
+	if len(orders) != 1 || orders[0].Status != CardOrderReady || orders[0].TransactionHash != "0xabc" {
+		t.Errorf("ListOrders() = %+v", orders)
+	}
+
+	card, err := client.Cards.CreateCardFromOrder(ctx, order.Id)
+	if err != nil {
+		t.Fatalf("CreateCardFromOrder() error = %v", err)
+	}
+	if card.Id != "card-9" {
+		t.Errorf("CreateCardFromOrder() = %+v", card)
+	}
+
+	order, err = client.Cards.GetOrder(ctx, order.Id)
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if order.Status != CardOrderCardCreated || order.CardToken != card.Id {
+		t.Errorf("GetOrder() = %+v", order)
+	}
+
+	if _, err := client.Cards.CreateCardFromOrder(ctx, order.Id); err == nil {
+		t.Error("CreateCardFromOrder() on a fulfilled order expected error")
+	}
+}
+
+func TestCardService_OrderCardValidates(t *testing.T) {
+	s, client := newOrderClient(t)
+	ctx := context.Background()
+
+	if _, err := client.Cards.OrderCard(ctx, &CardOrderRequest{EmbossedName: "Jane Doe"}); err == nil {
+		t.Error("OrderCard() without address expected error")
+	}
+	if _, err := client.Cards.SetOrderShippingAddress(ctx, "order-1", &ShippingAddress{}); err == nil {
+		t.Error("SetOrderShippingAddress() with empty address expected error")
+	}
+	if err := client.Cards.AttachOrderTransaction(ctx, "order-1", ""); err == nil {
+		t.Error("AttachOrderTransaction() with empty hash expected error")
+	}
+	if s.order.Id != "" {
+		t.Errorf("invalid requests reached the server: %+v", s.order)
+	}
+
+	if _, err := client.Cards.GetOrder(ctx, "nope"); err == nil {
+		t.Error("GetOrder() of unknown order expected error")
+	}
+}
+
+func TestCardService_CancelOrder(t *testing.T) {
+	s, client := newOrderClient(t)
+	s.order = CardOrder{Id: "order-1", Status: CardOrderPendingTransaction}
+
+	if err := client.Cards.CancelOrder(context.Background(), "order-1"); err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+	if s.order.Status != CardOrderCancelled {
+		t.Errorf("order status = %s, want %s", s.order.Status, CardOrderCancelled)
+	}
+}
+
+func TestCardService_CreateVirtualCard(t *testing.T) {
+	_, client := newOrderClient(t)
+
+	card, err := client.Cards.CreateVirtualCard(context.Background())
+	if err != nil {
+		t.Fatalf("CreateVirtualCard() error = %v", err)
+	}
+	if card.Id != "virtual-1" || card.LastFourDigits != "1234" {
+		t.Errorf("CreateVirtualCard() = %+v", card)
+	}
+}
+
+func TestCardService_WaitForOrder(t *testing.T) {
+	t.Run("returns once the order is final", func(t *testing.T) {
+		s, client := newOrderClient(t)
+		s.order = CardOrder{Id: "order-1", Status: CardOrderTransactionComplete}
+		s.advance = func(o *CardOrder, polls int) {
+			switch polls {
+			case 2:
+				o.Status = CardOrderReady
+			case 3:
+				o.Status, o.CardToken = CardOrderCardCreated, "card-9"
+			}
+		}
+
+		order, err := client.Cards.WaitForOrder(context.Background(), "order-1", time.Millisecond)
+		if err != nil {
+			t.Fatalf("WaitForOrder() error = %v", err)
+		}
+		if order.Status != CardOrderCardCreated || order.CardToken != "card-9" || s.polls != 3 {
+			t.Errorf("WaitForOrder() = %+v after %d polls", order, s.polls)
+		}
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		s, client := newOrderClient(t)
+		s.order = CardOrder{Id: "order-1", Status: CardOrderPendingTransaction}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		order, err := client.Cards.WaitForOrder(ctx, "order-1", time.Hour)
+		if err != context.DeadlineExceeded {
+			t.Errorf("WaitForOrder() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+		if order == nil || order.Status != CardOrderPendingTransaction {
+			t.Errorf("WaitForOrder() = %+v, want the last polled order", order)
+		}
+	})
+
+	t.Run("returns API errors", func(t *testing.T) {
+		_, client := newOrderClient(t)
+		if _, err := client.Cards.WaitForOrder(context.Background(), "missing", time.Millisecond); err == nil {
+			t.Error("WaitForOrder() of unknown order expected error")
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package gnosispay
+
+import (
+	"context"
+	"fmt"
+)
+
+// CardState is the lifecycle state of a card, derived from the flags of a
+// CardStatus.
+type CardState string
+
+// Card states, as reported by CardStatus.State.
+const (
+	CardStateInactive CardState = "inactive"
+	CardStateActive   CardState = "active"
+	CardStateFrozen   CardState = "frozen"
+	CardStateLost     CardState = "lost"
+	CardStateStolen   CardState = "stolen"
+	CardStateBlocked  CardState = "blocked"
+	CardStateVoid     CardState = "void"
+)
+
+// String returns the state as a string.
+func (s CardState) String() string {
+	return string(s)
+}
+
+// Terminal reports whether no transition leads out of s.
+func (s CardState) Terminal() bool {
+	switch s {
+	case CardStateLost, CardStateStolen, CardStateBlocked, CardStateVoid:
+		return true
+	}
+	return false
+}
+
+// State collapses the status flags into a single CardState. When several
+// flags are set the most severe wins, in the order void, stolen, lost,
+// blocked, frozen; a card with none set is active once it has an
+// activation date.
+func (s *CardStatus) State() CardState {
+	switch {
+	case s.IsVoid:
+		return CardStateVoid
+	case s.IsStolen:
+		return CardStateStolen
+	case s.IsLost:
+		return CardStateLost
+	case s.IsBlocked:
+		return CardStateBlocked
+	case s.IsFrozen:
+		return CardStateFrozen
+	case s.ActivatedAt == "":
+		return CardStateInactive
+	default:
+		return CardStateActive
+	}
+}
+
+// CardAction is an API call that moves a card between states.
+type CardAction string
+
+// Card actions, one per CardService method.
+const (
+	CardActionActivate     CardAction = "activate"
+	CardActionFreeze       CardAction = "freeze"
+	CardActionUnfreeze     CardAction = "unfreeze"
+	CardActionReportLost   CardAction = "report lost"
+	CardActionReportStolen CardAction = "report stolen"
+)
+
+type cardTransition struct {
+	from, to CardState
+}
+
+// cardTransitions lists every legal transition and the action that
+// performs it. Blocked and void cards can only be changed by Gnosis Pay.
+var cardTransitions = map[cardTransition]CardAction{
+	{CardStateInactive, CardStateActive}: CardActionActivate,
+	{CardStateInactive, CardStateLost}:   CardActionReportLost,
+	{CardStateInactive, CardStateStolen}: CardActionReportStolen,
+	{CardStateActive, CardStateFrozen}:   CardActionFreeze,
+	{CardStateActive, CardStateLost}:     CardActionReportLost,
+	{CardStateActive, CardStateStolen}:   CardActionReportStolen,
+	{CardStateFrozen, CardStateActive}:   CardActionUnfreeze,
+	{CardStateFrozen, CardStateLost}:     CardActionReportLost,
+	{CardStateFrozen, CardStateStolen}:   CardActionReportStolen,
+}
+
+// CanTransition reports whether a card can move from one state to another.
+func CanTransition(from, to CardState) bool {
+	_, ok := cardTransitions[cardTransition{from, to}]
+	return ok
+}
+
+// TransitionAction returns the action that moves a card from one state to
+// another, or a *TransitionError if there is none.
+func TransitionAction(from, to CardState) (CardAction, error) {
+	action, ok := cardTransitions[cardTransition{from, to}]
+	if !ok {
+		return "", &TransitionError{From: from, To: to}
+	}
+	return action, nil
+}
+
+// TransitionError reports a card state change that is not allowed.
+type TransitionError struct {
+	From CardState
+	To   CardState
+}
+
+func (e *TransitionError) Error() string {
+	if e.From == e.To {
+		return fmt.Sprintf("card is already %s", e.From)
+	}
+	return fmt.Sprintf("illegal card transition from %s to %s", e.From, e.To)
+}
+
+// Transition moves a card to the target state. It fetches the card's
+// current status and returns a *TransitionError without calling the API
+// if the move is not legal.
+func (s *CardService) Transition(ctx context.Context, cardID string, target CardState) error {
+	status, err := s.GetStatus(ctx, cardID)
+	if err != nil {
+		return err
+	}
+	action, err := TransitionAction(status.State(), target)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case CardActionActivate:
+		return s.Activate(ctx, cardID)
+	case CardActionFreeze:
+		return s.Freeze(ctx, cardID)
+	case CardActionUnfreeze:
+		return s.Unfreeze(ctx, cardID)
+	case CardActionReportLost:
+		return s.ReportLost(ctx, cardID)
+	case CardActionReportStolen:
+		return s.ReportStolen(ctx, cardID)
+	}
+	return fmt.Errorf("unknown card action %q", action)
+}
@@ -0,0 +1,106 @@
+package gnosispay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCardStatus_State(t *testing.T) {
+	tests := []struct {
+		name   string
+		status CardStatus
+		want   CardState
+	}{
+		{"no flags, not activated", CardStatus{}, CardStateInactive},
+		{"no flags, activated", CardStatus{ActivatedAt: "2025-01-01"}, CardStateActive},
+		{"frozen", CardStatus{ActivatedAt: "2025-01-01", IsFrozen: true}, CardStateFrozen},
+		{"blocked beats frozen", CardStatus{IsFrozen: true, IsBlocked: true}, CardStateBlocked},
+		{"lost beats blocked", CardStatus{IsBlocked: true, IsLost: true}, CardStateLost},
+		{"stolen beats lost", CardStatus{IsLost: true, IsStolen: true}, CardStateStolen},
+		{"void beats everything", CardStatus{IsFrozen: true, IsStolen: true, IsVoid: true}, CardStateVoid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.status.State(); got != tt.want {
+				t.Errorf("State() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransitionAction(t *testing.T) {
+	tests := []struct {
+		from, to CardState
+		want     CardAction
+		wantErr  bool
+	}{
+		{CardStateInactive, CardStateActive, CardActionActivate, false},
+		{CardStateActive, CardStateFrozen, CardActionFreeze, false},
+		{CardStateFrozen, CardStateActive, CardActionUnfreeze, false},
+		{CardStateFrozen, CardStateLost, CardActionReportLost, false},
+		{CardStateActive, CardStateStolen, CardActionReportStolen, false},
+		{CardStateInactive, CardStateFrozen, "", true},
+		{CardStateActive, CardStateActive, "", true},
+		{CardStateLost, CardStateActive, "", true},
+		{CardStateLost, CardStateStolen, "", true},
+		{CardStateActive, CardStateBlocked, "", true},
+		{CardStateVoid, CardStateActive, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			got, err := TransitionAction(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TransitionAction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("TransitionAction() = %q, want %q", got, tt.want)
+			}
+			if CanTransition(tt.from, tt.to) == tt.wantErr {
+				t.Errorf("CanTransition() = %v, want %v", !tt.wantErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCardService_Transition(t *testing.T) {
+	var status CardStatus
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(status)
+			return
+		}
+		calls = append(calls, r.URL.Path)
+	}))
+	defer server.Close()
+
+	client, _ := New(nil, SetBaseURL(server.URL))
+
+	t.Run("calls the matching endpoint", func(t *testing.T) {
+		status, calls = CardStatus{ActivatedAt: "2025-01-01", IsFrozen: true}, nil
+		if err := client.Cards.Transition(context.Background(), "card-1", CardStateActive); err != nil {
+			t.Fatalf("Transition() error = %v", err)
+		}
+		if len(calls) != 1 || calls[0] != "/api/v1/cards/card-1/unfreeze" {
+			t.Errorf("Transition() calls = %v", calls)
+		}
+	})
+
+	t.Run("refuses illegal transitions", func(t *testing.T) {
+		status, calls = CardStatus{ActivatedAt: "2025-01-01", IsStolen: true}, nil
+		err := client.Cards.Transition(context.Background(), "card-1", CardStateFrozen)
+		var terr *TransitionError
+		if !errors.As(err, &terr) || terr.From != CardStateStolen || terr.To != CardStateFrozen {
+			t.Fatalf("Transition() error = %v, want *TransitionError", err)
+		}
+		if len(calls) != 0 {
+			t.Errorf("Transition() called %v for an illegal transition", calls)
+		}
+	})
+}
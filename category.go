@@ -0,0 +1,9 @@
+package gnosispay
+
+import "github.com/guarilha/go-gnosispay/mcc"
+
+// Category returns the spending category of the event's merchant category
+// code in mcc.Default, or mcc.Other when the code is unknown.
+func (e CardEvent) Category() mcc.Category {
+	return mcc.CategoryOf(e.Mcc)
+}
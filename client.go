@@ -9,6 +9,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -24,6 +25,9 @@ type Client struct {
 	// HTTP client used to communicate with the API.
 	client *http.Client
 
+	// Optional limit on the request rate, set with SetRateLimit.
+	limiter *rateLimiter
+
 	// Base URL for API requests.
 	BaseURL *url.URL
 
@@ -166,6 +170,12 @@ func (c *Client) NewRequest(ctx context.Context, method, urlStr string, body int
 
 // Do sends an API request and returns the API response.
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) error {
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx); err != nil {
+			return err
+		}
+	}
+
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return err
@@ -183,11 +193,13 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) error
 	slog.Debug("Response Body", "body", string(bodyBytes))
 
 	if resp.StatusCode >= 400 {
-		var apiError ApiError
-		if err := json.Unmarshal(bodyBytes, &apiError); err != nil {
-			return fmt.Errorf("failed to decode error response: %w", err)
+		errResp := &ErrorResponse{StatusCode: resp.StatusCode}
+		if err := json.Unmarshal(bodyBytes, &errResp.Body); err != nil {
+			// Not every error comes from the API itself (e.g. gateway errors),
+			// so keep the raw body rather than losing the status code.
+			errResp.Body.Message = strings.TrimSpace(string(bodyBytes))
 		}
-		return fmt.Errorf("API error (status %d): %v %v", resp.StatusCode, apiError.Message, apiError.Error)
+		return errResp
 	}
 
 	if v != nil {
@@ -201,6 +213,16 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) error
 	return nil
 }
 
+// ErrorResponse reports an error response from the Gnosis Pay API.
+type ErrorResponse struct {
+	StatusCode int      // HTTP status code of the response.
+	Body       ApiError // Decoded error body.
+}
+
+func (e *ErrorResponse) Error() string {
+	return fmt.Sprintf("API error (status %d): %v %v", e.StatusCode, e.Body.Message, e.Body.Error)
+}
+
 // IsAuthenticated checks if the client has a valid, non-expired authentication token.
 func (c *Client) IsAuthenticated() bool {
 	if c.AuthToken == "" {
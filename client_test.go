@@ -3,6 +3,7 @@ package gnosispay
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -149,6 +150,12 @@ func TestClient_Do(t *testing.T) {
 			responseBody: ApiError{Message: "error message", Error: "error details"},
 			wantErr:      true,
 		},
+		{
+			name:         "non-JSON error response",
+			statusCode:   http.StatusBadGateway,
+			responseBody: "bad gateway",
+			wantErr:      true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -168,6 +175,12 @@ func TestClient_Do(t *testing.T) {
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Do() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if err != nil {
+				var errResp *ErrorResponse
+				if !errors.As(err, &errResp) || errResp.StatusCode != tt.statusCode {
+					t.Errorf("Do() error = %#v, want *ErrorResponse with status %d", err, tt.statusCode)
+				}
+			}
 		})
 	}
 }
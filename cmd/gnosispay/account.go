@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/guarilha/go-gnosispay"
+)
+
+func balancesCommand() *command {
+	return &command{
+		name:    "balances",
+		summary: "show Safe account balances",
+		run:     runBalances,
+	}
+}
+
+func runBalances(ctx context.Context, a *app, args []string) error {
+	if err := parse(a.flags("balances", ""), args, 0); err != nil {
+		return err
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	balances, err := c.Account.GetBalances(ctx)
+	if err != nil {
+		return err
+	}
+	return a.render(balances, func() table {
+		return fields("total", balances.Total, "spendable", balances.Spendable, "pending", balances.Pending)
+	})
+}
+
+func safeCommand() *command {
+	return &command{
+		name: "safe",
+		subs: []*command{
+			{name: "config", summary: "show the Safe configuration", run: runSafeConfig},
+		},
+	}
+}
+
+func runSafeConfig(ctx context.Context, a *app, args []string) error {
+	if err := parse(a.flags("safe config", ""), args, 0); err != nil {
+		return err
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	cfg, err := c.Account.GetSafeConfig(ctx)
+	if err != nil {
+		return err
+	}
+	return a.render(cfg, func() table {
+		return fields(
+			"address", cfg.Address,
+			"deployed", yesNo(cfg.IsDeployed),
+			"no approvals", yesNo(cfg.HasNoApprovals),
+			"token", cfg.TokenSymbol,
+			"fiat", cfg.FiatSymbol,
+		)
+	})
+}
+
+func eoaCommand() *command {
+	return &command{
+		name: "eoa",
+		subs: []*command{
+			{name: "list", summary: "list sign-in wallets (EOAs)", run: runEoaList},
+			{name: "add", args: "<address>", summary: "add a sign-in wallet", run: runEoaAdd},
+			{name: "remove", args: "<id>", summary: "remove a sign-in wallet", run: runEoaRemove},
+		},
+	}
+}
+
+func eoaTable(accounts []gnosispay.EoaAccount) func() table {
+	return func() table {
+		t := table{header: []string{"ID", "ADDRESS", "CREATED"}}
+		for _, acc := range accounts {
+			t.rows = append(t.rows, []string{acc.Id, acc.Address, formatTime(acc.CreatedAt)})
+		}
+		return t
+	}
+}
+
+func runEoaList(ctx context.Context, a *app, args []string) error {
+	if err := parse(a.flags("eoa list", ""), args, 0); err != nil {
+		return err
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	accounts, err := c.Account.ListEoaAccounts(ctx)
+	if err != nil {
+		return err
+	}
+	return a.render(accounts, eoaTable(accounts))
+}
+
+func runEoaAdd(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("eoa add", "<address>")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	if !common.IsHexAddress(fs.Arg(0)) {
+		return usagef("eoa add: invalid address %q", fs.Arg(0))
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	account, err := c.Account.CreateEoa(ctx, common.HexToAddress(fs.Arg(0)))
+	if err != nil {
+		return err
+	}
+	return a.render(account, eoaTable([]gnosispay.EoaAccount{*account}))
+}
+
+func runEoaRemove(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("eoa remove", "<id>")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	if err := c.Account.DeleteEoa(ctx, fs.Arg(0)); err != nil {
+		return err
+	}
+	return a.done(map[string]string{"id": fs.Arg(0), "result": "removed"}, fmt.Sprintf("Removed %s.", fs.Arg(0)))
+}
+
+func delayedCommand() *command {
+	return &command{
+		name: "delayed",
+		subs: []*command{
+			{name: "list", summary: "list transactions queued in the delay module", run: runDelayedList},
+		},
+	}
+}
+
+func runDelayedList(ctx context.Context, a *app, args []string) error {
+	if err := parse(a.flags("delayed list", ""), args, 0); err != nil {
+		return err
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	txs, err := c.Account.ListDelayedTransactions(ctx)
+	if err != nil {
+		return err
+	}
+	return a.render(txs, func() table {
+		t := table{header: []string{"ID", "OPERATION", "STATUS", "READY", "CREATED"}}
+		for _, tx := range txs {
+			t.rows = append(t.rows, []string{tx.Id, tx.OperationType, tx.Status, formatTime(tx.ReadyAt), formatTime(tx.CreatedAt)})
+		}
+		return t
+	})
+}
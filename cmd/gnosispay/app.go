@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/mcc"
+)
+
+// Exit codes. API errors are mapped by HTTP status so scripts can tell an
+// expired session from a missing card or an outage.
+const (
+	exitOK          = 0
+	exitError       = 1 // Unclassified failure.
+	exitUsage       = 2 // Bad command line.
+	exitAuth        = 3 // Not logged in, or the API returned 401/403.
+	exitNotFound    = 4 // The API returned 404.
+	exitInvalid     = 5 // The API rejected the request (400, 409, 422).
+	exitRateLimited = 6 // The API returned 429.
+	exitServer      = 7 // The API returned a 5xx status.
+)
+
+const (
+	defaultSIWEURI = "https://app.gnosispay.com"
+	envToken       = "GNOSISPAY_TOKEN"
+	envBaseURL     = "GNOSISPAY_BASE_URL"
+	envConfigDir   = "GNOSISPAY_CONFIG_DIR"
+)
+
+var errNotLoggedIn = errors.New(`not logged in or session expired; run "gnosispay login"`)
+
+// usageError is returned for malformed command lines.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// app holds the state shared by all commands.
+type app struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	baseURL string
+	siweURI string
+	chainID int
+	output  string
+	token   string
+	profile string
+	signer  signerSource
+
+	httpClient *http.Client
+	client     *gnosispay.Client
+	mcc        *mcc.Table
+}
+
+// command is a node in the command tree. Leaf commands have run set,
+// groups have subcommands.
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(ctx context.Context, a *app, args []string) error
+	subs    []*command
+}
+
+func commands() []*command {
+	return []*command{
+		loginCommand(),
+		logoutCommand(),
+		profileCommand(),
+		userCommand(),
+		cardsCommand(),
+		txCommand(),
+		kycCommand(),
+		ibanCommand(),
+		balancesCommand(),
+		safeCommand(),
+		eoaCommand(),
+		delayedCommand(),
+		tuiCommand(),
+		syncCommand(),
+		recurringCommand(),
+		expensesCommand(),
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	a := &app{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("gnosispay", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&a.baseURL, "base-url", envOr(envBaseURL, "https://api.gnosispay.com"), "API base URL")
+	fs.StringVar(&a.siweURI, "siwe-uri", defaultSIWEURI, "application URI used in the SIWE message")
+	fs.IntVar(&a.chainID, "chain-id", 100, "chain ID used in the SIWE message")
+	fs.StringVar(&a.output, "o", "table", "output format: table, json or yaml")
+	fs.StringVar(&a.output, "output", "table", "output format: table, json or yaml")
+	// The token defaults to $GNOSISPAY_TOKEN after parsing, so that -h does
+	// not print it.
+	fs.StringVar(&a.token, "token", "", "session token (overrides the cached session; default $"+envToken+")")
+	fs.StringVar(&a.profile, "profile", os.Getenv(envProfile), "profile to use (default: the current profile)")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			a.printUsage(stdout, fs, commands(), "")
+			return exitOK
+		}
+		fmt.Fprintf(stderr, "gnosispay: %v\n", err)
+		a.printUsage(stderr, fs, commands(), "")
+		return exitUsage
+	}
+	if a.token == "" {
+		a.token = os.Getenv(envToken)
+	}
+	switch a.output {
+	case "table", "json", "yaml":
+	default:
+		fmt.Fprintf(stderr, "gnosispay: unknown output format %q\n", a.output)
+		return exitUsage
+	}
+	if err := a.applyProfile(fs); err != nil {
+		return a.exitCode(err)
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 || rest[0] == "help" {
+		w := stdout
+		if len(rest) == 0 {
+			w = stderr
+		}
+		a.printUsage(w, fs, commands(), "")
+		if len(rest) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	cmds := commands()
+	prefix := ""
+	for {
+		cmd := findCommand(cmds, rest[0])
+		if cmd == nil {
+			fmt.Fprintf(stderr, "gnosispay: unknown command %q\n", strings.TrimSpace(prefix+" "+rest[0]))
+			a.printUsage(stderr, fs, cmds, prefix)
+			return exitUsage
+		}
+		prefix = strings.TrimSpace(prefix + " " + cmd.name)
+		rest = rest[1:]
+		if cmd.run != nil {
+			return a.exitCode(cmd.run(ctx, a, rest))
+		}
+		if len(rest) == 0 || rest[0] == "help" || rest[0] == "-h" || rest[0] == "--help" {
+			a.printUsage(stderr, nil, cmd.subs, prefix)
+			return exitUsage
+		}
+		cmds = cmd.subs
+	}
+}
+
+func findCommand(cmds []*command, name string) *command {
+	for _, c := range cmds {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func (a *app) printUsage(w io.Writer, fs *flag.FlagSet, cmds []*command, prefix string) {
+	name := "gnosispay [global flags]"
+	if prefix != "" {
+		name += " " + prefix
+	}
+	fmt.Fprintf(w, "Usage: %s <command> [flags] [args]\n\nCommands:\n", name)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	var walk func(cmds []*command, prefix string)
+	walk = func(cmds []*command, prefix string) {
+		for _, c := range cmds {
+			name := strings.TrimSpace(prefix + " " + c.name)
+			if c.run != nil {
+				fmt.Fprintf(tw, "  %s %s\t%s\n", name, c.args, c.summary)
+			}
+			walk(c.subs, name)
+		}
+	}
+	walk(cmds, prefix)
+	tw.Flush()
+
+	if fs != nil {
+		fmt.Fprint(w, "\nGlobal flags:\n")
+		fs.SetOutput(w)
+		fs.PrintDefaults()
+		fs.SetOutput(io.Discard)
+	}
+}
+
+// exitCode reports err on stderr and maps it to an exit code.
+func (a *app) exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	fmt.Fprintf(a.stderr, "gnosispay: %v\n", err)
+
+	var uerr *usageError
+	if errors.As(err, &uerr) {
+		return exitUsage
+	}
+	if errors.Is(err, errNotLoggedIn) {
+		return exitAuth
+	}
+	var apiErr *gnosispay.ErrorResponse
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+			return exitAuth
+		case apiErr.StatusCode == http.StatusNotFound:
+			return exitNotFound
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			return exitRateLimited
+		case apiErr.StatusCode >= 500:
+			return exitServer
+		case apiErr.StatusCode >= 400:
+			return exitInvalid
+		}
+	}
+	return exitError
+}
+
+// applyProfile selects the active profile and fills in its settings for
+// every global flag not given explicitly on the command line.
+func (a *app) applyProfile(fs *flag.FlagSet) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if a.profile == "" {
+		a.profile = cfg.Current
+	}
+	if a.profile == "" {
+		a.profile = defaultProfile
+	}
+	if !profileName.MatchString(a.profile) {
+		return usagef("invalid profile name %q", a.profile)
+	}
+
+	// A profile without configuration still gets its own session, so
+	// "gnosispay -profile work login" works before "profile add".
+	p, ok := cfg.Profiles[a.profile]
+	if !ok {
+		return nil
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if p.BaseURL != "" && !set["base-url"] && os.Getenv(envBaseURL) == "" {
+		a.baseURL = p.BaseURL
+	}
+	if p.SIWEURI != "" && !set["siwe-uri"] {
+		a.siweURI = p.SIWEURI
+	}
+	if p.ChainID != 0 && !set["chain-id"] {
+		a.chainID = p.ChainID
+	}
+	a.signer = p.Signer
+	return nil
+}
+
+// newClient builds an API client without credentials.
+func (a *app) newClient() (*gnosispay.Client, error) {
+	c, err := gnosispay.New(a.httpClient,
+		gnosispay.SetBaseURL(a.baseURL),
+		gnosispay.SetSIWEParams(a.siweURI),
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.ChainID = a.chainID
+	return c, nil
+}
+
+// authClient returns a client carrying a valid session token, taken from
+// --token or the cached session.
+func (a *app) authClient() (*gnosispay.Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+	c, err := a.newClient()
+	if err != nil {
+		return nil, err
+	}
+	token := a.token
+	if token == "" {
+		token, err = loadSession(a.profile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	c.AuthToken = token
+	if !c.IsAuthenticated() {
+		return nil, errNotLoggedIn
+	}
+	a.client = c
+	return c, nil
+}
+
+// flags returns a flag set for a leaf command that reports errors as
+// usage errors instead of exiting.
+func (a *app) flags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: gnosispay %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses flags and checks the number of positional arguments.
+func parse(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if fs.NArg() != nargs {
+		return usagef("%s: expected %d argument(s), got %d", fs.Name(), nargs, fs.NArg())
+	}
+	return nil
+}
+
+// tokenValid reports whether token is a JWT that has not expired yet.
+func tokenValid(token string) bool {
+	c, err := gnosispay.New(nil, gnosispay.SetAuthToken(token))
+	return err == nil && c.IsAuthenticated()
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// fields renders a flat key/value table for single objects.
+func fields(kv ...string) table {
+	t := table{header: []string{"FIELD", "VALUE"}}
+	for i := 0; i+1 < len(kv); i += 2 {
+		t.rows = append(t.rows, []string{kv[i], kv[i+1]})
+	}
+	return t
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func itoa(n int) string { return strconv.Itoa(n) }
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/mcc"
+)
+
+func cardsCommand() *command {
+	action := func(name, summary, past string, fn func(*gnosispay.CardService, context.Context, string) error) *command {
+		return &command{
+			name:    name,
+			args:    "<card-id>",
+			summary: summary,
+			run: func(ctx context.Context, a *app, args []string) error {
+				fs := a.flags("cards "+name, "<card-id>")
+				if err := parse(fs, args, 1); err != nil {
+					return err
+				}
+				c, err := a.authClient()
+				if err != nil {
+					return err
+				}
+				id := fs.Arg(0)
+				if err := fn(c.Cards, ctx, id); err != nil {
+					return err
+				}
+				return a.done(map[string]string{"id": id, "result": past}, fmt.Sprintf("Card %s %s.", id, past))
+			},
+		}
+	}
+
+	return &command{
+		name: "cards",
+		subs: []*command{
+			{name: "list", summary: "list cards", run: runCardsList},
+			{name: "status", args: "<card-id>", summary: "show the status of a card", run: runCardsStatus},
+			action("freeze", "temporarily freeze a card", "frozen", (*gnosispay.CardService).Freeze),
+			action("unfreeze", "unfreeze a frozen card", "unfrozen", (*gnosispay.CardService).Unfreeze),
+			action("activate", "activate a card", "activated", (*gnosispay.CardService).Activate),
+			action("lost", "report a card as lost", "reported lost", (*gnosispay.CardService).ReportLost),
+			action("stolen", "report a card as stolen", "reported stolen", (*gnosispay.CardService).ReportStolen),
+		},
+	}
+}
+
+func runCardsList(ctx context.Context, a *app, args []string) error {
+	if err := parse(a.flags("cards list", ""), args, 0); err != nil {
+		return err
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	cards, err := c.Cards.List(ctx)
+	if err != nil {
+		return err
+	}
+	return a.render(cards, func() table {
+		t := table{header: []string{"ID", "LAST4", "ACTIVATED"}}
+		for _, card := range cards {
+			t.rows = append(t.rows, []string{card.Id, card.LastFourDigits, formatTime(card.ActivatedAt)})
+		}
+		return t
+	})
+}
+
+func runCardsStatus(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("cards status", "<card-id>")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	status, err := c.Cards.GetStatus(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return a.render(status, func() table {
+		return fields(
+			"state", status.State().String(),
+			"activated", status.ActivatedAt,
+			"status code", fmt.Sprint(status.StatusCode),
+			"frozen", yesNo(status.IsFrozen),
+			"lost", yesNo(status.IsLost),
+			"stolen", yesNo(status.IsStolen),
+			"blocked", yesNo(status.IsBlocked),
+			"void", yesNo(status.IsVoid),
+		)
+	})
+}
+
+func txCommand() *command {
+	return &command{
+		name: "tx",
+		subs: []*command{
+			{name: "list", summary: "list card transactions", run: runTxList},
+		},
+	}
+}
+
+// listFlag collects a comma-separated or repeated flag into a slice.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+// timeFlag accepts RFC 3339 timestamps or plain dates (midnight UTC).
+type timeFlag struct{ t *time.Time }
+
+func (f timeFlag) String() string {
+	if f.t == nil || f.t.IsZero() {
+		return ""
+	}
+	return f.t.Format(time.RFC3339)
+}
+
+func (f timeFlag) Set(v string) error {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, v); err == nil {
+			*f.t = t
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid time %q: use YYYY-MM-DD or RFC 3339", v)
+}
+
+// transactionFilters registers the ListTransactionsOptions flags.
+func transactionFilters(fs *flag.FlagSet, opts *gnosispay.ListTransactionsOptions, billing, transaction *string) {
+	fs.Var((*listFlag)(&opts.CardTokens), "card", "card ID to include (repeatable or comma-separated)")
+	fs.Var(timeFlag{&opts.After}, "after", "only events created after this time")
+	fs.Var(timeFlag{&opts.Before}, "before", "only events created before this time")
+	fs.StringVar(billing, "billing-currency", "", "ISO 4217 billing currency")
+	fs.StringVar(transaction, "transaction-currency", "", "ISO 4217 transaction currency")
+	fs.Var((*listFlag)(&opts.MCC), "mcc", "merchant category code (repeatable or comma-separated)")
+}
+
+func runTxList(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("tx list", "")
+	var opts gnosispay.ListTransactionsOptions
+	var billing, transaction string
+	var names []string
+	transactionFilters(fs, &opts, &billing, &transaction)
+	fs.Var((*listFlag)(&names), "category", "spending category such as groceries or travel (repeatable or comma-separated)")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	opts.BillingCurrency = gnosispay.CurrencyCode(strings.ToUpper(billing))
+	opts.TransactionCurrency = gnosispay.CurrencyCode(strings.ToUpper(transaction))
+	if err := opts.Validate(); err != nil {
+		return usagef("tx list: %v", err)
+	}
+	wanted := map[mcc.Category]bool{}
+	for _, name := range names {
+		cat, err := mcc.ParseCategory(name)
+		if err != nil {
+			return usagef("tx list: %v", err)
+		}
+		wanted[cat] = true
+	}
+	cats, err := a.categories()
+	if err != nil {
+		return err
+	}
+
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	events, err := c.Cards.ListTransactions(ctx, &opts)
+	if err != nil {
+		return err
+	}
+	// Categories may cover MCC ranges and user overrides, so they are
+	// matched here rather than expanded into the MCC filter.
+	if len(wanted) > 0 {
+		kept := events[:0]
+		for _, e := range events {
+			if wanted[cats.Category(e.Mcc)] {
+				kept = append(kept, e)
+			}
+		}
+		events = kept
+	}
+	return a.render(events, func() table {
+		t := table{header: []string{"CREATED", "STATUS", "KIND", "MERCHANT", "MCC", "CATEGORY", "AMOUNT", "ORIGINAL"}}
+		for _, e := range events {
+			merchant := ""
+			if e.Merchant != nil {
+				merchant = e.Merchant.Name
+			}
+			t.rows = append(t.rows, []string{
+				formatTime(e.CreatedAt), eventStatus(e), string(e.Kind), merchant, e.Mcc, string(cats.Category(e.Mcc)),
+				formatMoney(e.BillingMoney()), formatMoney(e.TransactionMoney()),
+			})
+		}
+		return t
+	})
+}
+
+// eventStatus reports whether a card event was declined or has settled.
+func eventStatus(e gnosispay.CardEvent) string {
+	if e.Declined() {
+		return "declined: " + e.Status.DeclineReason()
+	}
+	if e.IsPending {
+		return "pending"
+	}
+	return "cleared"
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func formatMoney(m gnosispay.Money, err error) string {
+	if err != nil {
+		return "-"
+	}
+	return m.String()
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/expense"
+	"github.com/guarilha/go-gnosispay/fx"
+	"github.com/guarilha/go-gnosispay/receipts"
+)
+
+func expensesCommand() *command {
+	return &command{
+		name:    "expenses",
+		summary: "write a monthly expense report as PDF or HTML (run from cron on the 1st for last month)",
+		run:     runExpenses,
+	}
+}
+
+// expensesResult summarises a written report.
+type expensesResult struct {
+	File            string `json:"file"`
+	Period          string `json:"period"`
+	Expenses        int    `json:"expenses"`
+	Total           string `json:"total"`
+	MissingReceipts int    `json:"missingReceipts"`
+}
+
+func runExpenses(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("expenses", "")
+	lastMonth := time.Now().AddDate(0, 0, -time.Now().Day()).Format("2006-01")
+	month := fs.String("month", lastMonth, "month to report as YYYY-MM (default: last month)")
+	format := fs.String("format", "", "pdf or html (default: from the -out extension, else pdf)")
+	out := fs.String("out", "", `file to write, or "-" for stdout (default: expenses-<month>.<format>)`)
+	currency := fs.String("currency", "", "reporting currency (default: the billing currency)")
+	ratesPath := fs.String("rates", "", "ECB reference rates CSV, to convert expenses billed in other currencies")
+	receiptsDir := fs.String("receipts", "", "receipts directory (default: the -db database, if it exists)")
+	dbPath := fs.String("db", "", "SQLite database with receipts (default: per-profile file in the config directory)")
+	minReceipt := fs.String("min-receipt", "", "smallest payment that needs a receipt, in the reporting currency")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	start, err := time.ParseInLocation("2006-01", *month, time.Local)
+	if err != nil {
+		return usagef("expenses: -month must look like 2025-01")
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*out)), ".")
+		if *format != "html" {
+			*format = "pdf"
+		}
+	}
+	if *format != "pdf" && *format != "html" {
+		return usagef("expenses: unknown format %q", *format)
+	}
+	if *out == "" {
+		*out = "expenses-" + *month + "." + *format
+	}
+
+	opts := &expense.Options{}
+	opts.From, opts.To = expense.Month(start.Year(), start.Month(), time.Local)
+	if opts.Categories, err = a.categories(); err != nil {
+		return err
+	}
+	if *currency != "" {
+		code := gnosispay.CurrencyCode(strings.ToUpper(*currency))
+		if err := code.Validate(); err != nil {
+			return usagef("expenses: %v", err)
+		}
+		opts.Currency = code.Currency()
+	}
+	if *minReceipt != "" {
+		if opts.Currency.Code == "" {
+			return usagef("expenses: -min-receipt needs -currency")
+		}
+		if opts.MinReceipt, err = gnosispay.ParseMoney(*minReceipt, opts.Currency); err != nil {
+			return usagef("expenses: -min-receipt: %v", err)
+		}
+	}
+	if *ratesPath != "" {
+		f, err := os.Open(*ratesPath)
+		if err != nil {
+			return err
+		}
+		opts.Rates, err = fx.ParseECB(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	store, closeStore, err := a.receiptStore(ctx, *receiptsDir, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	opts.Receipts = store
+
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	report, err := expense.Generate(ctx, c, opts)
+	if err != nil {
+		return err
+	}
+
+	write := func(w io.Writer, r *expense.Report) error {
+		// The PDF is complete; only some characters print as '?'.
+		var glyphErr *expense.GlyphError
+		if err := expense.WritePDF(w, r); !errors.As(err, &glyphErr) {
+			return err
+		}
+		fmt.Fprintf(a.stderr, "gnosispay: warning: the PDF shows %q as '?'; use -format html to keep them\n", string(glyphErr.Chars))
+		return nil
+	}
+	if *format == "html" {
+		write = expense.WriteHTML
+	}
+	if *out == "-" {
+		return write(a.stdout, report)
+	}
+	if err := writeReport(*out, report, write); err != nil {
+		return err
+	}
+
+	res := expensesResult{
+		File:            *out,
+		Period:          *month,
+		Expenses:        report.Lines(),
+		Total:           report.Total.String(),
+		MissingReceipts: report.Receipts.Missing,
+	}
+	return a.render(res, func() table {
+		return fields(
+			"file", res.File,
+			"period", res.Period,
+			"expenses", itoa(res.Expenses),
+			"total", res.Total,
+			"missing receipts", itoa(res.MissingReceipts),
+		)
+	})
+}
+
+// receiptStore opens the receipts directory, or the database when no
+// directory is given. Without either, the default database is used only
+// if it already exists, and the report shows receipts as unknown
+// otherwise.
+func (a *app) receiptStore(ctx context.Context, dir, dbPath string) (receipts.Store, func(), error) {
+	if dir != "" && dbPath != "" {
+		return nil, nil, usagef("expenses: use either -receipts or -db")
+	}
+	if dir != "" {
+		return &receipts.DirStore{Root: dir}, func() {}, nil
+	}
+	if dbPath == "" {
+		path, err := defaultDBPath(a.profile)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, func() {}, nil
+		}
+		dbPath = path
+	}
+	st, err := a.openStore(ctx, dbPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return st.Receipts(), func() { st.Close() }, nil
+}
+
+// writeReport writes the report to a temporary file next to path and
+// renames it into place, so a failed run leaves no partial report behind.
+func writeReport(path string, r *expense.Report, write func(io.Writer, *expense.Report) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/receipts"
+)
+
+func TestExpenses(t *testing.T) {
+	t.Setenv(envConfigDir, t.TempDir())
+	t.Setenv(envProfile, "")
+
+	eur := &gnosispay.Currency{Code: "EUR", Decimals: 2}
+	hotel := gnosispay.CardEvent{
+		CreatedAt: time.Date(2025, 1, 15, 12, 0, 0, 0, time.Local), Kind: "Payment", Status: "Approved", Mcc: "7011",
+		Merchant:      &gnosispay.Merchant{Name: "Hotel Adlon", City: "Berlin"},
+		BillingAmount: "42000", BillingCurrency: eur,
+		Transactions: []gnosispay.Transaction{{Hash: "0x1"}},
+	}
+	taxi := gnosispay.CardEvent{
+		CreatedAt: time.Date(2025, 1, 16, 12, 0, 0, 0, time.Local), Kind: "Payment", Status: "Approved", Mcc: "4121",
+		Merchant:      &gnosispay.Merchant{Name: "Taxi 東京"},
+		BillingAmount: "3000", BillingCurrency: eur,
+		Transactions: []gnosispay.Transaction{{Hash: "0x2"}},
+	}
+	var query string
+	srv := newTestServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /api/v1/user": jsonHandler(gnosispay.User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com"}),
+		"GET /transactions": func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+			json.NewEncoder(w).Encode([]gnosispay.CardEvent{hotel, taxi})
+		},
+	})
+	global := []string{"-base-url", srv.URL, "-token", testToken(t, time.Now().Add(time.Hour))}
+
+	dir := t.TempDir()
+	rs := &receipts.DirStore{Root: filepath.Join(dir, "receipts")}
+	if _, err := rs.Add(context.Background(), hotel.ID(), "folio.pdf", strings.NewReader("folio")); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "report.pdf")
+	code, stdout, stderr := runCLI(t, append(global, "expenses", "-month", "2025-01", "-out", out,
+		"-receipts", rs.Root, "-currency", "EUR", "-min-receipt", "25")...)
+	if code != exitOK {
+		t.Fatalf("exit code = %d (stderr: %s)", code, stderr)
+	}
+	if !strings.Contains(stderr, `warning: the PDF shows "京東" as '?'`) {
+		t.Errorf("stderr lacks the glyph warning:\n%s", stderr)
+	}
+	for _, want := range []string{out, "450.00 EUR", "missing receipts"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("output lacks %q:\n%s", want, stdout)
+		}
+	}
+	if !strings.Contains(query, "after=2025-01") || !strings.Contains(query, "before=2025-0") {
+		t.Errorf("transactions query = %q, want the month's bounds", query)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.Contains(data, []byte("/Author (Ada Lovelace)")) {
+		t.Errorf("report file = %.40q, %v", data, err)
+	}
+
+	code, stdout, _ = runCLI(t, append(global, "expenses", "-month", "2025-01", "-format", "html", "-out", "-",
+		"-receipts", rs.Root, "-currency", "EUR", "-min-receipt", "25")...)
+	if code != exitOK || !strings.Contains(stdout, "<h1>Expense report</h1>") || !strings.Contains(stdout, "1 attached, 1 missing, 0 not required") {
+		t.Errorf("html report = %d:\n%s", code, stdout)
+	}
+
+	for _, args := range [][]string{
+		{"-month", "January"},
+		{"-format", "docx"},
+		{"-min-receipt", "25"},
+		{"-receipts", dir, "-db", filepath.Join(dir, "x.db")},
+	} {
+		if code, _, _ := runCLI(t, append(append(global, "expenses"), args...)...); code != exitUsage {
+			t.Errorf("expenses %v exit code = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
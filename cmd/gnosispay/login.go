@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/term"
+)
+
+const (
+	envPrivateKey       = "GNOSISPAY_PRIVATE_KEY"
+	envKeystorePassword = "GNOSISPAY_KEYSTORE_PASSWORD"
+)
+
+func loginCommand() *command {
+	return &command{
+		name:    "login",
+		args:    "",
+		summary: "sign in with Ethereum using a private key or keystore file",
+		run:     runLogin,
+	}
+}
+
+func logoutCommand() *command {
+	return &command{
+		name:    "logout",
+		summary: "forget the cached session token",
+		run: func(ctx context.Context, a *app, args []string) error {
+			if err := parse(a.flags("logout", ""), args, 0); err != nil {
+				return err
+			}
+			if err := deleteSession(a.profile); err != nil {
+				return err
+			}
+			return a.done(map[string]bool{"ok": true}, "Logged out.")
+		},
+	}
+}
+
+// signerFlags select where the signing key comes from.
+type signerFlags struct {
+	key          string
+	keystore     string
+	passwordFile string
+}
+
+func (s *signerFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&s.key, "key", "", "hex-encoded private key (default $"+envPrivateKey+")")
+	fs.StringVar(&s.keystore, "keystore", "", "path to an encrypted JSON keystore file")
+	fs.StringVar(&s.passwordFile, "password-file", "", "file holding the keystore password (default $"+envKeystorePassword+" or prompt)")
+}
+
+// privateKey loads the signing key, falling back to the profile's signer
+// source when no flag is given. Keys are never echoed or logged.
+func (s *signerFlags) privateKey(a *app) (*ecdsa.PrivateKey, error) {
+	if s.keystore == "" && s.key == "" {
+		s.keystore = a.signer.Keystore
+		if a.signer.KeyEnv != "" {
+			s.key = os.Getenv(a.signer.KeyEnv)
+			if s.key == "" {
+				return nil, fmt.Errorf("profile %s: $%s is not set", a.profile, a.signer.KeyEnv)
+			}
+		}
+	}
+	if s.keystore != "" {
+		data, err := os.ReadFile(s.keystore)
+		if err != nil {
+			return nil, err
+		}
+		password, err := s.password(a)
+		if err != nil {
+			return nil, err
+		}
+		key, err := keystore.DecryptKey(data, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt keystore: %w", err)
+		}
+		return key.PrivateKey, nil
+	}
+
+	hex := s.key
+	if hex == "" {
+		hex = os.Getenv(envPrivateKey)
+	}
+	if hex == "" {
+		return nil, usagef("login: one of --key, --keystore or $%s is required", envPrivateKey)
+	}
+	pk, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hex), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return pk, nil
+}
+
+func (s *signerFlags) password(a *app) (string, error) {
+	if s.passwordFile != "" {
+		data, err := os.ReadFile(s.passwordFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if pw, ok := os.LookupEnv(envKeystorePassword); ok {
+		return pw, nil
+	}
+	fmt.Fprint(a.stderr, "Keystore password: ")
+	if f, ok := a.stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		pw, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(a.stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return string(pw), nil
+	}
+	line, err := bufio.NewReader(a.stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func runLogin(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("login", "")
+	var signer signerFlags
+	signer.register(fs)
+	force := fs.Bool("force", false, "sign in again even if the cached session is still valid")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	if !*force {
+		if token, err := loadSession(a.profile); err == nil && tokenValid(token) {
+			return a.done(map[string]string{"profile": a.profile, "result": "reused"},
+				fmt.Sprintf("Already logged in (profile %s).", a.profile))
+		}
+	}
+
+	pk, err := signer.privateKey(a)
+	if err != nil {
+		return err
+	}
+	address := crypto.PubkeyToAddress(pk.PublicKey)
+
+	c, err := a.newClient()
+	if err != nil {
+		return err
+	}
+	token, err := c.Auth.AuthenticateWithPrivateKey(ctx, address, pk)
+	if err != nil {
+		return err
+	}
+	if err := saveSession(a.profile, token); err != nil {
+		return err
+	}
+
+	result := map[string]string{"profile": a.profile, "address": address.Hex()}
+	return a.done(result, fmt.Sprintf("Logged in as %s (profile %s).", address.Hex(), a.profile))
+}
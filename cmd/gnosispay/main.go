@@ -0,0 +1,23 @@
+// Command gnosispay is a command-line client for the Gnosis Pay API.
+//
+// Usage:
+//
+//	gnosispay [global flags] <command> [subcommand] [flags] [args]
+//
+// Run "gnosispay help" for the list of commands. Authenticate once with
+// "gnosispay login"; the session token is cached and reused until it
+// expires.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
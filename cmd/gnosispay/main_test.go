@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/guarilha/go-gnosispay"
+)
+
+func testToken(t *testing.T, exp time.Time) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": float64(exp.Unix())})
+	s, err := token.SignedString([]byte("test-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// newTestServer serves canned responses keyed by "METHOD path".
+func newTestServer(t *testing.T, routes map[string]func(w http.ResponseWriter, r *http.Request)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := routes[r.Method+" "+r.URL.Path]; ok {
+			h(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(gnosispay.ApiError{Message: "not found"})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func jsonHandler(v any) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+func runCLI(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(""), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv(envConfigDir, t.TempDir())
+	t.Setenv(envToken, "")
+
+	var gotQuery string
+	srv := newTestServer(t, map[string]func(http.ResponseWriter, *http.Request){
+		"GET /api/v1/cards": jsonHandler([]gnosispay.Card{{Id: "card-1", LastFourDigits: "4242"}}),
+		"GET /transactions": func(w http.ResponseWriter, r *http.Request) {
+			gotQuery = r.URL.RawQuery
+			json.NewEncoder(w).Encode([]gnosispay.CardEvent{})
+		},
+		"POST /api/v1/cards/card-1/freeze": jsonHandler(map[string]bool{"ok": true}),
+		"GET /api/v1/account-balances": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("upstream unavailable"))
+		},
+		"GET /api/v1/user": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(gnosispay.ApiError{Message: "Unauthorized"})
+		},
+	})
+	token := testToken(t, time.Now().Add(time.Hour))
+	global := []string{"-base-url", srv.URL, "-token", token}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "table output",
+			args:       append(global, "cards", "list"),
+			wantCode:   exitOK,
+			wantStdout: "card-1",
+		},
+		{
+			name:       "json output",
+			args:       append(global, "-o", "json", "cards", "list"),
+			wantCode:   exitOK,
+			wantStdout: `"lastFourDigits": "4242"`,
+		},
+		{
+			name:       "yaml output",
+			args:       append(global, "-o", "yaml", "cards", "list"),
+			wantCode:   exitOK,
+			wantStdout: "lastFourDigits: \"4242\"",
+		},
+		{
+			name:       "card action",
+			args:       append(global, "cards", "freeze", "card-1"),
+			wantCode:   exitOK,
+			wantStdout: "Card card-1 frozen.",
+		},
+		{
+			name:       "not found maps to exit code",
+			args:       append(global, "cards", "status", "missing"),
+			wantCode:   exitNotFound,
+			wantStderr: "status 404",
+		},
+		{
+			name:     "unauthorized maps to exit code",
+			args:     append(global, "user", "show"),
+			wantCode: exitAuth,
+		},
+		{
+			name:       "server error maps to exit code",
+			args:       append(global, "balances"),
+			wantCode:   exitServer,
+			wantStderr: "upstream unavailable",
+		},
+		{
+			name:       "invalid filter is a usage error",
+			args:       append(global, "tx", "list", "-mcc", "food"),
+			wantCode:   exitUsage,
+			wantStderr: "invalid MCC",
+		},
+		{
+			name:     "missing argument",
+			args:     append(global, "cards", "freeze"),
+			wantCode: exitUsage,
+		},
+		{
+			name:       "unknown command",
+			args:       append(global, "cards", "shred"),
+			wantCode:   exitUsage,
+			wantStderr: `unknown command "cards shred"`,
+		},
+		{
+			name:       "not logged in",
+			args:       []string{"-base-url", srv.URL, "cards", "list"},
+			wantCode:   exitAuth,
+			wantStderr: "not logged in",
+		},
+		{
+			name:       "expired token",
+			args:       []string{"-base-url", srv.URL, "-token", testToken(t, time.Now().Add(-time.Hour)), "cards", "list"},
+			wantCode:   exitAuth,
+			wantStderr: "not logged in",
+		},
+		{
+			name:     "unknown output format",
+			args:     append(global, "-o", "xml", "cards", "list"),
+			wantCode: exitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(t, tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d (stderr: %s)", code, tt.wantCode, stderr)
+			}
+			if !strings.Contains(stdout, tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout, tt.wantStdout)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+
+	t.Run("tx list filters", func(t *testing.T) {
+		code, _, stderr := runCLI(t, append(global, "tx", "list",
+			"-card", "card-1", "-mcc", "5411,5812", "-after", "2025-01-01", "-billing-currency", "eur")...)
+		if code != exitOK {
+			t.Fatalf("exit code = %d (stderr: %s)", code, stderr)
+		}
+		want := "after=2025-01-01T00%3A00%3A00Z&billingCurrency=EUR&cardTokens=card-1&mcc=5411%2C5812"
+		if gotQuery != want {
+			t.Errorf("query = %s, want %s", gotQuery, want)
+		}
+	})
+	t.Run("token from the environment", func(t *testing.T) {
+		t.Setenv(envToken, token)
+		if code, _, stderr := runCLI(t, "-base-url", srv.URL, "cards", "list"); code != exitOK {
+			t.Errorf("exit code = %d (stderr: %s)", code, stderr)
+		}
+		code, stdout, _ := runCLI(t, "-h")
+		if code != exitOK || strings.Contains(stdout, token) || !strings.Contains(stdout, "$"+envToken) {
+			t.Errorf("-h = %d, want usage naming $%s without the token:\n%s", code, envToken, stdout)
+		}
+	})
+}
+
+func TestLogin(t *testing.T) {
+	t.Setenv(envConfigDir, t.TempDir())
+	t.Setenv(envToken, "")
+
+	token := testToken(t, time.Now().Add(time.Hour))
+	srv := newTestServer(t, map[string]func(http.ResponseWriter, *http.Request){
+		"GET /api/v1/auth/nonce": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("abcdefgh12345678"))
+		},
+		"POST /api/v1/auth/challenge": jsonHandler(map[string]string{"token": token}),
+		"GET /api/v1/cards":           jsonHandler([]gnosispay.Card{}),
+	})
+
+	// Well-known test key; never use it for real funds.
+	key := "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	code, stdout, stderr := runCLI(t, "-base-url", srv.URL, "login", "-key", key)
+	if code != exitOK {
+		t.Fatalf("login exit code = %d (stderr: %s)", code, stderr)
+	}
+	if !strings.Contains(stdout, "Logged in as 0x") {
+		t.Errorf("login stdout = %q", stdout)
+	}
+	if strings.Contains(stdout+stderr, key) {
+		t.Error("login printed the private key")
+	}
+
+	if code, _, stderr := runCLI(t, "-base-url", srv.URL, "cards", "list"); code != exitOK {
+		t.Errorf("cards list after login exit code = %d (stderr: %s)", code, stderr)
+	}
+
+	if code, _, _ := runCLI(t, "logout"); code != exitOK {
+		t.Errorf("logout exit code = %d", code)
+	}
+	if code, _, _ := runCLI(t, "-base-url", srv.URL, "cards", "list"); code != exitAuth {
+		t.Errorf("cards list after logout exit code = %d, want %d", code, exitAuth)
+	}
+}
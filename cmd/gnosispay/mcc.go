@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/guarilha/go-gnosispay/mcc"
+)
+
+// mccOverrides is the file in the config directory whose rows override the
+// embedded merchant category table.
+const mccOverrides = "mcc.csv"
+
+// categories returns the merchant category table with the user's overrides
+// applied.
+func (a *app) categories() (*mcc.Table, error) {
+	if a.mcc != nil {
+		return a.mcc, nil
+	}
+	t, err := mcc.NewTable()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.LoadFile(filepath.Join(dir, mccOverrides)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	a.mcc = t
+	return t, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+func TestTxListCategory(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(envConfigDir, dir)
+	t.Setenv(envProfile, "")
+
+	srv := newTestServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /transactions": jsonHandler([]gnosispay.CardEvent{
+			{Kind: "Payment", Mcc: "5411", BillingAmount: "100"},
+			{Kind: "Payment", Mcc: "5814", BillingAmount: "200"},
+			{Kind: "Payment", Mcc: "3012", BillingAmount: "300"},
+		}),
+	})
+	global := []string{"-base-url", srv.URL, "-token", testToken(t, time.Now().Add(time.Hour)), "-o", "json"}
+
+	mccs := func(args ...string) []string {
+		t.Helper()
+		code, stdout, stderr := runCLI(t, append(global, args...)...)
+		if code != exitOK {
+			t.Fatalf("%v exit code = %d (stderr: %s)", args, code, stderr)
+		}
+		var events []gnosispay.CardEvent
+		if err := json.Unmarshal([]byte(stdout), &events); err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, e := range events {
+			out = append(out, e.Mcc)
+		}
+		return out
+	}
+
+	if got := mccs("tx", "list", "-category", "groceries,travel"); len(got) != 2 || got[0] != "5411" || got[1] != "3012" {
+		t.Errorf("groceries,travel = %v", got)
+	}
+	if err := os.WriteFile(filepath.Join(dir, mccOverrides), []byte("5814,groceries\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := mccs("tx", "list", "-category", "Groceries"); len(got) != 2 || got[1] != "5814" {
+		t.Errorf("groceries with override = %v", got)
+	}
+	if code, _, _ := runCLI(t, append(global, "tx", "list", "-category", "snacks")...); code != exitUsage {
+		t.Errorf("unknown category exit code = %d, want %d", code, exitUsage)
+	}
+}
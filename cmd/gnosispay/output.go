@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// table is the tabular rendering of a command result.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// render writes v in the selected output format. JSON and YAML use the
+// API field names; the table layout is built lazily by tbl.
+func (a *app) render(v any, tbl func() table) error {
+	switch a.output {
+	case "json":
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(a.stdout, "%s\n", data)
+		return err
+
+	case "yaml":
+		// Round-trip through JSON so YAML keys match the JSON field names.
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var generic any
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return err
+		}
+		out, err := yaml.Marshal(generic)
+		if err != nil {
+			return err
+		}
+		_, err = a.stdout.Write(out)
+		return err
+
+	default:
+		t := tbl()
+		tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// done reports the outcome of a command that returns no data.
+func (a *app) done(v any, msg string) error {
+	if a.output == "table" {
+		_, err := fmt.Fprintln(a.stdout, msg)
+		return err
+	}
+	return a.render(v, nil)
+}
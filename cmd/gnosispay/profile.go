@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultProfile = "default"
+	envProfile     = "GNOSISPAY_PROFILE"
+)
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// config is the plain-text CLI configuration. It never holds secrets:
+// session tokens live in the credentials directory and signing keys are
+// referenced by keystore path or environment variable name only.
+type config struct {
+	Current  string              `json:"current,omitempty"`
+	Profiles map[string]*profile `json:"profiles"`
+}
+
+// profile holds the connection settings for one Gnosis Pay user.
+type profile struct {
+	BaseURL string       `json:"baseUrl,omitempty"`
+	SIWEURI string       `json:"siweUri,omitempty"`
+	ChainID int          `json:"chainId,omitempty"`
+	Signer  signerSource `json:"signer,omitempty"`
+}
+
+// signerSource says where login finds the signing key.
+type signerSource struct {
+	Keystore string `json:"keystore,omitempty"` // Path to an encrypted JSON keystore.
+	KeyEnv   string `json:"keyEnv,omitempty"`   // Environment variable holding a hex key.
+}
+
+func (s signerSource) String() string {
+	switch {
+	case s.Keystore != "":
+		return "keystore:" + s.Keystore
+	case s.KeyEnv != "":
+		return "env:" + s.KeyEnv
+	}
+	return "-"
+}
+
+// configDir returns the directory holding CLI state, honouring
+// GNOSISPAY_CONFIG_DIR.
+func configDir() (string, error) {
+	if dir := os.Getenv(envConfigDir); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gnosispay"), nil
+}
+
+func loadConfig() (*config, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &config{Profiles: map[string]*profile{}}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]*profile{}
+	}
+	return cfg, nil
+}
+
+func (c *config) save() error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	// config.json names keystores and key variables, so it is readable
+	// only by the user, and it is replaced atomically so a crash never
+	// loses the profiles.
+	path := filepath.Join(dir, "config.json")
+	tmp, err := os.CreateTemp(dir, "config.json.*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (c *config) names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// credentialPath returns the file caching the session token of a profile.
+// Tokens are kept apart from config.json, readable only by the user.
+func credentialPath(name string) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials", name+".token"), nil
+}
+
+// loadSession returns the cached session token of a profile, or
+// errNotLoggedIn if there is none.
+func loadSession(name string) (string, error) {
+	path, err := credentialPath(name)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", errNotLoggedIn
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func saveSession(name, token string) error {
+	path, err := credentialPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(token+"\n"), 0o600)
+}
+
+func deleteSession(name string) error {
+	path, err := credentialPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func profileCommand() *command {
+	return &command{
+		name: "profile",
+		subs: []*command{
+			{name: "add", args: "<name>", summary: "add or update a profile", run: runProfileAdd},
+			{name: "list", summary: "list profiles", run: runProfileList},
+			{name: "use", args: "<name>", summary: "make a profile the default", run: runProfileUse},
+			{name: "remove", args: "<name>", summary: "remove a profile and its cached session", run: runProfileRemove},
+		},
+	}
+}
+
+// runProfileAdd creates a profile or updates an existing one. Only the
+// flags given change an existing profile; the others keep their values.
+func runProfileAdd(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("profile add", "<name>")
+	var given profile
+	fs.StringVar(&given.BaseURL, "base-url", "", "API base URL")
+	fs.StringVar(&given.SIWEURI, "siwe-uri", "", "application URI used in the SIWE message")
+	fs.IntVar(&given.ChainID, "chain-id", 0, "chain ID used in the SIWE message")
+	fs.StringVar(&given.Signer.Keystore, "keystore", "", "path to an encrypted JSON keystore file")
+	fs.StringVar(&given.Signer.KeyEnv, "key-env", "", "environment variable holding the hex private key")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+	if !profileName.MatchString(name) {
+		return usagef("profile add: invalid name %q: use letters, digits, '-' and '_'", name)
+	}
+	if given.Signer.Keystore != "" && given.Signer.KeyEnv != "" {
+		return usagef("profile add: -keystore and -key-env are mutually exclusive")
+	}
+	if given.Signer.Keystore != "" {
+		abs, err := filepath.Abs(given.Signer.Keystore)
+		if err != nil {
+			return err
+		}
+		given.Signer.Keystore = abs
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	var p profile
+	old, existed := cfg.Profiles[name]
+	if existed {
+		p = *old
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "base-url":
+			p.BaseURL = given.BaseURL
+		case "siwe-uri":
+			p.SIWEURI = given.SIWEURI
+		case "chain-id":
+			p.ChainID = given.ChainID
+		case "keystore", "key-env":
+			// A profile has one signer; choosing one replaces the other.
+			p.Signer = given.Signer
+		}
+	})
+	cfg.Profiles[name] = &p
+	if cfg.Current == "" {
+		cfg.Current = name
+	}
+	if err := cfg.save(); err != nil {
+		return err
+	}
+	verb := "Added"
+	if existed {
+		verb = "Updated"
+	}
+	return a.done(map[string]string{"profile": name}, fmt.Sprintf("%s profile %s.", verb, name))
+}
+
+type profileInfo struct {
+	Name     string `json:"name"`
+	Current  bool   `json:"current"`
+	BaseURL  string `json:"baseUrl,omitempty"`
+	SIWEURI  string `json:"siweUri,omitempty"`
+	ChainID  int    `json:"chainId,omitempty"`
+	Signer   string `json:"signer"`
+	LoggedIn bool   `json:"loggedIn"`
+}
+
+func runProfileList(ctx context.Context, a *app, args []string) error {
+	if err := parse(a.flags("profile list", ""), args, 0); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	infos := []profileInfo{}
+	for _, name := range cfg.names() {
+		p := cfg.Profiles[name]
+		info := profileInfo{
+			Name:    name,
+			Current: name == a.profile,
+			BaseURL: p.BaseURL,
+			SIWEURI: p.SIWEURI,
+			ChainID: p.ChainID,
+			Signer:  p.Signer.String(),
+		}
+		if token, err := loadSession(name); err == nil {
+			info.LoggedIn = tokenValid(token)
+		}
+		infos = append(infos, info)
+	}
+	return a.render(infos, func() table {
+		t := table{header: []string{"", "NAME", "BASE URL", "SIGNER", "LOGGED IN"}}
+		for _, p := range infos {
+			mark := ""
+			if p.Current {
+				mark = "*"
+			}
+			baseURL := p.BaseURL
+			if baseURL == "" {
+				baseURL = "-"
+			}
+			t.rows = append(t.rows, []string{mark, p.Name, baseURL, p.Signer, yesNo(p.LoggedIn)})
+		}
+		return t
+	})
+}
+
+func runProfileUse(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("profile use", "<name>")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+	if _, ok := cfg.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	cfg.Current = name
+	if err := cfg.save(); err != nil {
+		return err
+	}
+	return a.done(map[string]string{"profile": name}, fmt.Sprintf("Using profile %s.", name))
+}
+
+func runProfileRemove(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("profile remove", "<name>")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+	if _, ok := cfg.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	delete(cfg.Profiles, name)
+	if cfg.Current == name {
+		cfg.Current = ""
+	}
+	if err := cfg.save(); err != nil {
+		return err
+	}
+	if err := deleteSession(name); err != nil {
+		return err
+	}
+	return a.done(map[string]string{"profile": name}, fmt.Sprintf("Removed profile %s.", name))
+}
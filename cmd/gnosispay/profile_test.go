@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+// authServer serves the SIWE flow and counts challenges.
+func authServer(t *testing.T, token string, challenges *atomic.Int32) string {
+	t.Helper()
+	srv := newTestServer(t, map[string]func(http.ResponseWriter, *http.Request){
+		"GET /api/v1/auth/nonce": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("abcdefgh12345678"))
+		},
+		"POST /api/v1/auth/challenge": func(w http.ResponseWriter, r *http.Request) {
+			challenges.Add(1)
+			json.NewEncoder(w).Encode(map[string]string{"token": token})
+		},
+		"GET /api/v1/cards": jsonHandler([]gnosispay.Card{}),
+	})
+	return srv.URL
+}
+
+func TestProfiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(envConfigDir, dir)
+	t.Setenv(envToken, "")
+	t.Setenv(envProfile, "")
+	t.Setenv(envBaseURL, "")
+
+	key := "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	t.Setenv("ALICE_KEY", key)
+	t.Setenv("BOB_KEY", key)
+
+	var aliceChallenges, bobChallenges atomic.Int32
+	aliceToken := testToken(t, time.Now().Add(time.Hour))
+	bobToken := testToken(t, time.Now().Add(2*time.Hour))
+	aliceURL := authServer(t, aliceToken, &aliceChallenges)
+	bobURL := authServer(t, bobToken, &bobChallenges)
+
+	mustRun := func(args ...string) string {
+		t.Helper()
+		code, stdout, stderr := runCLI(t, args...)
+		if code != exitOK {
+			t.Fatalf("%v: exit code = %d (stderr: %s)", args, code, stderr)
+		}
+		return stdout
+	}
+
+	mustRun("profile", "add", "-base-url", aliceURL, "-key-env", "ALICE_KEY", "alice")
+	mustRun("profile", "add", "-base-url", bobURL, "-key-env", "BOB_KEY", "-chain-id", "10200", "bob")
+
+	// The first profile added becomes current.
+	mustRun("login")
+	if aliceChallenges.Load() != 1 || bobChallenges.Load() != 0 {
+		t.Fatalf("challenges = %d/%d, want alice to log in", aliceChallenges.Load(), bobChallenges.Load())
+	}
+
+	mustRun("-profile", "bob", "login")
+	if bobChallenges.Load() != 1 {
+		t.Fatalf("bob challenges = %d, want 1", bobChallenges.Load())
+	}
+
+	// A valid cached token is reused instead of signing in again.
+	if out := mustRun("login"); !strings.Contains(out, "Already logged in") {
+		t.Errorf("second login output = %q", out)
+	}
+	if aliceChallenges.Load() != 1 {
+		t.Errorf("alice challenges = %d, want the cached token to be reused", aliceChallenges.Load())
+	}
+
+	mustRun("profile", "use", "bob")
+	mustRun("cards", "list")
+
+	var infos []profileInfo
+	if err := json.Unmarshal([]byte(mustRun("-o", "json", "profile", "list")), &infos); err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 || !infos[1].Current || infos[1].Name != "bob" || !infos[0].LoggedIn {
+		t.Errorf("profile list = %+v", infos)
+	}
+
+	// Updating a profile keeps the settings not given again.
+	if out := mustRun("profile", "add", "-siwe-uri", "https://example.com", "bob"); !strings.Contains(out, "Updated profile bob") {
+		t.Errorf("profile add output = %q", out)
+	}
+	infos = nil
+	if err := json.Unmarshal([]byte(mustRun("-o", "json", "profile", "list")), &infos); err != nil {
+		t.Fatal(err)
+	}
+	if b := infos[1]; b.BaseURL != bobURL || b.ChainID != 10200 || b.Signer != "env:BOB_KEY" || b.SIWEURI != "https://example.com" {
+		t.Errorf("updated profile = %+v", b)
+	}
+
+	// Secrets stay out of the plain config file.
+	cfgData, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{key, aliceToken, bobToken} {
+		if strings.Contains(string(cfgData), secret) {
+			t.Errorf("config.json contains a secret")
+		}
+	}
+	for _, name := range []string{"config.json", filepath.Join("credentials", "bob.token")} {
+		fi, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0o600 {
+			t.Errorf("%s mode = %v, want 0600", name, fi.Mode().Perm())
+		}
+	}
+
+	mustRun("profile", "remove", "bob")
+	if _, err := os.Stat(filepath.Join(dir, "credentials", "bob.token")); !os.IsNotExist(err) {
+		t.Errorf("removing a profile left its credentials behind: %v", err)
+	}
+	if code, _, _ := runCLI(t, "cards", "list"); code != exitAuth {
+		t.Errorf("cards list without current profile session exit code = %d, want %d", code, exitAuth)
+	}
+
+	if code, _, _ := runCLI(t, "profile", "use", "nobody"); code != exitError {
+		t.Errorf("profile use for unknown profile exit code = %d, want %d", code, exitError)
+	}
+	if code, _, _ := runCLI(t, "profile", "add", "bad/name"); code != exitUsage {
+		t.Errorf("profile add with invalid name exit code = %d, want %d", code, exitUsage)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/recurring"
+)
+
+func recurringCommand() *command {
+	return &command{
+		name:    "recurring",
+		summary: "report subscriptions and other recurring charges per card",
+		run:     runRecurring,
+	}
+}
+
+// recurringSeries is a detected series together with the card it is
+// charged to.
+type recurringSeries struct {
+	Card string `json:"card"`
+	recurring.Series
+}
+
+func runRecurring(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("recurring", "")
+	var cards []string
+	after := time.Now().AddDate(-1, -1, 0)
+	fs.Var((*listFlag)(&cards), "card", "card ID to include (repeatable or comma-separated; default: all cards)")
+	fs.Var(timeFlag{&after}, "after", "history to analyse (default: the last 13 months)")
+	minCharges := fs.Int("min-charges", 3, "charges needed before a series counts as recurring")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *minCharges < 2 {
+		return usagef("recurring: -min-charges must be at least 2")
+	}
+
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{}
+	if len(cards) == 0 {
+		list, err := c.Cards.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, card := range list {
+			cards = append(cards, card.Id)
+			labels[card.Id] = cardLabel(card)
+		}
+	}
+
+	// Card events do not name their card, so each card is fetched on its
+	// own to attribute the series.
+	report := []recurringSeries{}
+	opts := &recurring.Options{MinCharges: *minCharges}
+	for _, id := range cards {
+		events, err := c.Cards.ListTransactions(ctx, &gnosispay.ListTransactionsOptions{CardTokens: []string{id}, After: after})
+		if err != nil {
+			return err
+		}
+		for _, s := range recurring.Detect(events, opts) {
+			report = append(report, recurringSeries{Card: id, Series: s})
+		}
+	}
+
+	return a.render(report, func() table {
+		t := table{header: []string{"CARD", "MERCHANT", "CADENCE", "CHARGES", "LAST", "NEXT", "NEXT AMOUNT", "FLAGS"}}
+		for _, r := range report {
+			card := labels[r.Card]
+			if card == "" {
+				card = r.Card
+			}
+			var flags []string
+			if r.PriceIncreased() {
+				last := r.PriceChanges[len(r.PriceChanges)-1]
+				flags = append(flags, "price up from "+last.From.String())
+			}
+			if r.Missed > 0 {
+				flags = append(flags, "missed "+itoa(r.Missed))
+			}
+			t.rows = append(t.rows, []string{
+				card, r.Merchant, string(r.Cadence), itoa(len(r.Charges)),
+				formatTime(r.Last().At), r.NextAt.Format(time.DateOnly), r.NextAmount.String(),
+				strings.Join(flags, ", "),
+			})
+		}
+		return t
+	})
+}
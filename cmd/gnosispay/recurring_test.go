@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+func TestRecurring(t *testing.T) {
+	t.Setenv(envConfigDir, t.TempDir())
+	t.Setenv(envProfile, "")
+
+	eur := &gnosispay.Currency{Code: "EUR", Decimals: 2}
+	start := time.Now().AddDate(0, -4, 0)
+	var subscription []gnosispay.CardEvent
+	for i, amount := range []string{"1299", "1299", "1299", "1499"} {
+		subscription = append(subscription, gnosispay.CardEvent{
+			CreatedAt: start.AddDate(0, i, 0), Kind: "Payment", Status: "Approved",
+			Merchant:      &gnosispay.Merchant{Name: "Netflix"},
+			BillingAmount: amount, BillingCurrency: eur,
+		})
+	}
+	srv := newTestServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /api/v1/cards": jsonHandler([]gnosispay.Card{{Id: "card-1", LastFourDigits: "4242"}, {Id: "card-2", LastFourDigits: "1111"}}),
+		"GET /transactions": func(w http.ResponseWriter, r *http.Request) {
+			events := []gnosispay.CardEvent{}
+			if r.URL.Query().Get("cardTokens") == "card-2" {
+				events = subscription
+			}
+			json.NewEncoder(w).Encode(events)
+		},
+	})
+	global := []string{"-base-url", srv.URL, "-token", testToken(t, time.Now().Add(time.Hour))}
+
+	code, stdout, stderr := runCLI(t, append(global, "recurring")...)
+	if code != exitOK {
+		t.Fatalf("exit code = %d (stderr: %s)", code, stderr)
+	}
+	for _, want := range []string{"****1111", "NETFLIX", "monthly", "14.99 EUR", "price up from 12.99 EUR"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("output lacks %q:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "****4242") {
+		t.Errorf("card without subscriptions listed:\n%s", stdout)
+	}
+
+	code, stdout, _ = runCLI(t, append(global, "-o", "json", "recurring", "-card", "card-2")...)
+	var got []recurringSeries
+	if code != exitOK || json.Unmarshal([]byte(stdout), &got) != nil || len(got) != 1 || got[0].Card != "card-2" || len(got[0].Charges) != 4 {
+		t.Errorf("json report = %d %s", code, stdout)
+	}
+
+	if code, _, _ := runCLI(t, append(global, "recurring", "-min-charges", "1")...); code != exitUsage {
+		t.Errorf("-min-charges 1 exit code = %d, want %d", code, exitUsage)
+	}
+}
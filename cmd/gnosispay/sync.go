@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/guarilha/go-gnosispay/store"
+)
+
+func syncCommand() *command {
+	return &command{
+		name:    "sync",
+		summary: "copy cards, transactions, IBAN orders and balances into a local SQLite database",
+		run:     runSync,
+	}
+}
+
+// defaultDBPath keeps one database per profile in the config directory.
+func defaultDBPath(profile string) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "data", profile+".db"), nil
+}
+
+// openStore opens the -db database, creating its directory if needed.
+func (a *app) openStore(ctx context.Context, path string) (*store.Store, error) {
+	if path == "" {
+		var err error
+		if path, err = defaultDBPath(a.profile); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	return store.Open(ctx, path)
+}
+
+func runSync(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("sync", "")
+	dbPath := fs.String("db", "", "SQLite database path (default: per-profile file in the config directory)")
+	var since time.Time
+	fs.Var(timeFlag{&since}, "since", "on the first sync, only fetch transactions created after this time")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	st, err := a.openStore(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	res, err := st.Sync(ctx, c, &store.SyncOptions{Since: since})
+	if err != nil {
+		return err
+	}
+	return a.render(res, func() table {
+		return fields(
+			"cards", itoa(res.Cards),
+			"card events", itoa(res.CardEvents),
+			"iban orders", itoa(res.IbanOrders),
+			"delayed transactions", itoa(res.DelayedTransactions),
+			"balance snapshots", itoa(res.BalanceSnapshots),
+		)
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/store"
+)
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(envConfigDir, dir)
+	t.Setenv(envProfile, "")
+
+	srv := newTestServer(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /api/v1/cards":               jsonHandler([]gnosispay.Card{{Id: "card-1", LastFourDigits: "4242"}}),
+		"GET /api/v1/cards/card-1/status": jsonHandler(gnosispay.CardStatus{}),
+		"GET /transactions": jsonHandler([]gnosispay.CardEvent{
+			{CreatedAt: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), Kind: "Payment", BillingAmount: "100"},
+		}),
+		"GET /api/v1/ibans/orders":     jsonHandler(map[string][]gnosispay.IbanOrder{"data": {}}),
+		"GET /api/v1/delay-relay":      jsonHandler([]gnosispay.DelayTransaction{}),
+		"GET /api/v1/account-balances": jsonHandler(gnosispay.AccountBalances{Total: "1", Spendable: "1", Pending: "0"}),
+	})
+	global := []string{"-base-url", srv.URL, "-token", testToken(t, time.Now().Add(time.Hour))}
+
+	code, stdout, stderr := runCLI(t, append(global, "-o", "json", "sync", "-since", "2025-01-01")...)
+	if code != exitOK {
+		t.Fatalf("sync exit code = %d (stderr: %s)", code, stderr)
+	}
+	var res store.SyncResult
+	if err := json.Unmarshal([]byte(stdout), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Cards != 1 || res.CardEvents != 1 || res.BalanceSnapshots != 1 {
+		t.Errorf("sync result = %+v", res)
+	}
+
+	path := filepath.Join(dir, "data", "default.db")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("default database not created: %v", err)
+	}
+	custom := filepath.Join(t.TempDir(), "custom.db")
+	if code, _, stderr := runCLI(t, append(global, "sync", "-db", custom)...); code != exitOK {
+		t.Fatalf("sync -db exit code = %d (stderr: %s)", code, stderr)
+	}
+	if _, err := os.Stat(custom); err != nil {
+		t.Errorf("-db database not created: %v", err)
+	}
+}
@@ -0,0 +1,698 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/guarilha/go-gnosispay"
+	"golang.org/x/term"
+)
+
+// tokenDecimals is the precision of the Safe's token; the API reports
+// balances in its base units.
+const tokenDecimals = 18
+
+func tuiCommand() *command {
+	return &command{
+		name:    "tui",
+		summary: "full-screen dashboard of balances, cards and transactions",
+		run:     runTUI,
+	}
+}
+
+func runTUI(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("tui", "")
+	var opts gnosispay.ListTransactionsOptions
+	var billing, transaction string
+	transactionFilters(fs, &opts, &billing, &transaction)
+	every := fs.Duration("refresh", 30*time.Second, "how often to reload balances, cards and transactions")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	opts.BillingCurrency = gnosispay.CurrencyCode(strings.ToUpper(billing))
+	opts.TransactionCurrency = gnosispay.CurrencyCode(strings.ToUpper(transaction))
+	if err := opts.Validate(); err != nil {
+		return usagef("tui: %v", err)
+	}
+	if *every < time.Second {
+		return usagef("tui: -refresh must be at least 1s")
+	}
+
+	in, ok := a.stdin.(*os.File)
+	if !ok || !term.IsTerminal(int(in.Fd())) {
+		return usagef("tui: standard input is not a terminal")
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return err
+	}
+	defer term.Restore(int(in.Fd()), state)
+
+	sizeFd := int(in.Fd())
+	if out, ok := a.stdout.(*os.File); ok && term.IsTerminal(int(out.Fd())) {
+		sizeFd = int(out.Fd())
+	}
+	size := func() (int, int) {
+		w, h, err := term.GetSize(sizeFd)
+		if err != nil {
+			return 80, 24
+		}
+		return w, h
+	}
+	return newDashboard(c, opts, a.profile).run(ctx, in, a.stdout, size, *every)
+}
+
+// Dashboard panes that take the cursor keys.
+const (
+	paneCards = iota
+	paneFeed
+)
+
+// dashboardCard is a card with its most recently fetched status.
+type dashboardCard struct {
+	gnosispay.Card
+	Status *gnosispay.CardStatus
+}
+
+// snapshot is the result of one background refresh.
+type snapshot struct {
+	currency gnosispay.Currency
+	balances *gnosispay.AccountBalances
+	cards    []dashboardCard
+	events   []gnosispay.CardEvent
+	err      error
+	at       time.Time
+}
+
+// dashboard is the state of the "gnosispay tui" screen. Data is reloaded in
+// the background; key handling and drawing happen on the run loop only.
+type dashboard struct {
+	client  *gnosispay.Client
+	opts    gnosispay.ListTransactionsOptions
+	profile string
+
+	currency gnosispay.Currency
+	balances *gnosispay.AccountBalances
+	cards    []dashboardCard
+	events   []gnosispay.CardEvent
+	updated  time.Time
+	err      error
+
+	focus       int
+	selected    int
+	offset      int
+	feedHeight  int
+	filter      string
+	editing     bool
+	pendingOnly bool
+	confirm     *cardAction
+	acting      bool // A confirmed card action is running.
+	message     string
+}
+
+// cardAction is a freeze or unfreeze awaiting confirmation.
+type cardAction struct {
+	card   dashboardCard
+	freeze bool
+}
+
+// actionResult is the outcome of a card action run in the background.
+type actionResult struct {
+	act    cardAction
+	status *gnosispay.CardStatus // Re-read after the action; nil if that failed.
+	err    error
+}
+
+// Results of handleKey that need the run loop.
+const (
+	keyHandled = iota
+	keyQuit
+	keyRefresh
+	keyConfirmed
+)
+
+func newDashboard(c *gnosispay.Client, opts gnosispay.ListTransactionsOptions, profile string) *dashboard {
+	return &dashboard{client: c, opts: opts, profile: profile, feedHeight: 10}
+}
+
+func (d *dashboard) run(ctx context.Context, in io.Reader, out io.Writer, size func() (int, int), every time.Duration) error {
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	done := make(chan struct{})
+	defer close(done)
+	keys := make(chan string)
+	go readKeys(in, keys, done)
+
+	snaps := make(chan snapshot, 1)
+	results := make(chan actionResult, 1)
+	loading := false
+	reload := func() {
+		if loading {
+			return
+		}
+		loading = true
+		go func() { snaps <- d.load(ctx) }()
+	}
+
+	refresh := time.NewTicker(every)
+	defer refresh.Stop()
+	clock := time.NewTicker(time.Second)
+	defer clock.Stop()
+
+	d.message = "Loading…"
+	reload()
+	for {
+		w, h := size()
+		d.draw(out, w, h)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case s := <-snaps:
+			loading = false
+			d.apply(s)
+		case <-refresh.C:
+			reload()
+		case <-clock.C:
+		case k, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			switch d.handleKey(k) {
+			case keyQuit:
+				return nil
+			case keyRefresh:
+				d.message = "Refreshing…"
+				reload()
+			case keyConfirmed:
+				act := *d.confirm
+				d.confirm, d.acting = nil, true
+				go func() { results <- d.perform(ctx, act) }()
+			}
+		case r := <-results:
+			d.acting = false
+			d.finish(r)
+			reload()
+		}
+	}
+}
+
+// load fetches everything shown on the dashboard. It only reads d.client
+// and d.opts, so it is safe to call from a goroutine.
+func (d *dashboard) load(ctx context.Context) snapshot {
+	s := snapshot{at: time.Now()}
+	if cfg, err := d.client.Account.GetSafeConfig(ctx); err == nil {
+		if code := gnosispay.CurrencyCode(cfg.FiatSymbol); code.Validate() == nil {
+			s.currency = code.Currency()
+		}
+	}
+	if s.balances, s.err = d.client.Account.GetBalances(ctx); s.err != nil {
+		return s
+	}
+	cards, err := d.client.Cards.List(ctx)
+	if err != nil {
+		s.err = err
+		return s
+	}
+	for _, card := range cards {
+		status, err := d.client.Cards.GetStatus(ctx, card.Id)
+		if err != nil {
+			s.err = err
+			return s
+		}
+		s.cards = append(s.cards, dashboardCard{Card: card, Status: status})
+	}
+	opts := d.opts
+	if s.events, s.err = d.client.Cards.ListTransactions(ctx, &opts); s.err != nil {
+		return s
+	}
+	sort.SliceStable(s.events, func(i, j int) bool {
+		return s.events[i].CreatedAt.After(s.events[j].CreatedAt)
+	})
+	return s
+}
+
+// apply installs a snapshot, keeping the selected card and scroll position.
+// A failed refresh keeps the previous data on screen.
+func (d *dashboard) apply(s snapshot) {
+	d.err = s.err
+	if s.err != nil {
+		d.message = ""
+		return
+	}
+	var selectedID string
+	if d.selected < len(d.cards) {
+		selectedID = d.cards[d.selected].Id
+	}
+	d.currency, d.balances, d.cards, d.events, d.updated = s.currency, s.balances, s.cards, s.events, s.at
+	d.selected = 0
+	for i, c := range d.cards {
+		if c.Id == selectedID {
+			d.selected = i
+		}
+	}
+	d.scroll(0)
+	if d.message == "Loading…" || d.message == "Refreshing…" {
+		d.message = ""
+	}
+}
+
+// perform moves the card to the state the action asks for and re-reads
+// its status. Like load, it only reads d.client, so it runs on a goroutine.
+func (d *dashboard) perform(ctx context.Context, act cardAction) actionResult {
+	target := gnosispay.CardStateActive
+	if act.freeze {
+		target = gnosispay.CardStateFrozen
+	}
+	r := actionResult{act: act}
+	if r.err = d.client.Cards.Transition(ctx, act.card.Id, target); r.err != nil {
+		return r
+	}
+	r.status, _ = d.client.Cards.GetStatus(ctx, act.card.Id)
+	return r
+}
+
+// finish reports the outcome of a card action and installs the card's new
+// status.
+func (d *dashboard) finish(r actionResult) {
+	verb, past := "unfreeze", "unfrozen"
+	if r.act.freeze {
+		verb, past = "freeze", "frozen"
+	}
+	if r.err != nil {
+		d.message = fmt.Sprintf("Could not %s card %s: %v", verb, cardLabel(r.act.card.Card), r.err)
+		return
+	}
+	d.message = fmt.Sprintf("Card %s %s.", cardLabel(r.act.card.Card), past)
+	if r.status == nil {
+		return
+	}
+	for i := range d.cards {
+		if d.cards[i].Id == r.act.card.Id {
+			d.cards[i].Status = r.status
+		}
+	}
+}
+
+// handleKey updates the dashboard for one key press.
+func (d *dashboard) handleKey(k string) int {
+	if d.confirm != nil {
+		if k == "y" || k == "Y" {
+			verb := "Unfreezing"
+			if d.confirm.freeze {
+				verb = "Freezing"
+			}
+			d.message = fmt.Sprintf("%s card %s…", verb, cardLabel(d.confirm.card.Card))
+			return keyConfirmed
+		}
+		d.confirm = nil
+		d.message = "Cancelled."
+		return keyHandled
+	}
+
+	if d.editing {
+		switch k {
+		case "enter":
+			d.editing = false
+		case "esc":
+			d.editing, d.filter = false, ""
+		case "backspace":
+			if _, n := utf8.DecodeLastRuneInString(d.filter); n > 0 {
+				d.filter = d.filter[:len(d.filter)-n]
+			}
+		case "ctrl+c":
+			return keyQuit
+		default:
+			if utf8.RuneCountInString(k) == 1 {
+				d.filter += k
+			}
+		}
+		d.offset = 0
+		return keyHandled
+	}
+
+	d.message = ""
+	switch k {
+	case "q", "ctrl+c":
+		return keyQuit
+	case "tab":
+		d.focus = (d.focus + 1) % 2
+	case "up", "k":
+		d.move(-1)
+	case "down", "j":
+		d.move(1)
+	case "pgup":
+		d.move(-d.feedHeight)
+	case "pgdown":
+		d.move(d.feedHeight)
+	case "home", "g":
+		d.move(-len(d.events) - len(d.cards))
+	case "end", "G":
+		d.move(len(d.events) + len(d.cards))
+	case "/":
+		d.editing = true
+	case "esc":
+		d.filter = ""
+		d.scroll(0)
+	case "p":
+		d.pendingOnly = !d.pendingOnly
+		d.offset = 0
+	case "r":
+		return keyRefresh
+	case "f", "u":
+		if d.acting {
+			d.message = "Wait for the running card action to finish."
+			break
+		}
+		if d.selected >= len(d.cards) {
+			d.message = "No card selected."
+			break
+		}
+		card := d.cards[d.selected]
+		frozen := card.Status != nil && card.Status.IsFrozen
+		switch {
+		case k == "f" && frozen:
+			d.message = fmt.Sprintf("Card %s is already frozen.", cardLabel(card.Card))
+		case k == "u" && !frozen:
+			d.message = fmt.Sprintf("Card %s is not frozen.", cardLabel(card.Card))
+		default:
+			d.confirm = &cardAction{card: card, freeze: k == "f"}
+		}
+	}
+	return keyHandled
+}
+
+// move moves the cursor in the focused pane by n rows.
+func (d *dashboard) move(n int) {
+	if d.focus == paneFeed {
+		d.scroll(n)
+		return
+	}
+	d.selected = min(max(d.selected+n, 0), max(len(d.cards)-1, 0))
+}
+
+func (d *dashboard) scroll(n int) {
+	d.offset = min(max(d.offset+n, 0), max(len(d.visibleEvents())-d.feedHeight, 0))
+}
+
+// visibleEvents applies the pending-only toggle and the text filter, which
+// matches merchant, city, MCC, kind, status and amount.
+func (d *dashboard) visibleEvents() []gnosispay.CardEvent {
+	needle := strings.ToLower(d.filter)
+	var out []gnosispay.CardEvent
+	for _, e := range d.events {
+		if d.pendingOnly && !e.IsPending {
+			continue
+		}
+		if needle != "" {
+			hay := []string{e.Mcc, string(e.Kind), string(e.Status), eventStatus(e), formatMoney(e.BillingMoney())}
+			if e.Merchant != nil {
+				hay = append(hay, e.Merchant.Name, e.Merchant.City)
+			}
+			if !strings.Contains(strings.ToLower(strings.Join(hay, " ")), needle) {
+				continue
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// draw repaints the whole screen in place.
+func (d *dashboard) draw(out io.Writer, width, height int) {
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for _, line := range d.view(width, height) {
+		b.WriteString(line)
+		b.WriteString("\x1b[K\r\n")
+	}
+	b.WriteString("\x1b[J")
+	io.WriteString(out, b.String())
+}
+
+// view lays out the screen as at most height lines of at most width
+// columns.
+func (d *dashboard) view(width, height int) []string {
+	var lines []string
+	add := func(s string) { lines = append(lines, clip(s, width)) }
+	bold := func(s string) { lines = append(lines, "\x1b[1m"+clip(s, width)+"\x1b[0m") }
+
+	title := "Gnosis Pay · profile " + d.profile
+	if !d.updated.IsZero() {
+		title += " · updated " + d.updated.Local().Format("15:04:05")
+	}
+	bold(title)
+	if d.balances == nil {
+		add("Balance  -")
+	} else {
+		add(fmt.Sprintf("Balance  total %s   spendable %s   pending %s",
+			d.balance(d.balances.TotalMoney, d.balances.Total),
+			d.balance(d.balances.SpendableMoney, d.balances.Spendable),
+			d.balance(d.balances.PendingMoney, d.balances.Pending)))
+	}
+	add("")
+
+	bold(paneTitle("Cards", d.focus == paneCards))
+	cardRows := tabulate(nil, func(tw io.Writer) {
+		for i, c := range d.cards {
+			marker := " "
+			if i == d.selected {
+				marker = ">"
+			}
+			fmt.Fprintf(tw, "%s %s\t%s\t%s\tactivated %s\n", marker, cardLabel(c.Card), c.Id, cardState(c.Status), formatTime(c.ActivatedAt))
+		}
+	})
+	if len(cardRows) == 0 {
+		add("  no cards")
+	}
+	for i, row := range cardRows {
+		if i == d.selected && d.focus == paneCards {
+			lines = append(lines, "\x1b[7m"+pad(clip(row, width), width)+"\x1b[0m")
+		} else {
+			add(row)
+		}
+	}
+	add("")
+
+	events := d.visibleEvents()
+	feedTitle := fmt.Sprintf("%s  %d of %d", paneTitle("Transactions", d.focus == paneFeed), len(events), len(d.events))
+	if d.filter != "" || d.editing {
+		feedTitle += fmt.Sprintf(" · filter %q", d.filter)
+	}
+	if d.pendingOnly {
+		feedTitle += " · pending only"
+	}
+	bold(feedTitle)
+	feed := tabulate([]string{"CREATED", "STATUS", "KIND", "MERCHANT", "MCC", "AMOUNT", "ORIGINAL"}, func(tw io.Writer) {
+		for _, e := range events {
+			merchant := ""
+			if e.Merchant != nil {
+				merchant = e.Merchant.Name
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", formatTime(e.CreatedAt), eventStatus(e), e.Kind,
+				merchant, e.Mcc, formatMoney(e.BillingMoney()), formatMoney(e.TransactionMoney()))
+		}
+	})
+	add(feed[0])
+
+	// Two footer lines: status and key help.
+	d.feedHeight = max(height-len(lines)-2, 1)
+	d.scroll(0)
+	rows := feed[1:]
+	for i := d.offset; i < len(rows) && i < d.offset+d.feedHeight; i++ {
+		add(rows[i])
+	}
+	for len(lines) < height-2 {
+		lines = append(lines, "")
+	}
+
+	switch {
+	case d.confirm != nil:
+		verb := "Unfreeze"
+		if d.confirm.freeze {
+			verb = "Freeze"
+		}
+		bold(fmt.Sprintf("%s card %s (%s)? [y/N]", verb, cardLabel(d.confirm.card.Card), d.confirm.card.Id))
+	case d.editing:
+		add("Filter: " + d.filter + "█")
+	case d.err != nil:
+		add("Error: " + d.err.Error())
+	default:
+		add(d.message)
+	}
+	add("tab pane · ↑↓ move · / filter · p pending · f freeze · u unfreeze · r refresh · q quit")
+
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return lines
+}
+
+// balance formats a balance in the Safe's fiat currency, falling back to
+// the raw base-unit amount when the currency is unknown.
+func (d *dashboard) balance(fn func(gnosispay.Currency) (gnosispay.Money, error), raw string) string {
+	if d.currency.Code == "" {
+		return raw
+	}
+	token := d.currency
+	token.Decimals = tokenDecimals
+	m, err := fn(token)
+	if err != nil {
+		return raw
+	}
+	// Show whole cents; the token's extra precision is noise on a dashboard.
+	dec := m.Decimal()
+	if i := strings.IndexByte(dec, '.'); i >= 0 {
+		dec = dec[:min(len(dec), i+1+int(d.currency.Decimals))]
+	}
+	rounded, err := gnosispay.ParseMoney(strings.TrimSuffix(dec, "."), d.currency)
+	if err != nil {
+		return m.String()
+	}
+	return rounded.String()
+}
+
+func paneTitle(name string, focused bool) string {
+	if focused {
+		return "▸ " + name
+	}
+	return "  " + name
+}
+
+func cardLabel(c gnosispay.Card) string {
+	return "****" + c.LastFourDigits
+}
+
+// cardState names the card's lifecycle state, or "unknown" without a status.
+func cardState(s *gnosispay.CardStatus) string {
+	if s == nil {
+		return "unknown"
+	}
+	return s.State().String()
+}
+
+// tabulate aligns rows written by fn, returning one string per line with
+// the header, if any, first.
+func tabulate(header []string, fn func(tw io.Writer)) []string {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	if header != nil {
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+	}
+	fn(tw)
+	tw.Flush()
+	s := strings.TrimSuffix(b.String(), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// clip truncates s to width runes.
+func clip(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:max(width, 0)])
+}
+
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// escapeKeys maps terminal escape sequences to key names.
+var escapeKeys = map[string]string{
+	"\x1b[A": "up", "\x1b[B": "down", "\x1b[C": "right", "\x1b[D": "left",
+	"\x1bOA": "up", "\x1bOB": "down", "\x1bOC": "right", "\x1bOD": "left",
+	"\x1b[H": "home", "\x1b[F": "end", "\x1bOH": "home", "\x1bOF": "end",
+	"\x1b[1~": "home", "\x1b[4~": "end", "\x1b[5~": "pgup", "\x1b[6~": "pgdown",
+}
+
+// readKeys decodes key presses from a raw-mode terminal until r fails or
+// done is closed. keys is closed when input ends.
+func readKeys(r io.Reader, keys chan<- string, done <-chan struct{}) {
+	defer close(keys)
+	buf := make([]byte, 256)
+	for {
+		n, err := r.Read(buf)
+		for _, k := range parseKeys(buf[:n]) {
+			select {
+			case keys <- k:
+			case <-done:
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func parseKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		if b[0] == 0x1b {
+			k, n := parseEscape(b)
+			keys = append(keys, k)
+			b = b[n:]
+			continue
+		}
+		switch b[0] {
+		case 0x03:
+			keys = append(keys, "ctrl+c")
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case '\t':
+			keys = append(keys, "tab")
+		case 0x7f, 0x08:
+			keys = append(keys, "backspace")
+		default:
+			r, n := utf8.DecodeRune(b)
+			if r >= ' ' {
+				keys = append(keys, string(r))
+			}
+			b = b[n:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// parseEscape decodes the escape sequence at the start of b. Unknown
+// sequences are consumed and reported as "unknown"; a lone ESC is "esc".
+func parseEscape(b []byte) (string, int) {
+	for _, n := range []int{4, 3} {
+		if len(b) >= n {
+			if k, ok := escapeKeys[string(b[:n])]; ok {
+				return k, n
+			}
+		}
+	}
+	if len(b) > 1 && (b[1] == '[' || b[1] == 'O') {
+		for i := 2; i < len(b); i++ {
+			if b[i] >= 0x40 && b[i] <= 0x7e {
+				return "unknown", i + 1
+			}
+		}
+		return "unknown", len(b)
+	}
+	return "esc", 1
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"q", []string{"q"}},
+		{"\x1b[A\x1b[B", []string{"up", "down"}},
+		{"\x1b[5~\x1b[6~", []string{"pgup", "pgdown"}},
+		{"\x1bOA", []string{"up"}},
+		{"\x1b", []string{"esc"}},
+		{"\x1b[1;5C", []string{"unknown"}},
+		{"/ré\x7f\r", []string{"/", "r", "é", "backspace", "enter"}},
+		{"\t\x03", []string{"tab", "ctrl+c"}},
+	}
+	for _, tt := range tests {
+		if got := parseKeys([]byte(tt.in)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseKeys(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCardState(t *testing.T) {
+	tests := []struct {
+		status *gnosispay.CardStatus
+		want   string
+	}{
+		{nil, "unknown"},
+		{&gnosispay.CardStatus{}, "inactive"},
+		{&gnosispay.CardStatus{ActivatedAt: "2025-01-01"}, "active"},
+		{&gnosispay.CardStatus{ActivatedAt: "2025-01-01", IsFrozen: true}, "frozen"},
+		{&gnosispay.CardStatus{IsFrozen: true, IsStolen: true}, "stolen"},
+		{&gnosispay.CardStatus{IsLost: true, IsVoid: true}, "void"},
+	}
+	for _, tt := range tests {
+		if got := cardState(tt.status); got != tt.want {
+			t.Errorf("cardState(%+v) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func testDashboard() *dashboard {
+	d := newDashboard(nil, gnosispay.ListTransactionsOptions{}, "default")
+	eur := &gnosispay.Currency{Symbol: "€", Code: "EUR", Decimals: 2}
+	d.apply(snapshot{
+		currency: gnosispay.EUR.Currency(),
+		balances: &gnosispay.AccountBalances{Total: "12345678900000000000", Spendable: "12000000000000000000", Pending: "345678900000000000"},
+		cards: []dashboardCard{
+			{Card: gnosispay.Card{Id: "card-1", LastFourDigits: "4242"}, Status: &gnosispay.CardStatus{ActivatedAt: "2025-01-01"}},
+			{Card: gnosispay.Card{Id: "card-2", LastFourDigits: "1111"}, Status: &gnosispay.CardStatus{ActivatedAt: "2025-01-01", IsFrozen: true}},
+		},
+		events: []gnosispay.CardEvent{
+			{Kind: "Payment", IsPending: true, Mcc: "5411", Merchant: &gnosispay.Merchant{Name: "REWE"}, BillingAmount: "1250", BillingCurrency: eur},
+			{Kind: "Payment", Mcc: "5812", Merchant: &gnosispay.Merchant{Name: "Cafe Nero"}, BillingAmount: "480", BillingCurrency: eur},
+			{Kind: "Refund", Mcc: "5411", Merchant: &gnosispay.Merchant{Name: "REWE"}, BillingAmount: "300", BillingCurrency: eur},
+		},
+		at: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	return d
+}
+
+func TestDashboardView(t *testing.T) {
+	d := testDashboard()
+	lines := d.view(100, 20)
+	if len(lines) != 20 {
+		t.Fatalf("view returned %d lines, want 20", len(lines))
+	}
+	screen := strings.Join(lines, "\n")
+	for _, want := range []string{"total 12.34 EUR", "spendable 12.00 EUR", "****4242", "frozen", "3 of 3", "Cafe Nero", "q quit"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("screen does not contain %q:\n%s", want, screen)
+		}
+	}
+	for _, line := range d.view(30, 20) {
+		if n := len([]rune(strings.NewReplacer("\x1b[1m", "", "\x1b[0m", "", "\x1b[7m", "").Replace(line))); n > 30 {
+			t.Errorf("line %q is %d columns wide, want at most 30", line, n)
+		}
+	}
+}
+
+func TestDashboardKeys(t *testing.T) {
+	d := testDashboard()
+	press := func(keys ...string) int {
+		var r int
+		for _, k := range keys {
+			r = d.handleKey(k)
+		}
+		return r
+	}
+
+	press("/", "r", "e", "w", "e", "enter")
+	if got := len(d.visibleEvents()); got != 2 {
+		t.Errorf("filter %q matched %d events, want 2", d.filter, got)
+	}
+	press("p")
+	if got := len(d.visibleEvents()); got != 1 {
+		t.Errorf("pending-only filter matched %d events, want 1", got)
+	}
+	press("esc", "p")
+	if d.filter != "" || d.pendingOnly {
+		t.Errorf("filters not cleared: %q, %v", d.filter, d.pendingOnly)
+	}
+
+	// Freezing an already frozen card is refused without a prompt.
+	press("down", "f")
+	if d.confirm != nil || !strings.Contains(d.message, "already frozen") {
+		t.Errorf("freeze of frozen card: confirm = %v, message = %q", d.confirm, d.message)
+	}
+	// Anything but "y" cancels the prompt.
+	press("u", "n")
+	if d.confirm != nil || d.message != "Cancelled." {
+		t.Errorf("after cancelling: confirm = %v, message = %q", d.confirm, d.message)
+	}
+	if r := press("u", "y"); r != keyConfirmed || d.confirm == nil || d.confirm.card.Id != "card-2" || d.confirm.freeze {
+		t.Errorf("unfreeze confirm = %d, %+v", r, d.confirm)
+	}
+
+	act := *d.confirm
+	d.confirm, d.acting = nil, true
+	if press("u"); d.confirm != nil || !strings.HasPrefix(d.message, "Wait") {
+		t.Errorf("second action while one runs: confirm = %v, message = %q", d.confirm, d.message)
+	}
+	d.acting = false
+	d.finish(actionResult{act: act, err: &gnosispay.TransitionError{From: gnosispay.CardStateLost, To: gnosispay.CardStateActive}})
+	if !strings.HasPrefix(d.message, "Could not unfreeze card ****1111") {
+		t.Errorf("failed action message = %q", d.message)
+	}
+	d.finish(actionResult{act: act, status: &gnosispay.CardStatus{ActivatedAt: "2025-01-01"}})
+	if d.message != "Card ****1111 unfrozen." || cardState(d.cards[1].Status) != "active" {
+		t.Errorf("after unfreezing: message = %q, card = %s", d.message, cardState(d.cards[1].Status))
+	}
+
+	press("tab", "end")
+	if d.focus != paneFeed || d.selected != 1 {
+		t.Errorf("tab should move focus to the feed without moving the card cursor")
+	}
+	if r := press("q"); r != keyQuit {
+		t.Errorf("q = %d, want quit", r)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDashboardRun(t *testing.T) {
+	var freezes atomic.Int32
+	srv := newTestServer(t, map[string]func(http.ResponseWriter, *http.Request){
+		"GET /api/v1/safe-config":      jsonHandler(gnosispay.SafeConfig{FiatSymbol: "EUR"}),
+		"GET /api/v1/account-balances": jsonHandler(gnosispay.AccountBalances{Total: "1000000000000000000", Spendable: "0", Pending: "0"}),
+		"GET /api/v1/cards":            jsonHandler([]gnosispay.Card{{Id: "card-1", LastFourDigits: "4242"}}),
+		"GET /api/v1/cards/card-1/status": func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(gnosispay.CardStatus{ActivatedAt: "2025-01-01", IsFrozen: freezes.Load() > 0})
+		},
+		"POST /api/v1/cards/card-1/freeze": func(w http.ResponseWriter, r *http.Request) {
+			freezes.Add(1)
+			json.NewEncoder(w).Encode(map[string]bool{"ok": true})
+		},
+		"GET /transactions": jsonHandler([]gnosispay.CardEvent{}),
+	})
+	c, err := gnosispay.New(nil, gnosispay.SetBaseURL(srv.URL), gnosispay.SetAuthToken(testToken(t, time.Now().Add(time.Hour))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in, keys := io.Pipe()
+	var out syncBuffer
+	errc := make(chan error, 1)
+	go func() {
+		size := func() (int, int) { return 100, 20 }
+		errc <- newDashboard(c, gnosispay.ListTransactionsOptions{}, "default").run(context.Background(), in, &out, size, time.Minute)
+	}()
+
+	waitFor(t, "cards to load", func() bool { return strings.Contains(out.String(), "total 1.00 EUR") })
+	io.WriteString(keys, "f")
+	waitFor(t, "confirmation prompt", func() bool { return strings.Contains(out.String(), "Freeze card ****4242 (card-1)? [y/N]") })
+	io.WriteString(keys, "y")
+	waitFor(t, "freeze", func() bool { return strings.Contains(out.String(), "Card ****4242 frozen.") })
+	io.WriteString(keys, "q")
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if freezes.Load() != 1 {
+		t.Errorf("freeze called %d times, want 1", freezes.Load())
+	}
+	if !strings.HasSuffix(out.String(), "\x1b[?25h\x1b[?1049l") {
+		t.Error("terminal state not restored on exit")
+	}
+}
+
+func TestTUIRequiresTerminal(t *testing.T) {
+	t.Setenv(envConfigDir, t.TempDir())
+	code, _, stderr := runCLI(t, "-token", testToken(t, time.Now().Add(time.Hour)), "tui")
+	if code != exitUsage || !strings.Contains(stderr, "not a terminal") {
+		t.Errorf("tui without a terminal: exit code = %d, stderr = %q", code, stderr)
+	}
+}
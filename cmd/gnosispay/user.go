@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+func userCommand() *command {
+	return &command{
+		name: "user",
+		subs: []*command{
+			{name: "show", summary: "show the authenticated user", run: runUserShow},
+		},
+	}
+}
+
+func runUserShow(ctx context.Context, a *app, args []string) error {
+	if err := parse(a.flags("user show", ""), args, 0); err != nil {
+		return err
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	user, err := c.User.Get(ctx)
+	if err != nil {
+		return err
+	}
+	return a.render(user, func() table {
+		kyc := "-"
+		if user.KycStatus != nil {
+			kyc = string(*user.KycStatus)
+		}
+		var wallets, safes []string
+		for _, w := range user.SignInWallets {
+			wallets = append(wallets, w.Address)
+		}
+		for _, s := range user.SafeWallets {
+			safes = append(safes, s.Address)
+		}
+		iban := "-"
+		if user.BankingDetails != nil && user.BankingDetails.MoneriumIban != "" {
+			iban = user.BankingDetails.MoneriumIban
+		}
+		return fields(
+			"email", user.Email,
+			"name", strings.TrimSpace(user.FirstName+" "+user.LastName),
+			"kyc status", kyc,
+			"sign-in wallets", strings.Join(wallets, ", "),
+			"safes", strings.Join(safes, ", "),
+			"cards", itoa(len(user.Cards)),
+			"iban", iban,
+		)
+	})
+}
+
+func kycCommand() *command {
+	return &command{
+		name:    "kyc",
+		summary: "show KYC status and the verification link",
+		run:     runKYC,
+	}
+}
+
+func runKYC(ctx context.Context, a *app, args []string) error {
+	if err := parse(a.flags("kyc", ""), args, 0); err != nil {
+		return err
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	user, err := c.User.Get(ctx)
+	if err != nil {
+		return err
+	}
+	result := struct {
+		Status      *gnosispay.KycStatus      `json:"status"`
+		Integration *gnosispay.KycIntegration `json:"integration,omitempty"`
+	}{Status: user.KycStatus}
+
+	if user.KycStatus == nil || *user.KycStatus != gnosispay.APPROVED_KycStatus {
+		if result.Integration, err = c.KYC.GetIntegration(ctx); err != nil {
+			return err
+		}
+	}
+	return a.render(result, func() table {
+		status, url := "-", "-"
+		if result.Status != nil {
+			status = string(*result.Status)
+		}
+		if result.Integration != nil {
+			url = result.Integration.Url
+		}
+		return fields("status", status, "verification url", url)
+	})
+}
+
+func ibanCommand() *command {
+	return &command{
+		name: "iban",
+		subs: []*command{
+			{name: "details", summary: "show IBAN details", run: runIBANDetails},
+			{name: "orders", summary: "list IBAN transfer orders", run: runIBANOrders},
+		},
+	}
+}
+
+func runIBANDetails(ctx context.Context, a *app, args []string) error {
+	if err := parse(a.flags("iban details", ""), args, 0); err != nil {
+		return err
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	details, err := c.IBAN.GetDetails(ctx)
+	if err != nil {
+		return err
+	}
+	return a.render(details, func() table {
+		return fields("iban", details.Iban, "bic", details.Bic, "status", details.IbanStatus)
+	})
+}
+
+func runIBANOrders(ctx context.Context, a *app, args []string) error {
+	if err := parse(a.flags("iban orders", ""), args, 0); err != nil {
+		return err
+	}
+	c, err := a.authClient()
+	if err != nil {
+		return err
+	}
+	orders, err := c.IBAN.ListOrders(ctx)
+	if err != nil {
+		return err
+	}
+	return a.render(orders, func() table {
+		t := table{header: []string{"PLACED", "KIND", "STATE", "AMOUNT", "COUNTERPART", "MEMO"}}
+		for _, o := range orders {
+			placed, counterpart := "-", ""
+			if o.Meta != nil {
+				placed = o.Meta.PlacedAt
+			}
+			if o.Counterpart != nil {
+				counterpart = o.Counterpart.Details.Name
+			}
+			t.rows = append(t.rows, []string{placed, o.Kind, o.State, formatMoney(o.Money()), counterpart, o.Memo})
+		}
+		return t
+	})
+}
@@ -0,0 +1,57 @@
+package gnosispay
+
+import "fmt"
+
+// CurrencyCode is an ISO 4217 alphabetic currency code, such as "EUR".
+type CurrencyCode string
+
+// Currency codes commonly seen on Gnosis Pay cards and IBAN accounts.
+const (
+	EUR CurrencyCode = "EUR"
+	GBP CurrencyCode = "GBP"
+	USD CurrencyCode = "USD"
+	CHF CurrencyCode = "CHF"
+	BRL CurrencyCode = "BRL"
+)
+
+// Validate reports whether c is a well-formed ISO 4217 alphabetic code,
+// that is exactly three upper-case ASCII letters.
+func (c CurrencyCode) Validate() error {
+	if len(c) != 3 {
+		return fmt.Errorf("invalid currency code %q: must be 3 letters", string(c))
+	}
+	for i := 0; i < len(c); i++ {
+		if c[i] < 'A' || c[i] > 'Z' {
+			return fmt.Errorf("invalid currency code %q: must be upper-case letters", string(c))
+		}
+	}
+	return nil
+}
+
+// String returns the currency code as a string.
+func (c CurrencyCode) String() string {
+	return string(c)
+}
+
+// knownCurrencies holds the display details for currencies the SDK formats
+// without help from the API. Codes not listed default to two decimals.
+var knownCurrencies = map[CurrencyCode]Currency{
+	EUR:   {Symbol: "€", Code: "EUR", Decimals: 2, Name: "Euro"},
+	GBP:   {Symbol: "£", Code: "GBP", Decimals: 2, Name: "Pound Sterling"},
+	USD:   {Symbol: "$", Code: "USD", Decimals: 2, Name: "US Dollar"},
+	CHF:   {Symbol: "CHF", Code: "CHF", Decimals: 2, Name: "Swiss Franc"},
+	BRL:   {Symbol: "R$", Code: "BRL", Decimals: 2, Name: "Brazilian Real"},
+	"JPY": {Symbol: "¥", Code: "JPY", Decimals: 0, Name: "Yen"},
+	"KRW": {Symbol: "₩", Code: "KRW", Decimals: 0, Name: "Won"},
+	"BHD": {Symbol: "BD", Code: "BHD", Decimals: 3, Name: "Bahraini Dinar"},
+	"KWD": {Symbol: "KD", Code: "KWD", Decimals: 3, Name: "Kuwaiti Dinar"},
+}
+
+// Currency returns the Currency details for c. Unknown codes get two
+// decimals and the code itself as symbol.
+func (c CurrencyCode) Currency() Currency {
+	if cur, ok := knownCurrencies[c]; ok {
+		return cur
+	}
+	return Currency{Symbol: string(c), Code: string(c), Decimals: 2}
+}
@@ -55,13 +55,48 @@ type CardStatus struct {
 	IsVoid      bool    `json:"isVoid"`
 }
 
-type GetTransactionsFilters struct {
-	CardTokens          *string `json:"cardTokens"`
-	Before              *string `json:"before"`
-	After               *string `json:"after"`
-	BillingCurrency     *string `json:"billingCurrency"`
-	TransactionCurrency *string `json:"transactionCurrency"`
-	MCC                 *string `json:"mcc"`
+// CardControls enable or disable kinds of card transactions.
+type CardControls struct {
+	ECommerce           bool `json:"ecommerce"`           // Online and card-not-present payments.
+	Contactless         bool `json:"contactless"`         // Tap-to-pay at terminals.
+	ForeignTransactions bool `json:"foreignTransactions"` // Payments outside the card's home country.
+}
+
+// ShippingAddress is where a physical card is delivered.
+type ShippingAddress struct {
+	Address1   string `json:"address1"`
+	Address2   string `json:"address2,omitempty"`
+	City       string `json:"city"`
+	PostalCode string `json:"postalCode"`
+	Country    string `json:"country"` // ISO 3166-1 alpha-2 code.
+}
+
+// CardOrderStatus is the processing state of a card order.
+type CardOrderStatus string
+
+// Card order statuses, in the order an order normally moves through them.
+const (
+	CardOrderPendingTransaction   CardOrderStatus = "PENDINGTRANSACTION"
+	CardOrderTransactionComplete  CardOrderStatus = "TRANSACTIONCOMPLETE"
+	CardOrderConfirmationRequired CardOrderStatus = "CONFIRMATIONREQUIRED"
+	CardOrderReady                CardOrderStatus = "READY"
+	CardOrderCardCreated          CardOrderStatus = "CARDCREATED"
+	CardOrderFailedTransaction    CardOrderStatus = "FAILEDTRANSACTION"
+	CardOrderCancelled            CardOrderStatus = "CANCELLED"
+)
+
+type CardOrder struct {
+	Id               string           `json:"id"`
+	Status           CardOrderStatus  `json:"status"`
+	EmbossedName     string           `json:"embossedName,omitempty"`    // Name printed on the card.
+	Virtual          bool             `json:"virtual,omitempty"`         // Whether the order is for a virtual card.
+	ShippingAddress  *ShippingAddress `json:"shippingAddress,omitempty"` // Delivery address of a physical card.
+	CouponCode       string           `json:"couponCode,omitempty"`
+	TotalAmountEUR   string           `json:"totalAmountEUR,omitempty"`   // Price of the order in EUR.
+	TotalDiscountEUR string           `json:"totalDiscountEUR,omitempty"` // Discount applied to the order in EUR.
+	TransactionHash  string           `json:"transactionHash,omitempty"`  // Payment transaction of the order.
+	CardToken        string           `json:"cardToken,omitempty"`        // ID of the card created from the order.
+	CreatedAt        time.Time        `json:"createdAt,omitempty"`
 }
 
 type Country struct {
@@ -93,19 +128,20 @@ type Transaction struct {
 }
 
 type CardEvent struct {
-	Kind                string        `json:"kind,omitempty"`
-	Status              string        `json:"status,omitempty"`
-	CreatedAt           time.Time     `json:"createdAt,omitempty"`
-	ClearedAt           time.Time     `json:"clearedAt,omitempty"`
-	Country             *Country      `json:"country,omitempty"`
-	IsPending           bool          `json:"isPending,omitempty"`
-	Mcc                 string        `json:"mcc,omitempty"`
-	Merchant            *Merchant     `json:"merchant,omitempty"`
-	BillingAmount       string        `json:"billingAmount,omitempty"`
-	BillingCurrency     *Currency     `json:"billingCurrency,omitempty"`
-	TransactionAmount   string        `json:"transactionAmount,omitempty"`
-	TransactionCurrency *Currency     `json:"transactionCurrency,omitempty"`
-	Transactions        []Transaction `json:"transactions,omitempty"`
+	Kind                CardEventKind   `json:"kind,omitempty"`
+	Status              CardEventStatus `json:"status,omitempty"`   // Outcome of a payment; empty for refunds and reversals.
+	ThreadID            string          `json:"threadId,omitempty"` // Shared by a payment and its refunds and reversals.
+	CreatedAt           time.Time       `json:"createdAt,omitempty"`
+	ClearedAt           *time.Time      `json:"clearedAt,omitempty"` // Nil while the event is pending.
+	Country             *Country        `json:"country,omitempty"`
+	IsPending           bool            `json:"isPending,omitempty"`
+	Mcc                 string          `json:"mcc,omitempty"`
+	Merchant            *Merchant       `json:"merchant,omitempty"`
+	BillingAmount       string          `json:"billingAmount,omitempty"`
+	BillingCurrency     *Currency       `json:"billingCurrency,omitempty"`
+	TransactionAmount   string          `json:"transactionAmount,omitempty"`
+	TransactionCurrency *Currency       `json:"transactionCurrency,omitempty"`
+	Transactions        []Transaction   `json:"transactions,omitempty"`
 }
 
 type EoaAccount struct {
@@ -0,0 +1,323 @@
+// Package expense builds expense reports from card events: one employee's
+// spending over a period, grouped by merchant category, converted into a
+// reporting currency and annotated with the receipts on file. Reports
+// render to HTML and PDF.
+//
+// Events are counted as gnosispay.CardEvent.SignedAmount counts them:
+// payments are expenses, refunds and reversal events reduce them, and
+// declined or reversed payments are left out. A reversal event is also left
+// out when the payment it undoes already shows the reversal, so a reversed
+// expense never stays on the report.
+package expense
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/analytics"
+	"github.com/guarilha/go-gnosispay/fx"
+	"github.com/guarilha/go-gnosispay/mcc"
+	"github.com/guarilha/go-gnosispay/receipts"
+)
+
+// Employee identifies whose card spending a report covers.
+type Employee struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// EmployeeOf returns the employee described by a user profile.
+func EmployeeOf(u *gnosispay.User) Employee {
+	if u == nil {
+		return Employee{}
+	}
+	return Employee{
+		Name:  strings.TrimSpace(u.FirstName + " " + u.LastName),
+		Email: u.Email,
+	}
+}
+
+// ReceiptStatus tells whether an expense is backed by a receipt.
+type ReceiptStatus string
+
+const (
+	ReceiptAttached    ReceiptStatus = "attached"
+	ReceiptMissing     ReceiptStatus = "missing"
+	ReceiptNotRequired ReceiptStatus = "not required" // Refunds and payments below the threshold.
+	ReceiptUnknown     ReceiptStatus = "unknown"      // No receipt store was given.
+)
+
+// Line is one expense.
+type Line struct {
+	ID          string                  `json:"id"` // gnosispay.CardEvent.ID.
+	Date        time.Time               `json:"date"`
+	Kind        gnosispay.CardEventKind `json:"kind"`
+	Pending     bool                    `json:"pending"`
+	Merchant    string                  `json:"merchant"`
+	City        string                  `json:"city,omitempty"`
+	Country     string                  `json:"country,omitempty"`
+	MCC         string                  `json:"mcc,omitempty"`
+	Description string                  `json:"description,omitempty"` // Of the MCC.
+
+	// Amounts are positive for payments and negative for refunds.
+	Original gnosispay.Money `json:"original"` // As charged by the merchant.
+	Billed   gnosispay.Money `json:"billed"`   // As booked to the card.
+	Amount   gnosispay.Money `json:"amount"`   // In the reporting currency.
+
+	// Converted is set when Amount was converted from Billed at the
+	// reference rate.
+	Converted bool `json:"converted"`
+
+	Receipt     ReceiptStatus `json:"receipt"`
+	Attachments []string      `json:"attachments,omitempty"` // File names.
+	Note        string        `json:"note,omitempty"`
+}
+
+// Foreign reports whether the merchant charged in another currency than the
+// card was billed in.
+func (l Line) Foreign() bool {
+	return l.Original.Currency.Code != "" && l.Original.Currency.Code != l.Billed.Currency.Code
+}
+
+// Category holds the expenses of one merchant category.
+type Category struct {
+	Name  mcc.Category    `json:"name"`
+	Lines []Line          `json:"lines"` // Oldest first.
+	Total gnosispay.Money `json:"total"`
+}
+
+// ReceiptCounts counts expenses by receipt status.
+type ReceiptCounts struct {
+	Attached    int `json:"attached"`
+	Missing     int `json:"missing"`
+	NotRequired int `json:"notRequired"`
+	Unknown     int `json:"unknown"`
+}
+
+// Report is an expense report.
+type Report struct {
+	Employee    Employee           `json:"employee"`
+	From        time.Time          `json:"from"`
+	To          time.Time          `json:"to"` // Exclusive.
+	Currency    gnosispay.Currency `json:"currency"`
+	Categories  []Category         `json:"categories"` // Largest total first.
+	Total       gnosispay.Money    `json:"total"`
+	Receipts    ReceiptCounts      `json:"receipts"`
+	GeneratedAt time.Time          `json:"generatedAt"`
+}
+
+// Lines returns the number of expenses in the report.
+func (r *Report) Lines() int {
+	n := 0
+	for _, c := range r.Categories {
+		n += len(c.Lines)
+	}
+	return n
+}
+
+// Options configures Build.
+type Options struct {
+	// From and To bound the creation time of the included events as
+	// [From, To). Zero bounds are open.
+	From, To time.Time
+
+	// Currency is the reporting currency. Defaults to the billing currency
+	// of the first expense.
+	Currency gnosispay.Currency
+
+	// Rates converts expenses billed in another currency. Build fails when
+	// such an expense has no rate.
+	Rates *fx.Rates
+
+	// Receipts looks up the receipts and notes of each expense. Without it
+	// every receipt status is ReceiptUnknown.
+	Receipts receipts.Store
+
+	// MinReceipt is the smallest payment that needs a receipt; the zero
+	// value means every payment does.
+	MinReceipt gnosispay.Money
+
+	// Categories maps merchant category codes. Defaults to mcc.Default.
+	Categories *mcc.Table
+
+	// Now returns the generation time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Build builds the expense report of employee from events.
+func Build(ctx context.Context, employee Employee, events []gnosispay.CardEvent, opts *Options) (*Report, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	table := opts.Categories
+	if table == nil {
+		table = mcc.Default
+	}
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+	policy := &receipts.MissingOptions{Min: opts.MinReceipt, Rates: opts.Rates}
+
+	r := &Report{Employee: employee, From: opts.From, To: opts.To, Currency: opts.Currency, GeneratedAt: now()}
+	byCategory := map[mcc.Category]*Category{}
+	for _, e := range events {
+		if (!opts.From.IsZero() && e.CreatedAt.Before(opts.From)) || (!opts.To.IsZero() && !e.CreatedAt.Before(opts.To)) {
+			continue
+		}
+		if !e.Counts() || e.DoubleCounted(events) {
+			continue
+		}
+		signed, err := e.SignedAmount()
+		if err != nil {
+			return nil, fmt.Errorf("expense: event at %s: %w", e.CreatedAt.Format(time.RFC3339), err)
+		}
+		billed := signed.Neg()
+		if r.Currency.Code == "" {
+			r.Currency = billed.Currency
+		}
+		l, err := line(e, billed, r.Currency, opts.Rates, table)
+		if err != nil {
+			return nil, err
+		}
+		if err := receiptStatus(ctx, &l, e, opts.Receipts, policy); err != nil {
+			return nil, err
+		}
+		switch l.Receipt {
+		case ReceiptAttached:
+			r.Receipts.Attached++
+		case ReceiptMissing:
+			r.Receipts.Missing++
+		case ReceiptNotRequired:
+			r.Receipts.NotRequired++
+		default:
+			r.Receipts.Unknown++
+		}
+
+		name := table.Category(e.Mcc)
+		c := byCategory[name]
+		if c == nil {
+			c = &Category{Name: name, Total: gnosispay.NewMoney(0, r.Currency)}
+			byCategory[name] = c
+		}
+		c.Lines = append(c.Lines, l)
+		if c.Total, err = c.Total.Add(l.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	r.Total = gnosispay.NewMoney(0, r.Currency)
+	for _, c := range byCategory {
+		sort.SliceStable(c.Lines, func(i, j int) bool { return c.Lines[i].Date.Before(c.Lines[j].Date) })
+		r.Categories = append(r.Categories, *c)
+		r.Total, _ = r.Total.Add(c.Total)
+	}
+	sort.Slice(r.Categories, func(i, j int) bool {
+		a, b := r.Categories[i], r.Categories[j]
+		if c, _ := a.Total.Cmp(b.Total); c != 0 {
+			return c > 0
+		}
+		return a.Name < b.Name
+	})
+	return r, nil
+}
+
+func line(e gnosispay.CardEvent, billed gnosispay.Money, to gnosispay.Currency, rates *fx.Rates, table *mcc.Table) (Line, error) {
+	l := Line{
+		ID:          e.ID(),
+		Date:        e.CreatedAt,
+		Kind:        e.Kind,
+		Pending:     e.IsPending,
+		MCC:         e.Mcc,
+		Description: table.Describe(e.Mcc),
+		Billed:      billed,
+		Amount:      billed,
+		Original:    billed,
+	}
+	if e.Merchant != nil {
+		l.Merchant = strings.Join(strings.Fields(e.Merchant.Name), " ")
+		l.City = strings.TrimSpace(e.Merchant.City)
+	}
+	if country := analytics.ByCountry(e); country != analytics.Unknown {
+		l.Country = country
+	}
+	if original, err := e.TransactionMoney(); err == nil {
+		l.Original = original.Abs()
+		if billed.Sign() < 0 {
+			l.Original = l.Original.Neg()
+		}
+	}
+
+	if billed.Currency.Code != to.Code {
+		if rates == nil {
+			return Line{}, fmt.Errorf("expense: event at %s is billed in %s; rates are needed to convert it to %s",
+				e.CreatedAt.Format(time.RFC3339), billed.Currency.Code, to.Code)
+		}
+		amount, err := rates.Convert(billed, to, e.CreatedAt)
+		if err != nil {
+			return Line{}, fmt.Errorf("expense: converting event at %s: %w", e.CreatedAt.Format(time.RFC3339), err)
+		}
+		l.Amount, l.Converted = amount, true
+	}
+	return l, nil
+}
+
+func receiptStatus(ctx context.Context, l *Line, e gnosispay.CardEvent, store receipts.Store, policy *receipts.MissingOptions) error {
+	if store == nil {
+		l.Receipt = ReceiptUnknown
+		if !policy.Requires(e) {
+			l.Receipt = ReceiptNotRequired
+		}
+		return nil
+	}
+	atts, err := store.List(ctx, l.ID)
+	if err != nil {
+		return err
+	}
+	for _, a := range atts {
+		l.Attachments = append(l.Attachments, a.Name)
+	}
+	if l.Note, err = store.Note(ctx, l.ID); err != nil {
+		return err
+	}
+	switch {
+	case len(atts) > 0:
+		l.Receipt = ReceiptAttached
+	case policy.Requires(e):
+		l.Receipt = ReceiptMissing
+	default:
+		l.Receipt = ReceiptNotRequired
+	}
+	return nil
+}
+
+// Generate fetches the user's profile and the card events of the period
+// and builds the report.
+func Generate(ctx context.Context, c *gnosispay.Client, opts *Options) (*Report, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	user, err := c.User.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events, err := c.Cards.ListTransactions(ctx, &gnosispay.ListTransactionsOptions{After: opts.From, Before: opts.To})
+	if err != nil {
+		return nil, err
+	}
+	return Build(ctx, EmployeeOf(user), events, opts)
+}
+
+// Month returns the bounds of a calendar month in loc, for Options.From
+// and Options.To. A nil loc means UTC.
+func Month(year int, month time.Month, loc *time.Location) (from, to time.Time) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	from = time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	return from, from.AddDate(0, 1, 0)
+}
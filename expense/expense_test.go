@@ -0,0 +1,282 @@
+package expense
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/fx"
+	"github.com/guarilha/go-gnosispay/internal/eventtest"
+	"github.com/guarilha/go-gnosispay/mcc"
+	"github.com/guarilha/go-gnosispay/receipts"
+)
+
+var generatedAt = time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+
+func day(d int) time.Time {
+	return time.Date(2025, 1, d, 12, 0, 0, 0, time.UTC)
+}
+
+func testEvents() []gnosispay.CardEvent {
+	hotel := eventtest.Payment(day(6), "42000", eventtest.EUR, eventtest.MCC("7011"), eventtest.Merchant("Hotel Adlon"))
+	dinner := eventtest.Payment(day(7), "8950", eventtest.EUR, eventtest.MCC("5812"), eventtest.Merchant("Borchardt"))
+	taxi := eventtest.Payment(day(7), "1800", eventtest.EUR, eventtest.MCC("4121"), eventtest.Merchant("Taxi Berlin"))
+	coffee := eventtest.Payment(day(8), "350", eventtest.EUR, eventtest.MCC("5814"), eventtest.Merchant("Espresso Bar"))
+	london := eventtest.Payment(day(9), "4300", eventtest.GBP, eventtest.MCC("5812"), eventtest.Merchant("Dishoom"), eventtest.City("London"), eventtest.Country("GB"))
+	nyc := eventtest.Payment(day(10), "4650", eventtest.EUR, eventtest.MCC("5812"), eventtest.Merchant("Katz's"), eventtest.Transaction("4800", eventtest.USD))
+	refund := eventtest.Refund(day(11), "4200", eventtest.EUR, eventtest.MCC("7011"), eventtest.Merchant("Hotel Adlon"))
+	return []gnosispay.CardEvent{
+		hotel, dinner, taxi, coffee, london, nyc, refund,
+		eventtest.Payment(day(12), "9999", eventtest.EUR, eventtest.Status(gnosispay.CardEventInsufficientFunds), eventtest.MCC("5812"), eventtest.Merchant("Declined")),
+		eventtest.Payment(day(12), "9999", eventtest.EUR, eventtest.Status(gnosispay.CardEventReversed), eventtest.MCC("5812"), eventtest.Merchant("Reversed")),
+		eventtest.Reversal(day(13), "9999", eventtest.EUR, eventtest.MCC("5812"), eventtest.Merchant("Reversed")),
+		eventtest.Payment(day(31), "100", eventtest.EUR, eventtest.MCC("5812"), eventtest.Merchant("Next month")), // After To.
+	}
+}
+
+func testRates() *fx.Rates {
+	rates := fx.NewRates(gnosispay.EUR)
+	rates.Set(time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC), gnosispay.GBP, big.NewRat(86, 100))
+	return rates
+}
+
+func testReport(t *testing.T) *Report {
+	t.Helper()
+	ctx := context.Background()
+	events := testEvents()
+	store := &receipts.DirStore{Root: t.TempDir()}
+	if _, err := store.Add(ctx, events[0].ID(), "folio.pdf", strings.NewReader("folio")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetNote(ctx, events[1].ID(), "Client dinner (Acme & Co)"); err != nil {
+		t.Fatal(err)
+	}
+
+	from, to := Month(2025, time.January, time.UTC)
+	to = to.AddDate(0, 0, -1)
+	r, err := Build(ctx, Employee{Name: "Ada Lovelace", Email: "ada@example.com"}, events, &Options{
+		From: from, To: to,
+		Currency:   *eventtest.EUR,
+		Rates:      testRates(),
+		Receipts:   store,
+		MinReceipt: gnosispay.NewMoney(2500, *eventtest.EUR),
+		Now:        func() time.Time { return generatedAt },
+	})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	return r
+}
+
+func TestBuild(t *testing.T) {
+	r := testReport(t)
+
+	if got := r.Total.String(); got != "585.50 EUR" {
+		t.Errorf("Total = %s, want 585.50 EUR", got)
+	}
+	want := ReceiptCounts{Attached: 1, Missing: 3, NotRequired: 3}
+	if r.Receipts != want {
+		t.Errorf("Receipts = %+v, want %+v", r.Receipts, want)
+	}
+
+	wantCategories := []struct {
+		name  mcc.Category
+		total string
+		lines int
+	}{
+		{mcc.Travel, "378.00 EUR", 2},
+		{mcc.Dining, "189.50 EUR", 4},
+		{mcc.Transport, "18.00 EUR", 1},
+	}
+	if len(r.Categories) != len(wantCategories) {
+		t.Fatalf("got %d categories, want %d: %+v", len(r.Categories), len(wantCategories), r.Categories)
+	}
+	for i, w := range wantCategories {
+		c := r.Categories[i]
+		if c.Name != w.name || c.Total.String() != w.total || len(c.Lines) != w.lines {
+			t.Errorf("category %d = %s, %s, %d lines; want %s, %s, %d", i, c.Name, c.Total, len(c.Lines), w.name, w.total, w.lines)
+		}
+	}
+
+	dining := r.Categories[1].Lines
+	if l := dining[0]; l.Merchant != "Borchardt" || l.Receipt != ReceiptMissing || l.Note != "Client dinner (Acme & Co)" {
+		t.Errorf("dinner = %+v", l)
+	}
+	if l := dining[1]; l.Receipt != ReceiptNotRequired || l.Amount.String() != "3.50 EUR" {
+		t.Errorf("coffee = %+v", l)
+	}
+	if l := dining[2]; !l.Converted || l.Billed.String() != "43.00 GBP" || l.Amount.String() != "50.00 EUR" || l.Location() != "London, GB" {
+		t.Errorf("london = %+v", l)
+	}
+	if l := dining[3]; !l.Foreign() || l.Original.String() != "48.00 USD" || l.Converted {
+		t.Errorf("nyc = %+v", l)
+	}
+	travel := r.Categories[0].Lines
+	if l := travel[0]; l.Receipt != ReceiptAttached || len(l.Attachments) != 1 || l.Attachments[0] != "folio.pdf" {
+		t.Errorf("hotel = %+v", l)
+	}
+	if l := travel[1]; l.Kind != gnosispay.CardEventRefund || l.Amount.String() != "-42.00 EUR" || l.Receipt != ReceiptNotRequired {
+		t.Errorf("refund = %+v", l)
+	}
+}
+
+func TestBuild_Errors(t *testing.T) {
+	ctx := context.Background()
+	events := testEvents()
+
+	_, err := Build(ctx, Employee{}, events, &Options{Currency: *eventtest.EUR})
+	if err == nil || !strings.Contains(err.Error(), "rates are needed to convert it to EUR") {
+		t.Errorf("Build() without rates error = %v", err)
+	}
+	_, err = Build(ctx, Employee{}, events, &Options{Currency: *eventtest.EUR, Rates: fx.NewRates(gnosispay.EUR)})
+	if !errors.Is(err, fx.ErrNoRate) {
+		t.Errorf("Build() without a GBP rate error = %v, want ErrNoRate", err)
+	}
+
+	r, err := Build(ctx, Employee{}, events[:4], nil)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if r.Currency.Code != "EUR" || r.Receipts.Unknown != 4 || r.Receipts.NotRequired != 0 {
+		t.Errorf("Build() without options = %s, %+v", r.Currency.Code, r.Receipts)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, testReport(t)); err != nil {
+		t.Fatalf("WriteHTML() error = %v", err)
+	}
+	html := buf.String()
+	for _, want := range []string{
+		"Ada Lovelace &lt;ada@example.com&gt;",
+		"2025-01-01 to 2025-01-30",
+		"<h2>Travel <span class=\"muted\">378.00 EUR</span></h2>",
+		"Client dinner (Acme &amp; Co)",
+		"Katz&#39;s",
+		"48.00 USD",
+		"43.00 GBP",
+		`class="missing">missing`,
+		"folio.pdf",
+		"1 attached, 3 missing, 3 not required",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML lacks %q", want)
+		}
+	}
+	if strings.Contains(html, "Next month") || strings.Contains(html, "Declined") || strings.Contains(html, "Reversed") {
+		t.Error("HTML lists events outside the report")
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	r := testReport(t)
+	// Enough lines to need a second page.
+	for i := 0; i < 30; i++ {
+		r.Categories[2].Lines = append(r.Categories[2].Lines, r.Categories[2].Lines[0])
+	}
+
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, r); err != nil {
+		t.Fatalf("WritePDF() error = %v", err)
+	}
+	pdf := buf.Bytes()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("PDF header or trailer missing")
+	}
+
+	// The cross-reference table points at every object.
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, pdf[off:off+10])
+		}
+	}
+
+	for _, want := range []string{
+		"/Count 2",
+		shown(regular, "Page 2 of 2"),
+		shown(bold, "Transport (continued)"),
+		shown(italic, "Client dinner (Acme & Co)"),
+		shown(regular, "Ada Lovelace <ada@example.com>"),
+		shown(regular, "Hotel Adlon (refund)"),
+		"/Title (Expense report Ada Lovelace 2025-01-01 to 2025-01-30)",
+		"/BaseFont /GoRegular /Encoding /Identity-H",
+		"/BaseFont /Go-Bold",
+		"/FontFile2",
+		"/ToUnicode",
+	} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("PDF lacks %q", want)
+		}
+	}
+}
+
+// shown returns s as WritePDF draws it with f.
+func shown(f *pdfFont, s string) string {
+	return f.show(s, fontUse{}, map[rune]bool{})
+}
+
+func TestWritePDF_nonLatin(t *testing.T) {
+	cafe := eventtest.Payment(day(6), "2400", eventtest.EUR, eventtest.MCC("5812"), eventtest.Merchant("Кафе Пушкин"))
+	tokyo := eventtest.Payment(day(7), "1800", eventtest.EUR, eventtest.MCC("5812"), eventtest.Merchant("東京 Ramen"))
+	r, err := Build(context.Background(), Employee{Name: "Zoë Ελένη"}, []gnosispay.CardEvent{cafe, tokyo}, &Options{
+		Now: func() time.Time { return generatedAt },
+	})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = WritePDF(&buf, r)
+	var glyphErr *GlyphError
+	if !errors.As(err, &glyphErr) || string(glyphErr.Chars) != "京東" {
+		t.Fatalf("WritePDF() error = %v, want a GlyphError for 京東", err)
+	}
+	pdf := buf.Bytes()
+	if !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("PDF is incomplete")
+	}
+	for _, want := range []string{
+		shown(regular, "Кафе Пушкин"),
+		shown(regular, "?? Ramen"),
+		"<041A>", // ToUnicode entry of К.
+		"/Author <FEFF005A006F00EB0020039503BB03AD03BD03B7>",
+	} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("PDF lacks %q", want)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	if g := regular.glyph('Ж'); !g.ok || g.width == 0 {
+		t.Errorf("glyph(Ж) = %+v, want a glyph", g)
+	}
+	if g := regular.glyph('東'); g.ok || g.id != regular.glyph('?').id {
+		t.Errorf("glyph(東) = %+v, want '?'", g)
+	}
+	if got := fit(regular, "A very long merchant name", 10, 60); !strings.HasSuffix(got, "…") || textWidth(regular, got, 10) > 60 {
+		t.Errorf("fit() = %q, %.1f points wide", got, textWidth(regular, got, 10))
+	}
+	if got := fit(regular, "Short", 10, 60); got != "Short" {
+		t.Errorf("fit() = %q, want Short", got)
+	}
+}
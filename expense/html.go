@@ -0,0 +1,105 @@
+package expense
+
+import (
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/mcc"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date":   func(t time.Time) string { return t.Format(time.DateOnly) },
+	"period": period,
+	"title":  title,
+	"money":  func(m gnosispay.Money) string { return m.String() },
+	"join":   strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Expense report {{.Employee.Name}} {{period .}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 13px; color: #222; margin: 2em; }
+h1 { font-size: 22px; margin-bottom: 0.2em; }
+h2 { font-size: 16px; margin-top: 1.6em; border-bottom: 1px solid #ccc; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 4px 8px; vertical-align: top; }
+th { background: #f2f2f2; }
+tr:nth-child(even) td { background: #fafafa; }
+.amount { text-align: right; white-space: nowrap; }
+.muted { color: #777; font-size: 11px; }
+.missing { color: #b00020; font-weight: bold; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 2px 12px; }
+dt { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Expense report</h1>
+<dl>
+<dt>Employee</dt><dd>{{.Employee.Name}}{{with .Employee.Email}} &lt;{{.}}&gt;{{end}}</dd>
+<dt>Period</dt><dd>{{period .}}</dd>
+<dt>Total</dt><dd>{{money .Total}}</dd>
+<dt>Receipts</dt><dd>{{.Receipts.Attached}} attached, {{.Receipts.Missing}} missing, {{.Receipts.NotRequired}} not required{{with .Receipts.Unknown}}, {{.}} unknown{{end}}</dd>
+<dt>Generated</dt><dd>{{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</dd>
+</dl>
+{{range .Categories}}
+<h2>{{title .Name}} <span class="muted">{{money .Total}}</span></h2>
+<table>
+<thead><tr><th>Date</th><th>Merchant</th><th>Location</th><th class="amount">Original</th><th class="amount">Amount</th><th>Receipt</th></tr></thead>
+<tbody>
+{{range .Lines}}<tr>
+<td>{{date .Date}}{{if .Pending}} <span class="muted">pending</span>{{end}}</td>
+<td>{{.Merchant}}{{if ne .Kind "Payment"}} <span class="muted">{{.Kind}}</span>{{end}}{{with .Description}}<br><span class="muted">{{.}}</span>{{end}}{{with .Note}}<br><em>{{.}}</em>{{end}}</td>
+<td>{{.Location}}</td>
+<td class="amount">{{if .Foreign}}{{money .Original}}{{end}}</td>
+<td class="amount">{{money .Amount}}{{if .Converted}}<br><span class="muted">{{money .Billed}}</span>{{end}}</td>
+<td{{if eq .Receipt "missing"}} class="missing"{{end}}>{{.Receipt}}{{with .Attachments}}<br><span class="muted">{{join . ", "}}</span>{{end}}</td>
+</tr>
+{{end}}</tbody>
+</table>
+{{else}}
+<p>No expenses in this period.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML renders r as a standalone HTML page.
+func WriteHTML(w io.Writer, r *Report) error {
+	return htmlTemplate.Execute(w, r)
+}
+
+// period describes the report's period, e.g. "2025-01-01 to 2025-01-31".
+func period(r *Report) string {
+	switch {
+	case r.From.IsZero() && r.To.IsZero():
+		return "all time"
+	case r.To.IsZero():
+		return "since " + r.From.Format(time.DateOnly)
+	case r.From.IsZero():
+		return "until " + r.To.Add(-time.Nanosecond).Format(time.DateOnly)
+	}
+	return r.From.Format(time.DateOnly) + " to " + r.To.Add(-time.Nanosecond).Format(time.DateOnly)
+}
+
+// title capitalises a category name.
+func title(c mcc.Category) string {
+	if c == "" {
+		return ""
+	}
+	return strings.ToUpper(string(c[:1])) + string(c[1:])
+}
+
+// Location joins the merchant's city and country.
+func (l Line) Location() string {
+	var parts []string
+	for _, p := range []string{l.City, l.Country} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
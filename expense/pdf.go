@@ -0,0 +1,390 @@
+package expense
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// A4 in points, and the layout of report pages.
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+	margin     = 40.0
+	bottom     = 60.0 // Lowest baseline of body text; the footer sits below.
+)
+
+// Table columns: left edges for text, right edges for amounts.
+const (
+	colDate     = margin
+	colMerchant = 100.0
+	colLocation = 280.0
+	colOriginal = 425.0 // Right edge.
+	colAmount   = 495.0 // Right edge.
+	colReceipt  = 503.0
+	rightEdge   = pageWidth - margin
+)
+
+const (
+	bodySize   = 8.5
+	detailSize = 7.0
+	lineHeight = 11.0
+	muted      = 0.45
+)
+
+// GlyphError reports characters the PDF fonts have no glyph for. WritePDF
+// still writes the whole document, with '?' in their place; WriteHTML
+// shows them as they are.
+type GlyphError struct {
+	Chars []rune
+}
+
+func (e *GlyphError) Error() string {
+	return fmt.Sprintf("expense: PDF fonts cannot show %q", string(e.Chars))
+}
+
+// WritePDF renders r as an A4 PDF document. When r has characters the
+// embedded fonts lack, the document is complete and the error is a
+// *GlyphError.
+func WritePDF(w io.Writer, r *Report) error {
+	d := &pdfDoc{}
+	d.newPage()
+
+	d.text(margin, d.y, bold, 18, 0, "Expense report")
+	d.y -= 28
+	status := fmt.Sprintf("%d attached, %d missing, %d not required",
+		r.Receipts.Attached, r.Receipts.Missing, r.Receipts.NotRequired)
+	if r.Receipts.Unknown > 0 {
+		status += fmt.Sprintf(", %d unknown", r.Receipts.Unknown)
+	}
+	employee := r.Employee.Name
+	if r.Employee.Email != "" {
+		employee = strings.TrimSpace(employee + " <" + r.Employee.Email + ">")
+	}
+	for _, f := range [][2]string{
+		{"Employee", employee},
+		{"Period", period(r)},
+		{"Total", r.Total.String()},
+		{"Receipts", status},
+		{"Generated", r.GeneratedAt.Format("2006-01-02 15:04 MST")},
+	} {
+		d.text(margin, d.y, bold, 10, 0, f[0])
+		d.text(margin+70, d.y, regular, 10, 0, f[1])
+		d.y -= 14
+	}
+
+	for _, c := range r.Categories {
+		if slices.ContainsFunc(c.Lines, func(l Line) bool { return l.Pending }) {
+			d.text(margin, d.y, regular, detailSize, muted, "Dates marked * are pending and may still change.")
+			d.y -= 14
+			break
+		}
+	}
+	if len(r.Categories) == 0 {
+		d.y -= 10
+		d.text(margin, d.y, regular, 10, 0, "No expenses in this period.")
+	}
+	for _, c := range r.Categories {
+		d.y -= 14
+		if !d.fits(3*lineHeight + 24) {
+			d.newPage()
+		}
+		categoryHeader(d, c, false)
+		for _, l := range c.Lines {
+			details := lineDetails(l)
+			if !d.fits(lineHeight * float64(1+len(details))) {
+				d.newPage()
+				categoryHeader(d, c, true)
+			}
+			pdfLine(d, l, details)
+		}
+	}
+
+	footer := strings.TrimSpace("Expense report " + r.Employee.Name + ", " + period(r))
+	for i := range d.pages {
+		d.cur = i
+		d.rule(margin, rightEdge, 40, 0.8)
+		d.text(margin, 28, regular, detailSize, muted, footer)
+		d.textRight(rightEdge, 28, regular, detailSize, muted, fmt.Sprintf("Page %d of %d", i+1, len(d.pages)))
+	}
+
+	title := strings.TrimSpace("Expense report " + r.Employee.Name + " " + period(r))
+	if err := d.write(w, pdfInfo{Title: title, Author: r.Employee.Name, Created: r.GeneratedAt}); err != nil {
+		return err
+	}
+	if len(d.missing) > 0 {
+		return &GlyphError{Chars: sortedRunes(d.missing)}
+	}
+	return nil
+}
+
+func categoryHeader(d *pdfDoc, c Category, continued bool) {
+	name := title(c.Name)
+	if continued {
+		name += " (continued)"
+	}
+	d.text(margin, d.y, bold, 12, 0, name)
+	d.textRight(rightEdge, d.y, bold, 12, 0, c.Total.String())
+	d.y -= 6
+	d.rule(margin, rightEdge, d.y, 0.6)
+	d.y -= 11
+	for _, h := range []struct {
+		x     float64
+		right bool
+		s     string
+	}{
+		{colDate, false, "Date"}, {colMerchant, false, "Merchant"}, {colLocation, false, "Location"},
+		{colOriginal, true, "Original"}, {colAmount, true, "Amount"}, {colReceipt, false, "Receipt"},
+	} {
+		if h.right {
+			d.textRight(h.x, d.y, bold, detailSize, muted, h.s)
+		} else {
+			d.text(h.x, d.y, bold, detailSize, muted, h.s)
+		}
+	}
+	d.y -= lineHeight + 2
+}
+
+// lineDetail is a smaller line of text printed under an expense.
+type lineDetail struct {
+	x     float64
+	font  *pdfFont
+	right bool
+	width float64
+	s     string
+}
+
+// lineDetails returns the rows of smaller text under an expense: the MCC
+// description, the billed amount of converted expenses and the attachment
+// names, then the note.
+func lineDetails(l Line) [][]lineDetail {
+	var first []lineDetail
+	if l.Description != "" {
+		first = append(first, lineDetail{x: colMerchant, width: colLocation - colMerchant - 8, s: l.Description})
+	}
+	if l.Converted {
+		first = append(first, lineDetail{x: colAmount, right: true, s: l.Billed.String()})
+	}
+	if len(l.Attachments) > 0 {
+		first = append(first, lineDetail{x: colReceipt, width: rightEdge - colReceipt, s: strings.Join(l.Attachments, ", ")})
+	}
+	var rows [][]lineDetail
+	if len(first) > 0 {
+		rows = append(rows, first)
+	}
+	if l.Note != "" {
+		rows = append(rows, []lineDetail{{x: colMerchant, font: italic, width: colReceipt - colMerchant - 8, s: l.Note}})
+	}
+	return rows
+}
+
+func pdfLine(d *pdfDoc, l Line, details [][]lineDetail) {
+	date := l.Date.Format(time.DateOnly)
+	if l.Pending {
+		date += "*"
+	}
+	merchant := l.Merchant
+	if l.Kind != "" && l.Kind != "Payment" {
+		merchant += " (" + strings.ToLower(string(l.Kind)) + ")"
+	}
+	d.text(colDate, d.y, regular, bodySize, 0, date)
+	d.text(colMerchant, d.y, regular, bodySize, 0, fit(regular, merchant, bodySize, colLocation-colMerchant-8))
+	d.text(colLocation, d.y, regular, bodySize, 0, fit(regular, l.Location(), bodySize, colOriginal-colLocation-60))
+	if l.Foreign() {
+		d.textRight(colOriginal, d.y, regular, bodySize, 0, l.Original.String())
+	}
+	d.textRight(colAmount, d.y, regular, bodySize, 0, l.Amount.String())
+	font := regular
+	if l.Receipt == ReceiptMissing {
+		font = bold
+	}
+	d.text(colReceipt, d.y, font, bodySize, 0, string(l.Receipt))
+	d.y -= lineHeight
+
+	for _, row := range details {
+		d.y += 2
+		for _, c := range row {
+			font := c.font
+			if font == nil {
+				font = regular
+			}
+			if c.right {
+				d.textRight(c.x, d.y, font, detailSize, muted, c.s)
+			} else {
+				d.text(c.x, d.y, font, detailSize, muted, fit(font, c.s, detailSize, c.width))
+			}
+		}
+		d.y -= lineHeight - 2
+	}
+	d.y -= 2
+}
+
+// pdfDoc lays out text and rules on pages and writes them as a PDF file.
+type pdfDoc struct {
+	pages []*bytes.Buffer
+	cur   int     // Index of the page being drawn on.
+	y     float64 // Baseline of the next line on the current page.
+
+	used    map[*pdfFont]fontUse
+	missing map[rune]bool // Characters drawn as '?'.
+}
+
+func (d *pdfDoc) page() *bytes.Buffer {
+	return d.pages[d.cur]
+}
+
+func (d *pdfDoc) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.cur = len(d.pages) - 1
+	d.y = pageHeight - margin
+}
+
+// fits reports whether height more points fit above the bottom margin.
+func (d *pdfDoc) fits(height float64) bool {
+	return d.y-height >= bottom
+}
+
+// text draws s with its baseline at y, starting at x. A gray level of 0 is
+// black.
+func (d *pdfDoc) text(x, y float64, font *pdfFont, size, gray float64, s string) {
+	if s == "" {
+		return
+	}
+	if d.used == nil {
+		d.used, d.missing = map[*pdfFont]fontUse{}, map[rune]bool{}
+	}
+	if d.used[font] == nil {
+		d.used[font] = fontUse{}
+	}
+	fmt.Fprintf(d.page(), "BT %s g /%s %s Tf %s %s Td %s Tj ET\n",
+		num(gray), font.name, num(size), num(x), num(y), font.show(s, d.used[font], d.missing))
+}
+
+// textRight draws s ending at x.
+func (d *pdfDoc) textRight(x, y float64, font *pdfFont, size, gray float64, s string) {
+	d.text(x-textWidth(font, s, size), y, font, size, gray, s)
+}
+
+// rule draws a horizontal line at y.
+func (d *pdfDoc) rule(x1, x2, y, gray float64) {
+	fmt.Fprintf(d.page(), "%s G 0.5 w %s %s m %s %s l S\n", num(gray), num(x1), num(y), num(x2), num(y))
+}
+
+// pdfInfo is the document information dictionary.
+type pdfInfo struct {
+	Title, Author string
+	Created       time.Time
+}
+
+// write writes the document. Page contents are stored uncompressed; the
+// fonts are embedded whole.
+func (d *pdfDoc) write(w io.Writer, info pdfInfo) error {
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	var offsets []int64
+	obj := func(body string) {
+		offsets = append(offsets, cw.n)
+		fmt.Fprintf(cw, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-2 are the catalog and page tree, then the objects of each
+	// font drawn with and the info dictionary, then a page and its contents
+	// for each page.
+	var used []*pdfFont
+	for _, f := range pdfFonts {
+		if d.used[f] != nil {
+			used = append(used, f)
+		}
+	}
+	const fontObjects = 5
+	fontObj := 3
+	infoObj := fontObj + fontObjects*len(used)
+	firstPage := infoObj + 1
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+	var fonts []string
+	for i, f := range used {
+		fonts = append(fonts, fmt.Sprintf("/%s %d 0 R", f.name, fontObj+fontObjects*i))
+	}
+
+	cw.Write([]byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"))
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	for i, f := range used {
+		for _, body := range f.objects(fontObj+fontObjects*i, d.used[f]) {
+			obj(body)
+		}
+	}
+	obj(fmt.Sprintf("<< /Title %s /Author %s /Producer (go-gnosispay) /CreationDate (D:%s) >>",
+		pdfString(info.Title), pdfString(info.Author), info.Created.UTC().Format("20060102150405Z")))
+	for i, p := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			num(pageWidth), num(pageHeight), strings.Join(fonts, " "), firstPage+2*i+1))
+		obj(stream(p.String()))
+	}
+
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(cw, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(cw, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, infoObj, xref)
+	if cw.err != nil {
+		return cw.err
+	}
+	return bw.Flush()
+}
+
+// pdfString returns s as a PDF text string: a literal string when s is
+// ASCII, UTF-16 otherwise.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if c >= utf8.RuneSelf {
+			return "<FEFF" + utf16Hex(s) + ">"
+		}
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return "(" + b.String() + ")"
+}
+
+// stream returns an uncompressed stream object body.
+func stream(data string) string {
+	return fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(data), data)
+}
+
+// num formats a coordinate with at most two decimals.
+func num(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 2, 64)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// countingWriter tracks the byte offsets needed for the cross-reference
+// table and keeps the first write error.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,235 @@
+package expense
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// PDF fonts. The Go fonts are embedded as TrueType and text is written as
+// glyph IDs (Identity-H), so merchant names in Latin, Greek and Cyrillic
+// scripts print as they are. Characters the fonts lack print as '?' and
+// are reported by WritePDF.
+var (
+	regular = newPDFFont("F1", goregular.TTF)
+	bold    = newPDFFont("F2", gobold.TTF)
+	italic  = newPDFFont("F3", goitalic.TTF)
+
+	pdfFonts = []*pdfFont{regular, bold, italic}
+)
+
+// pdfFont is an embeddable TrueType font.
+type pdfFont struct {
+	name string // Resource name in page dictionaries.
+	ttf  []byte
+	font *sfnt.Font
+
+	// Font descriptor values, in thousandths of the font size.
+	base                       string
+	bbox                       [4]int
+	ascent, descent, capHeight int
+	italicAngle                float64
+
+	glyphs sync.Map // rune to glyph.
+
+	// compressed is the FlateDecode font program.
+	compressed func() []byte
+}
+
+// glyph is a character as drawn with a pdfFont.
+type glyph struct {
+	id    sfnt.GlyphIndex
+	width int  // Advance in thousandths of the font size.
+	ok    bool // Unset when the font lacks the character and '?' stands in.
+}
+
+func newPDFFont(name string, ttf []byte) *pdfFont {
+	f, err := sfnt.Parse(ttf)
+	if err != nil {
+		panic(fmt.Sprintf("expense: parsing font %s: %v", name, err))
+	}
+	var b sfnt.Buffer
+	base, err := f.Name(&b, sfnt.NameIDPostScript)
+	if err != nil {
+		panic(fmt.Sprintf("expense: font %s has no PostScript name: %v", name, err))
+	}
+	m, _ := f.Metrics(&b, fixed.I(1000), font.HintingNone)
+	bounds, _ := f.Bounds(&b, fixed.I(1000), font.HintingNone)
+
+	pf := &pdfFont{
+		name: name,
+		ttf:  ttf,
+		font: f,
+		base: base,
+		// sfnt's y axis points down.
+		bbox:      [4]int{bounds.Min.X.Round(), -bounds.Max.Y.Round(), bounds.Max.X.Round(), -bounds.Min.Y.Round()},
+		ascent:    m.Ascent.Round(),
+		descent:   -m.Descent.Round(),
+		capHeight: m.CapHeight.Round(),
+	}
+	if post := f.PostTable(); post != nil {
+		pf.italicAngle = post.ItalicAngle
+	}
+	pf.compressed = sync.OnceValue(func() []byte {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(ttf)
+		zw.Close()
+		return buf.Bytes()
+	})
+	return pf
+}
+
+// glyph returns the glyph drawn for r. White space draws as a space.
+func (f *pdfFont) glyph(r rune) glyph {
+	if unicode.IsSpace(r) {
+		r = ' '
+	}
+	if g, ok := f.glyphs.Load(r); ok {
+		return g.(glyph)
+	}
+	var b sfnt.Buffer
+	id, err := f.font.GlyphIndex(&b, r)
+	ok := err == nil && id != 0
+	if !ok {
+		id, _ = f.font.GlyphIndex(&b, '?')
+	}
+	adv, _ := f.font.GlyphAdvance(&b, id, fixed.I(1000), font.HintingNone)
+	g := glyph{id: id, width: adv.Round(), ok: ok}
+	f.glyphs.Store(r, g)
+	return g
+}
+
+// textWidth returns the width of s in points when drawn with f at size.
+func textWidth(f *pdfFont, s string, size float64) float64 {
+	w := 0
+	for _, r := range s {
+		w += f.glyph(r).width
+	}
+	return float64(w) * size / 1000
+}
+
+// fit shortens s with an ellipsis until it is at most width points wide.
+func fit(f *pdfFont, s string, size, width float64) string {
+	if textWidth(f, s, size) <= width {
+		return s
+	}
+	for s != "" {
+		_, n := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-n]
+		if textWidth(f, s+"…", size) <= width {
+			return strings.TrimSpace(s) + "…"
+		}
+	}
+	return ""
+}
+
+// fontUse records the glyphs a document draws with one font, for the
+// widths and the ToUnicode map written with it.
+type fontUse map[sfnt.GlyphIndex]rune
+
+// show returns s as a hex string of glyph IDs, recording the glyphs in
+// used and the characters f lacks in missing.
+func (f *pdfFont) show(s string, used fontUse, missing map[rune]bool) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range s {
+		g := f.glyph(r)
+		if !g.ok {
+			missing[r] = true
+			r = '?'
+		} else if unicode.IsSpace(r) {
+			r = ' '
+		}
+		if _, ok := used[g.id]; !ok {
+			used[g.id] = r
+		}
+		fmt.Fprintf(&b, "%04X", uint16(g.id))
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// objects returns the bodies of the objects that embed f: the Type 0 font,
+// its descendant CID font, the font descriptor, the font program and the
+// ToUnicode map. first is the number of the first of them.
+func (f *pdfFont) objects(first int, used fontUse) []string {
+	ids := make([]sfnt.GlyphIndex, 0, len(used))
+	for id := range used {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	var widths strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&widths, "%d [%d] ", id, f.glyph(used[id]).width)
+	}
+
+	flags := 32 // Nonsymbolic.
+	if f.italicAngle != 0 {
+		flags |= 64
+	}
+	program := f.compressed()
+	return []string{
+		fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+			f.base, first+1, first+4),
+		fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /DW 1000 /W [%s] >>",
+			f.base, first+2, strings.TrimSpace(widths.String())),
+		fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags %d /FontBBox [%d %d %d %d] /ItalicAngle %s /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+			f.base, flags, f.bbox[0], f.bbox[1], f.bbox[2], f.bbox[3], num(f.italicAngle), f.ascent, f.descent, f.capHeight, first+3),
+		fmt.Sprintf("<< /Length %d /Length1 %d /Filter /FlateDecode >>\nstream\n%s\nendstream", len(program), len(f.ttf), program),
+		stream(toUnicode(ids, used)),
+	}
+}
+
+// toUnicode returns a CMap that maps the glyph IDs back to characters, so
+// text can be searched and copied.
+func toUnicode(ids []sfnt.GlyphIndex, used fontUse) string {
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// A bfchar block holds at most 100 entries.
+	for chunk := range slices.Chunk(ids, 100) {
+		fmt.Fprintf(&b, "%d beginbfchar\n", len(chunk))
+		for _, id := range chunk {
+			fmt.Fprintf(&b, "<%04X> <%s>\n", uint16(id), utf16Hex(string(used[id])))
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend")
+	return b.String()
+}
+
+// utf16Hex returns s as hex UTF-16BE.
+func utf16Hex(s string) string {
+	var b strings.Builder
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	return b.String()
+}
+
+// sortedRunes returns the keys of set in order.
+func sortedRunes(set map[rune]bool) []rune {
+	out := make([]rune, 0, len(set))
+	for r := range set {
+		out = append(out, r)
+	}
+	slices.Sort(out)
+	return out
+}
@@ -0,0 +1,376 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// CAMT053Options configures WriteCAMT053.
+type CAMT053Options struct {
+	// IBAN of the statement account, as returned by IBANService.GetDetails.
+	// Required.
+	IBAN string
+
+	// BIC of the account servicer and name of the account owner. Optional.
+	BIC   string
+	Owner string
+
+	// Currency of the account. Defaults to EUR.
+	Currency gnosispay.CurrencyCode
+
+	// From and To bound the statement period, inclusive. Orders placed
+	// outside the period are left out. Required.
+	From, To time.Time
+
+	// OpeningBalance and ClosingBalance are reported as the OPBD and CLBD
+	// balances. The API has no historical balances, so the caller has to
+	// supply them.
+	OpeningBalance gnosispay.Money
+	ClosingBalance gnosispay.Money
+
+	// MessageID and StatementID identify the statement. They default to an
+	// identifier derived from the period and the end of the IBAN. Values
+	// longer than 35 characters are truncated.
+	MessageID   string
+	StatementID string
+
+	// SequenceNumber is the electronic sequence number of the statement.
+	SequenceNumber int
+
+	// Now returns the creation time written to the statement.
+	// Defaults to time.Now.
+	Now func() time.Time
+}
+
+type camtDocument struct {
+	XMLName xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:camt.053.001.02 Document"`
+	Stmt    struct {
+		GrpHdr struct {
+			MsgId   string `xml:"MsgId"`
+			CreDtTm string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		Stmt camtStatement `xml:"Stmt"`
+	} `xml:"BkToCstmrStmt"`
+}
+
+type camtStatement struct {
+	Id           string `xml:"Id"`
+	ElctrncSeqNb string `xml:"ElctrncSeqNb,omitempty"`
+	CreDtTm      string `xml:"CreDtTm"`
+	FrToDt       struct {
+		FrDtTm string `xml:"FrDtTm"`
+		ToDtTm string `xml:"ToDtTm"`
+	} `xml:"FrToDt"`
+	Acct struct {
+		Id struct {
+			IBAN string `xml:"IBAN"`
+		} `xml:"Id"`
+		Ccy  string        `xml:"Ccy"`
+		Ownr *camtParty    `xml:"Ownr,omitempty"`
+		Svcr *camtServicer `xml:"Svcr,omitempty"`
+	} `xml:"Acct"`
+	Bal       []camtBalance `xml:"Bal"`
+	TxsSummry struct {
+		TtlNtries    camtSummary  `xml:"TtlNtries"`
+		TtlCdtNtries camtNumbered `xml:"TtlCdtNtries"`
+		TtlDbtNtries camtNumbered `xml:"TtlDbtNtries"`
+	} `xml:"TxsSummry"`
+	Ntry []camtEntry `xml:"Ntry"`
+}
+
+type camtParty struct {
+	Nm string `xml:"Nm"`
+}
+
+type camtServicer struct {
+	FinInstnId struct {
+		BIC string `xml:"BIC"`
+	} `xml:"FinInstnId"`
+}
+
+type camtRemittance struct {
+	Ustrd string `xml:"Ustrd"`
+}
+
+type camtAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtDate struct {
+	Dt string `xml:"Dt"`
+}
+
+type camtBalance struct {
+	Tp struct {
+		CdOrPrtry struct {
+			Cd string `xml:"Cd"`
+		} `xml:"CdOrPrtry"`
+	} `xml:"Tp"`
+	Amt       camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	Dt        camtDate   `xml:"Dt"`
+}
+
+type camtNumbered struct {
+	NbOfNtries string `xml:"NbOfNtries"`
+	Sum        string `xml:"Sum"`
+}
+
+type camtSummary struct {
+	NbOfNtries    string `xml:"NbOfNtries"`
+	Sum           string `xml:"Sum"`
+	TtlNetNtryAmt string `xml:"TtlNetNtryAmt"`
+	CdtDbtInd     string `xml:"CdtDbtInd"`
+}
+
+type camtEntry struct {
+	NtryRef     string     `xml:"NtryRef"`
+	Amt         camtAmount `xml:"Amt"`
+	CdtDbtInd   string     `xml:"CdtDbtInd"`
+	Sts         string     `xml:"Sts"`
+	BookgDt     camtDate   `xml:"BookgDt"`
+	ValDt       camtDate   `xml:"ValDt"`
+	AcctSvcrRef string     `xml:"AcctSvcrRef"`
+	BkTxCd      struct {
+		Domn struct {
+			Cd   string `xml:"Cd"`
+			Fmly struct {
+				Cd        string `xml:"Cd"`
+				SubFmlyCd string `xml:"SubFmlyCd"`
+			} `xml:"Fmly"`
+		} `xml:"Domn"`
+	} `xml:"BkTxCd"`
+	NtryDtls struct {
+		TxDtls struct {
+			Refs struct {
+				AcctSvcrRef string `xml:"AcctSvcrRef"`
+				EndToEndId  string `xml:"EndToEndId"`
+			} `xml:"Refs"`
+			RltdPties *camtRelatedParties `xml:"RltdPties,omitempty"`
+			RmtInf    *camtRemittance     `xml:"RmtInf,omitempty"`
+		} `xml:"TxDtls"`
+	} `xml:"NtryDtls"`
+}
+
+type camtRelatedParties struct {
+	Dbtr     *camtParty   `xml:"Dbtr,omitempty"`
+	DbtrAcct *camtAccount `xml:"DbtrAcct,omitempty"`
+	Cdtr     *camtParty   `xml:"Cdtr,omitempty"`
+	CdtrAcct *camtAccount `xml:"CdtrAcct,omitempty"`
+}
+
+type camtAccount struct {
+	Id struct {
+		IBAN string `xml:"IBAN"`
+	} `xml:"Id"`
+}
+
+// WriteCAMT053 writes IBAN orders as an ISO 20022 camt.053.001.02 bank
+// to customer statement.
+//
+// Issued orders (incoming SEPA transfers) become credit entries with the
+// counterpart as debtor, redeemed orders become debit entries with the
+// counterpart as creditor. Processed orders are booked (BOOK), other
+// non-rejected orders are reported as pending (PDNG). The booking date is
+// taken from IbanOrderMetadata.PlacedAt and the memo becomes unstructured
+// remittance information.
+func WriteCAMT053(w io.Writer, orders []gnosispay.IbanOrder, opts CAMT053Options) error {
+	if opts.IBAN == "" {
+		return fmt.Errorf("IBAN cannot be empty")
+	}
+	if opts.From.IsZero() || opts.To.IsZero() {
+		return fmt.Errorf("statement period is required")
+	}
+	if opts.To.Before(opts.From) {
+		return fmt.Errorf("statement period ends before it starts")
+	}
+	currency := opts.Currency
+	if currency == "" {
+		currency = gnosispay.EUR
+	}
+	if err := currency.Validate(); err != nil {
+		return err
+	}
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+
+	iban := strings.ReplaceAll(opts.IBAN, " ", "")
+	id := fmt.Sprintf("%s-%s-%s", opts.From.UTC().Format("20060102"), opts.To.UTC().Format("20060102"), iban[max(0, len(iban)-8):])
+	if opts.StatementID == "" {
+		opts.StatementID = id
+	}
+	if opts.MessageID == "" {
+		opts.MessageID = "GP-" + id
+	}
+
+	var doc camtDocument
+	created := now().UTC().Format(time.RFC3339)
+	doc.Stmt.GrpHdr.MsgId = truncate(opts.MessageID, 35)
+	doc.Stmt.GrpHdr.CreDtTm = created
+
+	st := &doc.Stmt.Stmt
+	st.Id = truncate(opts.StatementID, 35)
+	if opts.SequenceNumber > 0 {
+		st.ElctrncSeqNb = strconv.Itoa(opts.SequenceNumber)
+	}
+	st.CreDtTm = created
+	st.FrToDt.FrDtTm = opts.From.UTC().Format(time.RFC3339)
+	st.FrToDt.ToDtTm = opts.To.UTC().Format(time.RFC3339)
+	st.Acct.Id.IBAN = iban
+	st.Acct.Ccy = currency.String()
+	if opts.Owner != "" {
+		st.Acct.Ownr = &camtParty{Nm: truncate(opts.Owner, 140)}
+	}
+	if opts.BIC != "" {
+		st.Acct.Svcr = &camtServicer{}
+		st.Acct.Svcr.FinInstnId.BIC = opts.BIC
+	}
+
+	opening, err := camtBal("OPBD", opts.OpeningBalance, currency, opts.From)
+	if err != nil {
+		return fmt.Errorf("opening balance: %w", err)
+	}
+	closing, err := camtBal("CLBD", opts.ClosingBalance, currency, opts.To)
+	if err != nil {
+		return fmt.Errorf("closing balance: %w", err)
+	}
+	st.Bal = []camtBalance{opening, closing}
+
+	zero := gnosispay.NewMoney(0, currency.Currency())
+	credits, debits := zero, zero
+	var nCredits, nDebits int
+	for _, o := range orders {
+		if strings.EqualFold(o.State, "rejected") {
+			continue
+		}
+		if o.Meta == nil {
+			return fmt.Errorf("order %s: missing placedAt", o.Id)
+		}
+		placed, err := time.Parse(time.RFC3339, o.Meta.PlacedAt)
+		if err != nil {
+			return fmt.Errorf("order %s: invalid placedAt: %w", o.Id, err)
+		}
+		if placed.Before(opts.From) || placed.After(opts.To) {
+			continue
+		}
+		amount, err := o.Money()
+		if err != nil {
+			return fmt.Errorf("order %s: %w", o.Id, err)
+		}
+		if amount.Currency.Code != currency.String() {
+			return fmt.Errorf("order %s: currency %s does not match account currency %s", o.Id, amount.Currency.Code, currency)
+		}
+		amount = amount.Abs()
+
+		// Order IDs are UUIDs; without hyphens they fit Max35Text.
+		ref := truncate(strings.ReplaceAll(o.Id, "-", ""), 35)
+		entry := camtEntry{
+			NtryRef:     ref,
+			Amt:         camtAmount{Ccy: currency.String(), Value: amount.Decimal()},
+			Sts:         "PDNG",
+			BookgDt:     camtDate{Dt: placed.UTC().Format(time.DateOnly)},
+			ValDt:       camtDate{Dt: placed.UTC().Format(time.DateOnly)},
+			AcctSvcrRef: ref,
+		}
+		if strings.EqualFold(o.State, "processed") {
+			entry.Sts = "BOOK"
+		}
+		entry.BkTxCd.Domn.Cd = "PMNT"
+		entry.BkTxCd.Domn.Fmly.SubFmlyCd = "ESCT"
+		entry.NtryDtls.TxDtls.Refs.AcctSvcrRef = entry.AcctSvcrRef
+		entry.NtryDtls.TxDtls.Refs.EndToEndId = "NOTPROVIDED"
+		if o.Memo != "" {
+			entry.NtryDtls.TxDtls.RmtInf = &camtRemittance{Ustrd: truncate(o.Memo, 140)}
+		}
+
+		var party *camtParty
+		var account *camtAccount
+		if o.Counterpart != nil {
+			if name := o.Counterpart.Details.Name; name != "" {
+				party = &camtParty{Nm: truncate(name, 140)}
+			}
+			if cpIBAN := o.Counterpart.Identifier.Iban; cpIBAN != "" {
+				account = &camtAccount{}
+				account.Id.IBAN = strings.ReplaceAll(cpIBAN, " ", "")
+			}
+		}
+
+		if strings.EqualFold(o.Kind, "issue") {
+			entry.CdtDbtInd = "CRDT"
+			entry.BkTxCd.Domn.Fmly.Cd = "RCDT"
+			if party != nil || account != nil {
+				entry.NtryDtls.TxDtls.RltdPties = &camtRelatedParties{Dbtr: party, DbtrAcct: account}
+			}
+			if credits, err = credits.Add(amount); err != nil {
+				return err
+			}
+			nCredits++
+		} else {
+			entry.CdtDbtInd = "DBIT"
+			entry.BkTxCd.Domn.Fmly.Cd = "ICDT"
+			if party != nil || account != nil {
+				entry.NtryDtls.TxDtls.RltdPties = &camtRelatedParties{Cdtr: party, CdtrAcct: account}
+			}
+			if debits, err = debits.Add(amount); err != nil {
+				return err
+			}
+			nDebits++
+		}
+		st.Ntry = append(st.Ntry, entry)
+	}
+
+	sum, _ := credits.Add(debits)
+	net, _ := credits.Sub(debits)
+	st.TxsSummry.TtlNtries = camtSummary{
+		NbOfNtries:    strconv.Itoa(nCredits + nDebits),
+		Sum:           sum.Decimal(),
+		TtlNetNtryAmt: net.Abs().Decimal(),
+		CdtDbtInd:     creditDebit(net),
+	}
+	st.TxsSummry.TtlCdtNtries = camtNumbered{NbOfNtries: strconv.Itoa(nCredits), Sum: credits.Decimal()}
+	st.TxsSummry.TtlDbtNtries = camtNumbered{NbOfNtries: strconv.Itoa(nDebits), Sum: debits.Decimal()}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func camtBal(code string, m gnosispay.Money, currency gnosispay.CurrencyCode, date time.Time) (camtBalance, error) {
+	if m.Currency.Code == "" {
+		m = gnosispay.NewMoneyFromBig(m.MinorUnits(), currency.Currency())
+	}
+	if m.Currency.Code != currency.String() {
+		return camtBalance{}, fmt.Errorf("currency %s does not match account currency %s", m.Currency.Code, currency)
+	}
+	var b camtBalance
+	b.Tp.CdOrPrtry.Cd = code
+	b.Amt = camtAmount{Ccy: currency.String(), Value: m.Abs().Decimal()}
+	b.CdtDbtInd = creditDebit(m)
+	b.Dt = camtDate{Dt: date.UTC().Format(time.DateOnly)}
+	return b, nil
+}
+
+func creditDebit(m gnosispay.Money) string {
+	if m.Sign() < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
@@ -0,0 +1,354 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+func TestWriteCAMT053(t *testing.T) {
+	orders := loadOrders(t)
+	eur := gnosispay.EUR.Currency()
+	opts := CAMT053Options{
+		IBAN:           "DE42 1001 1001 2625 2110 04",
+		BIC:            "TRWIBEB1XXX",
+		Owner:          "Jane Doe",
+		From:           time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2025, 1, 31, 23, 59, 59, 0, time.UTC),
+		OpeningBalance: gnosispay.NewMoney(-2500, eur),
+		ClosingBalance: gnosispay.NewMoney(147500, eur),
+		SequenceNumber: 1,
+		Now:            func() time.Time { return time.Date(2025, 2, 1, 6, 0, 0, 0, time.UTC) },
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCAMT053(&buf, orders, opts); err != nil {
+		t.Fatalf("WriteCAMT053() error = %v", err)
+	}
+	checkGolden(t, "statement.camt053.golden", buf.Bytes())
+
+	schema := loadSchema(t, "camt.053.001.02.xsd")
+	if err := schema.validate(buf.Bytes()); err != nil {
+		t.Errorf("statement does not match schema: %v", err)
+	}
+
+	t.Run("period excludes orders", func(t *testing.T) {
+		short := opts
+		short.From = time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)
+		var buf bytes.Buffer
+		if err := WriteCAMT053(&buf, orders, short); err != nil {
+			t.Fatalf("WriteCAMT053() error = %v", err)
+		}
+		if n := strings.Count(buf.String(), "<Ntry>"); n != 1 {
+			t.Errorf("WriteCAMT053() wrote %d entries, want 1", n)
+		}
+		if err := schema.validate(buf.Bytes()); err != nil {
+			t.Errorf("statement does not match schema: %v", err)
+		}
+	})
+
+	t.Run("empty statement", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteCAMT053(&buf, nil, CAMT053Options{IBAN: "DE42100110012625211004", From: opts.From, To: opts.To}); err != nil {
+			t.Fatalf("WriteCAMT053() error = %v", err)
+		}
+		if err := schema.validate(buf.Bytes()); err != nil {
+			t.Errorf("statement does not match schema: %v", err)
+		}
+	})
+
+	errTests := []struct {
+		name   string
+		modify func(*CAMT053Options)
+	}{
+		{name: "missing IBAN", modify: func(o *CAMT053Options) { o.IBAN = "" }},
+		{name: "missing period", modify: func(o *CAMT053Options) { o.From = time.Time{} }},
+		{name: "reversed period", modify: func(o *CAMT053Options) { o.From, o.To = o.To, o.From }},
+		{name: "balance currency mismatch", modify: func(o *CAMT053Options) {
+			o.OpeningBalance = gnosispay.NewMoney(1, gnosispay.USD.Currency())
+		}},
+		{name: "account currency mismatch", modify: func(o *CAMT053Options) {
+			o.Currency = gnosispay.GBP
+			o.OpeningBalance, o.ClosingBalance = gnosispay.Money{}, gnosispay.Money{}
+		}},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := opts
+			tt.modify(&o)
+			if err := WriteCAMT053(&bytes.Buffer{}, orders, o); err == nil {
+				t.Error("WriteCAMT053() expected error")
+			}
+		})
+	}
+}
+
+// The types below implement the subset of XML Schema used by the camt.053
+// schema in testdata: sequences and choices of elements, simple content
+// with attributes, and string, decimal and date restrictions.
+
+type xsdSchema struct {
+	TargetNamespace string           `xml:"targetNamespace,attr"`
+	Elements        []xsdElement     `xml:"element"`
+	ComplexTypes    []xsdComplexType `xml:"complexType"`
+	SimpleTypes     []xsdSimpleType  `xml:"simpleType"`
+
+	complex map[string]xsdComplexType
+	simple  map[string]xsdSimpleType
+}
+
+type xsdElement struct {
+	Name      string `xml:"name,attr"`
+	Type      string `xml:"type,attr"`
+	MinOccurs string `xml:"minOccurs,attr"`
+	MaxOccurs string `xml:"maxOccurs,attr"`
+}
+
+func (e xsdElement) bounds() (int, int) {
+	lo, hi := 1, 1
+	if e.MinOccurs != "" {
+		lo, _ = strconv.Atoi(e.MinOccurs)
+	}
+	switch e.MaxOccurs {
+	case "":
+	case "unbounded":
+		hi = -1
+	default:
+		hi, _ = strconv.Atoi(e.MaxOccurs)
+	}
+	return lo, hi
+}
+
+type xsdGroup struct {
+	Elements []xsdElement `xml:"element"`
+}
+
+type xsdAttribute struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+	Use  string `xml:"use,attr"`
+}
+
+type xsdComplexType struct {
+	Name          string    `xml:"name,attr"`
+	Sequence      *xsdGroup `xml:"sequence"`
+	Choice        *xsdGroup `xml:"choice"`
+	SimpleContent *struct {
+		Extension struct {
+			Base       string         `xml:"base,attr"`
+			Attributes []xsdAttribute `xml:"attribute"`
+		} `xml:"extension"`
+	} `xml:"simpleContent"`
+}
+
+type xsdFacet struct {
+	Value string `xml:"value,attr"`
+}
+
+type xsdSimpleType struct {
+	Name        string `xml:"name,attr"`
+	Restriction struct {
+		Base           string     `xml:"base,attr"`
+		Enumerations   []xsdFacet `xml:"enumeration"`
+		Patterns       []xsdFacet `xml:"pattern"`
+		MinLength      *xsdFacet  `xml:"minLength"`
+		MaxLength      *xsdFacet  `xml:"maxLength"`
+		FractionDigits *xsdFacet  `xml:"fractionDigits"`
+		TotalDigits    *xsdFacet  `xml:"totalDigits"`
+		MinInclusive   *xsdFacet  `xml:"minInclusive"`
+	} `xml:"restriction"`
+}
+
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []xmlNode  `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+func loadSchema(t *testing.T, name string) *xsdSchema {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s xsdSchema
+	if err := xml.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.TargetNamespace != camt053Namespace {
+		t.Fatalf("schema namespace = %s, want %s", s.TargetNamespace, camt053Namespace)
+	}
+	s.complex = map[string]xsdComplexType{}
+	for _, ct := range s.ComplexTypes {
+		s.complex[ct.Name] = ct
+	}
+	s.simple = map[string]xsdSimpleType{}
+	for _, st := range s.SimpleTypes {
+		s.simple[st.Name] = st
+	}
+	return &s
+}
+
+func (s *xsdSchema) validate(doc []byte) error {
+	var root xmlNode
+	if err := xml.Unmarshal(doc, &root); err != nil {
+		return err
+	}
+	for _, el := range s.Elements {
+		if el.Name == root.XMLName.Local {
+			return s.validateNode(root, el.Type, "/"+el.Name)
+		}
+	}
+	return fmt.Errorf("unexpected root element %s", root.XMLName.Local)
+}
+
+func (s *xsdSchema) validateNode(n xmlNode, typeName, path string) error {
+	if n.XMLName.Space != s.TargetNamespace {
+		return fmt.Errorf("%s: namespace %q, want %q", path, n.XMLName.Space, s.TargetNamespace)
+	}
+
+	if st, ok := s.simple[typeName]; ok {
+		if len(n.Children) > 0 {
+			return fmt.Errorf("%s: simple type %s has child elements", path, typeName)
+		}
+		return s.validateText(n.Text, st, path)
+	}
+
+	ct, ok := s.complex[typeName]
+	if !ok {
+		return fmt.Errorf("%s: unknown type %s", path, typeName)
+	}
+
+	switch {
+	case ct.SimpleContent != nil:
+		ext := ct.SimpleContent.Extension
+		if len(n.Children) > 0 {
+			return fmt.Errorf("%s: simple content has child elements", path)
+		}
+		for _, a := range ext.Attributes {
+			idx := slices.IndexFunc(n.Attrs, func(x xml.Attr) bool { return x.Name.Local == a.Name })
+			if idx < 0 {
+				if a.Use == "required" {
+					return fmt.Errorf("%s: missing attribute %s", path, a.Name)
+				}
+				continue
+			}
+			if err := s.validateText(n.Attrs[idx].Value, s.simple[a.Type], path+"/@"+a.Name); err != nil {
+				return err
+			}
+		}
+		return s.validateText(n.Text, s.simple[ext.Base], path)
+
+	case ct.Choice != nil:
+		if len(n.Children) != 1 {
+			return fmt.Errorf("%s: choice needs exactly one element, got %d", path, len(n.Children))
+		}
+		child := n.Children[0]
+		for _, el := range ct.Choice.Elements {
+			if el.Name == child.XMLName.Local {
+				return s.validateNode(child, el.Type, path+"/"+el.Name)
+			}
+		}
+		return fmt.Errorf("%s: unexpected element %s in choice", path, child.XMLName.Local)
+
+	case ct.Sequence != nil:
+		if strings.TrimSpace(n.Text) != "" {
+			return fmt.Errorf("%s: unexpected text in complex element", path)
+		}
+		i := 0
+		for _, el := range ct.Sequence.Elements {
+			count := 0
+			for i < len(n.Children) && n.Children[i].XMLName.Local == el.Name {
+				if err := s.validateNode(n.Children[i], el.Type, path+"/"+el.Name); err != nil {
+					return err
+				}
+				i++
+				count++
+			}
+			lo, hi := el.bounds()
+			if count < lo {
+				return fmt.Errorf("%s: expected at least %d %s, got %d", path, lo, el.Name, count)
+			}
+			if hi >= 0 && count > hi {
+				return fmt.Errorf("%s: expected at most %d %s, got %d", path, hi, el.Name, count)
+			}
+		}
+		if i < len(n.Children) {
+			return fmt.Errorf("%s: unexpected element %s", path, n.Children[i].XMLName.Local)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s: type %s has no content model", path, typeName)
+}
+
+var xsdDecimal = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]+)?$`)
+
+func (s *xsdSchema) validateText(v string, st xsdSimpleType, path string) error {
+	r := st.Restriction
+	switch r.Base {
+	case "xs:string":
+	case "xs:decimal":
+		if !xsdDecimal.MatchString(v) {
+			return fmt.Errorf("%s: %q is not a decimal", path, v)
+		}
+		whole, frac, _ := strings.Cut(strings.TrimLeft(v, "+-"), ".")
+		if r.FractionDigits != nil {
+			if max, _ := strconv.Atoi(r.FractionDigits.Value); len(frac) > max {
+				return fmt.Errorf("%s: %q has more than %d fraction digits", path, v, max)
+			}
+		}
+		if r.TotalDigits != nil {
+			if max, _ := strconv.Atoi(r.TotalDigits.Value); len(strings.TrimLeft(whole, "0"))+len(frac) > max {
+				return fmt.Errorf("%s: %q has more than %d digits", path, v, max)
+			}
+		}
+		if r.MinInclusive != nil && r.MinInclusive.Value == "0" && strings.HasPrefix(v, "-") {
+			return fmt.Errorf("%s: %q is negative", path, v)
+		}
+	case "xs:date":
+		if _, err := time.Parse(time.DateOnly, v); err != nil {
+			return fmt.Errorf("%s: %q is not a date", path, v)
+		}
+	case "xs:dateTime":
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			return fmt.Errorf("%s: %q is not a date-time", path, v)
+		}
+	case "xs:boolean":
+		if v != "true" && v != "false" {
+			return fmt.Errorf("%s: %q is not a boolean", path, v)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported base type %q for %s", path, r.Base, st.Name)
+	}
+
+	if len(r.Enumerations) > 0 && !slices.ContainsFunc(r.Enumerations, func(f xsdFacet) bool { return f.Value == v }) {
+		return fmt.Errorf("%s: %q is not one of the allowed values", path, v)
+	}
+	for _, p := range r.Patterns {
+		if !regexp.MustCompile(`^(?:` + p.Value + `)$`).MatchString(v) {
+			return fmt.Errorf("%s: %q does not match %s", path, v, p.Value)
+		}
+	}
+	if r.MinLength != nil {
+		if min, _ := strconv.Atoi(r.MinLength.Value); len([]rune(v)) < min {
+			return fmt.Errorf("%s: %q is shorter than %d", path, v, min)
+		}
+	}
+	if r.MaxLength != nil {
+		if max, _ := strconv.Atoi(r.MaxLength.Value); len([]rune(v)) > max {
+			return fmt.Errorf("%s: %q is longer than %d", path, v, max)
+		}
+	}
+	return nil
+}
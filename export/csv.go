@@ -0,0 +1,199 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+// Column identifies a CSV column. Its value is used as the header.
+type Column string
+
+// Columns supported by WriteCSV.
+const (
+	ColumnID                  Column = "id"
+	ColumnCreatedAt           Column = "created_at"
+	ColumnClearedAt           Column = "cleared_at"
+	ColumnStatus              Column = "status"
+	ColumnKind                Column = "kind"
+	ColumnEventStatus         Column = "event_status"
+	ColumnMerchant            Column = "merchant"
+	ColumnCity                Column = "city"
+	ColumnCountry             Column = "country"
+	ColumnMCC                 Column = "mcc"
+	ColumnAmount              Column = "amount"
+	ColumnBillingAmount       Column = "billing_amount"
+	ColumnBillingCurrency     Column = "billing_currency"
+	ColumnTransactionAmount   Column = "transaction_amount"
+	ColumnTransactionCurrency Column = "transaction_currency"
+	ColumnTxHashes            Column = "tx_hashes"
+)
+
+// DefaultColumns is the column set used when CSVOptions.Columns is empty.
+var DefaultColumns = []Column{
+	ColumnCreatedAt,
+	ColumnClearedAt,
+	ColumnStatus,
+	ColumnKind,
+	ColumnMerchant,
+	ColumnCountry,
+	ColumnMCC,
+	ColumnAmount,
+	ColumnBillingCurrency,
+	ColumnTransactionAmount,
+	ColumnTransactionCurrency,
+	ColumnTxHashes,
+}
+
+// CSVOptions configures WriteCSV.
+type CSVOptions struct {
+	// Columns to write, in order. Defaults to DefaultColumns.
+	Columns []Column
+
+	// Comma is the field delimiter. Defaults to ','.
+	Comma rune
+
+	// NoHeader omits the header row.
+	NoHeader bool
+
+	// All also writes the events that did not move money, such as
+	// declined payments, with a zero ColumnAmount. Use ColumnEventStatus
+	// to tell them apart.
+	All bool
+}
+
+// WriteCSV writes events as CSV. Amounts are plain decimals in major
+// units; ColumnAmount is signed from the cardholder's point of view while
+// ColumnBillingAmount and ColumnTransactionAmount are as reported by the API.
+func WriteCSV(w io.Writer, events []gnosispay.CardEvent, opts *CSVOptions) error {
+	if opts == nil {
+		opts = &CSVOptions{}
+	}
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	for _, c := range columns {
+		if _, ok := csvFields[c]; !ok {
+			return fmt.Errorf("unknown CSV column %q", c)
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+
+	if !opts.NoHeader {
+		header := make([]string, len(columns))
+		for i, c := range columns {
+			header[i] = string(c)
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+
+	record := make([]string, len(columns))
+	for i, e := range events {
+		if !opts.All && !moved(e, events) {
+			continue
+		}
+		for j, c := range columns {
+			v, err := csvFields[c](e)
+			if err != nil {
+				return fmt.Errorf("event %d: %s: %w", i, c, err)
+			}
+			record[j] = v
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+var csvFields = map[Column]func(gnosispay.CardEvent) (string, error){
+	ColumnID: func(e gnosispay.CardEvent) (string, error) {
+		return e.ID(), nil
+	},
+	ColumnCreatedAt: func(e gnosispay.CardEvent) (string, error) {
+		return formatTime(e.CreatedAt), nil
+	},
+	ColumnClearedAt: func(e gnosispay.CardEvent) (string, error) {
+		if e.ClearedAt == nil {
+			return "", nil
+		}
+		return formatTime(*e.ClearedAt), nil
+	},
+	ColumnStatus: func(e gnosispay.CardEvent) (string, error) {
+		return status(e), nil
+	},
+	ColumnKind: func(e gnosispay.CardEvent) (string, error) {
+		return string(e.Kind), nil
+	},
+	ColumnEventStatus: func(e gnosispay.CardEvent) (string, error) {
+		return string(e.Status), nil
+	},
+	ColumnMerchant: func(e gnosispay.CardEvent) (string, error) {
+		return merchantName(e), nil
+	},
+	ColumnCity: func(e gnosispay.CardEvent) (string, error) {
+		return merchantCity(e), nil
+	},
+	ColumnCountry: func(e gnosispay.CardEvent) (string, error) {
+		return merchantCountry(e), nil
+	},
+	ColumnMCC: func(e gnosispay.CardEvent) (string, error) {
+		return e.Mcc, nil
+	},
+	ColumnAmount: func(e gnosispay.CardEvent) (string, error) {
+		m, err := e.SignedAmount()
+		if err != nil {
+			return "", err
+		}
+		return m.Decimal(), nil
+	},
+	ColumnBillingAmount: func(e gnosispay.CardEvent) (string, error) {
+		m, err := e.BillingMoney()
+		if err != nil {
+			return "", err
+		}
+		return m.Decimal(), nil
+	},
+	ColumnBillingCurrency: func(e gnosispay.CardEvent) (string, error) {
+		if e.BillingCurrency == nil {
+			return "", nil
+		}
+		return e.BillingCurrency.Code, nil
+	},
+	ColumnTransactionAmount: func(e gnosispay.CardEvent) (string, error) {
+		m, err := e.TransactionMoney()
+		if err != nil {
+			return "", err
+		}
+		return m.Decimal(), nil
+	},
+	ColumnTransactionCurrency: func(e gnosispay.CardEvent) (string, error) {
+		if e.TransactionCurrency == nil {
+			return "", nil
+		}
+		return e.TransactionCurrency.Code, nil
+	},
+	ColumnTxHashes: func(e gnosispay.CardEvent) (string, error) {
+		return strings.Join(txHashes(e), ";"), nil
+	},
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,80 @@
+// Package export writes Gnosis Pay card events in formats understood by
+// accounting software: CSV, OFX 2.x and QIF, and as Beancount or hledger
+// journal entries together with IBAN orders. IBAN orders can also be
+// written as ISO 20022 camt.053 bank statements.
+//
+// Records are written in the order given. Only money that moved is
+// exported: declined and reversed payments are left out, as are reversal
+// events already reflected in the status of their payment (see
+// gnosispay.CardEvent.DoubleCounted), so the exported amounts add up to the
+// account's change. Callers that only want settled spending should drop
+// pending events before exporting. Rejected IBAN orders are left out.
+package export
+
+import (
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+// isCredit reports whether the event moves money back to the cardholder.
+func isCredit(e gnosispay.CardEvent) bool {
+	return e.Kind.IsCredit()
+}
+
+// moved reports whether e moved money and is not a reversal that the
+// status of its payment among events already accounts for.
+func moved(e gnosispay.CardEvent, events []gnosispay.CardEvent) bool {
+	return e.Counts() && !e.DoubleCounted(events)
+}
+
+// postedAt returns the clearing time, falling back to creation time for
+// events that have not cleared yet.
+func postedAt(e gnosispay.CardEvent) time.Time {
+	if e.ClearedAt == nil {
+		return e.CreatedAt
+	}
+	return *e.ClearedAt
+}
+
+func merchantName(e gnosispay.CardEvent) string {
+	if e.Merchant == nil {
+		return ""
+	}
+	return strings.TrimSpace(e.Merchant.Name)
+}
+
+func merchantCity(e gnosispay.CardEvent) string {
+	if e.Merchant == nil {
+		return ""
+	}
+	return strings.TrimSpace(e.Merchant.City)
+}
+
+func merchantCountry(e gnosispay.CardEvent) string {
+	if e.Merchant != nil && e.Merchant.Country != nil && e.Merchant.Country.Alpha2 != "" {
+		return e.Merchant.Country.Alpha2
+	}
+	if e.Country != nil {
+		return e.Country.Alpha2
+	}
+	return ""
+}
+
+func txHashes(e gnosispay.CardEvent) []string {
+	var hashes []string
+	for _, tx := range e.Transactions {
+		if tx.Hash != "" {
+			hashes = append(hashes, tx.Hash)
+		}
+	}
+	return hashes
+}
+
+func status(e gnosispay.CardEvent) string {
+	if e.IsPending {
+		return "pending"
+	}
+	return "cleared"
+}
@@ -0,0 +1,179 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func loadEvents(t *testing.T) []gnosispay.CardEvent {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "events.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []gnosispay.CardEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+// unmoved returns events that moved no money: a declined payment and a
+// reversed payment reported both by its status and by a reversal event.
+// Exports leave them out.
+func unmoved() []gnosispay.CardEvent {
+	eur := &gnosispay.Currency{Symbol: "€", Code: "EUR", Decimals: 2}
+	event := func(at, kind, status, amount string) gnosispay.CardEvent {
+		created, _ := time.Parse(time.RFC3339, at)
+		return gnosispay.CardEvent{
+			CreatedAt:       created,
+			Kind:            gnosispay.CardEventKind(kind),
+			Status:          gnosispay.CardEventStatus(status),
+			Mcc:             "5999",
+			Merchant:        &gnosispay.Merchant{Name: "Kiosk", City: "Berlin"},
+			BillingAmount:   amount,
+			BillingCurrency: eur,
+		}
+	}
+	return []gnosispay.CardEvent{
+		event("2025-01-06T10:00:00Z", "Payment", "InsufficientFunds", "9900"),
+		event("2025-01-06T11:00:00Z", "Payment", "Reversal", "250"),
+		event("2025-01-06T11:05:00Z", "Reversal", "", "250"),
+	}
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch (run go test -update to regenerate)\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	events := append(loadEvents(t), unmoved()...)
+
+	tests := []struct {
+		name   string
+		opts   *CSVOptions
+		golden string
+	}{
+		{
+			name:   "default columns",
+			opts:   nil,
+			golden: "events.csv.golden",
+		},
+		{
+			name: "custom columns and delimiter",
+			opts: &CSVOptions{
+				Columns: []Column{ColumnID, ColumnCreatedAt, ColumnMerchant, ColumnCity, ColumnBillingAmount, ColumnEventStatus},
+				Comma:   ';',
+				All:     true,
+			},
+			golden: "events_custom.csv.golden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteCSV(&buf, events, tt.opts); err != nil {
+				t.Fatalf("WriteCSV() error = %v", err)
+			}
+			checkGolden(t, tt.golden, buf.Bytes())
+		})
+	}
+
+	t.Run("unknown column", func(t *testing.T) {
+		err := WriteCSV(&bytes.Buffer{}, events, &CSVOptions{Columns: []Column{"nope"}})
+		if err == nil {
+			t.Error("WriteCSV() expected error for unknown column")
+		}
+	})
+}
+
+func TestWriteOFX(t *testing.T) {
+	events := append(loadEvents(t), unmoved()...)
+	now := func() time.Time { return time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name   string
+		opts   OFXOptions
+		golden string
+	}{
+		{
+			name: "cleared only",
+			opts: OFXOptions{
+				AccountID:     "4242",
+				Currency:      gnosispay.EUR,
+				LedgerBalance: gnosispay.NewMoney(125000, gnosispay.EUR.Currency()),
+				Now:           now,
+			},
+			golden: "events.ofx.golden",
+		},
+		{
+			name: "with pending and fixed range",
+			opts: OFXOptions{
+				AccountID:      "4242",
+				Currency:       gnosispay.EUR,
+				Start:          time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				End:            time.Date(2025, 1, 31, 23, 59, 59, 0, time.UTC),
+				IncludePending: true,
+				Now:            now,
+			},
+			golden: "events_pending.ofx.golden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteOFX(&buf, events, tt.opts); err != nil {
+				t.Fatalf("WriteOFX() error = %v", err)
+			}
+			checkGolden(t, tt.golden, buf.Bytes())
+		})
+	}
+
+	t.Run("currency mismatch", func(t *testing.T) {
+		err := WriteOFX(&bytes.Buffer{}, events, OFXOptions{AccountID: "4242", Currency: gnosispay.USD, Now: now})
+		if err == nil {
+			t.Error("WriteOFX() expected error for mismatched currency")
+		}
+	})
+
+	t.Run("missing account", func(t *testing.T) {
+		err := WriteOFX(&bytes.Buffer{}, events, OFXOptions{Currency: gnosispay.EUR})
+		if err == nil {
+			t.Error("WriteOFX() expected error for missing account ID")
+		}
+	})
+}
+
+func TestWriteQIF(t *testing.T) {
+	events := append(loadEvents(t), unmoved()...)
+
+	var buf bytes.Buffer
+	if err := WriteQIF(&buf, events, &QIFOptions{AccountName: "Gnosis Pay Card"}); err != nil {
+		t.Fatalf("WriteQIF() error = %v", err)
+	}
+	checkGolden(t, "events.qif.golden", buf.Bytes())
+}
@@ -0,0 +1,392 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+// Rule maps matching records to a ledger account. All non-empty criteria
+// must match. Merchant and Pattern are checked against the merchant name of
+// card events and the counterpart name and memo of IBAN orders.
+type Rule struct {
+	MCC      []string       // Any of these merchant category codes.
+	Merchant string         // Case-insensitive substring of the merchant name.
+	Pattern  *regexp.Regexp // Regular expression.
+	Account  string         // Account to post to when the rule matches.
+}
+
+func (r Rule) match(mcc string, texts ...string) bool {
+	if len(r.MCC) > 0 && !slices.Contains(r.MCC, mcc) {
+		return false
+	}
+	if r.Merchant != "" {
+		want := strings.ToLower(r.Merchant)
+		found := false
+		for _, t := range texts {
+			if strings.Contains(strings.ToLower(t), want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.Pattern != nil {
+		found := false
+		for _, t := range texts {
+			if r.Pattern.MatchString(t) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return len(r.MCC) > 0 || r.Merchant != "" || r.Pattern != nil
+}
+
+// AccountMap decides which accounts ledger entries post to. Rules are
+// tried in order and the first match wins.
+type AccountMap struct {
+	Rules []Rule
+
+	// Safe is the asset account holding the Gnosis Pay Safe balance that
+	// both the card and the IBAN draw from. Defaults to "Assets:GnosisPay".
+	Safe string
+
+	// Expenses receives card events no rule matched.
+	// Defaults to "Expenses:Uncategorized".
+	Expenses string
+
+	// Incoming and Outgoing receive IBAN transfers no rule matched.
+	// They default to "Income:Transfers" and "Expenses:Transfers".
+	Incoming string
+	Outgoing string
+}
+
+func (m *AccountMap) lookup(fallback, mcc string, texts ...string) string {
+	for _, r := range m.Rules {
+		if r.match(mcc, texts...) {
+			return r.Account
+		}
+	}
+	return fallback
+}
+
+func (m *AccountMap) withDefaults() (*AccountMap, error) {
+	out := AccountMap{}
+	if m != nil {
+		out = *m
+	}
+	if out.Safe == "" {
+		out.Safe = "Assets:GnosisPay"
+	}
+	if out.Expenses == "" {
+		out.Expenses = "Expenses:Uncategorized"
+	}
+	if out.Incoming == "" {
+		out.Incoming = "Income:Transfers"
+	}
+	if out.Outgoing == "" {
+		out.Outgoing = "Expenses:Transfers"
+	}
+
+	accounts := []string{out.Safe, out.Expenses, out.Incoming, out.Outgoing}
+	for _, r := range out.Rules {
+		accounts = append(accounts, r.Account)
+	}
+	for _, a := range accounts {
+		if err := validateAccount(a); err != nil {
+			return nil, err
+		}
+	}
+	return &out, nil
+}
+
+// accountRoots are the top-level account types Beancount accepts.
+var accountRoots = []string{"Assets", "Liabilities", "Equity", "Income", "Expenses"}
+
+// accountComponent matches one colon-separated part of a Beancount account
+// name.
+var accountComponent = regexp.MustCompile(`^[\p{Lu}\p{Nd}][\p{L}\p{Nd}-]*$`)
+
+// validateAccount checks a against Beancount's account syntax, which is
+// stricter than hledger's: a known root such as "Expenses", then at least
+// one component starting with a capital letter or digit and containing
+// only letters, digits and dashes.
+func validateAccount(a string) error {
+	if a == "" {
+		return fmt.Errorf("account name cannot be empty")
+	}
+	if strings.IndexFunc(a, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("invalid account %q: must not contain spaces", a)
+	}
+	parts := strings.Split(a, ":")
+	if !slices.Contains(accountRoots, parts[0]) {
+		return fmt.Errorf("invalid account %q: must start with one of %s", a, strings.Join(accountRoots, ", "))
+	}
+	if len(parts) < 2 {
+		return fmt.Errorf("invalid account %q: needs a component below %s", a, parts[0])
+	}
+	for _, p := range parts[1:] {
+		if !accountComponent.MatchString(p) {
+			return fmt.Errorf("invalid account %q: component %q must start with a capital letter or digit and contain only letters, digits and dashes", a, p)
+		}
+	}
+	return nil
+}
+
+// Entry is a single ledger transaction.
+type Entry struct {
+	Date      time.Time
+	Pending   bool
+	Payee     string
+	Narration string
+	Meta      []Meta
+	Postings  []Posting
+}
+
+// Meta is a key/value pair attached to an entry. Keys may repeat.
+type Meta struct {
+	Key   string
+	Value string
+}
+
+// Posting moves Amount into Account. When Price is set the posting is
+// converted at that total price, which is how foreign-currency card
+// payments are recorded.
+type Posting struct {
+	Account string
+	Amount  gnosispay.Money
+	Price   *gnosispay.Money
+}
+
+// CardEventEntries converts card events into ledger entries. Each entry
+// debits the mapped expense account and credits the Safe account in the
+// billing currency; refunds and reversals post the other way round.
+// Payments made in another currency are booked in the transaction
+// currency at the billed total price. Events that moved no money are
+// skipped, as described in the package documentation, so reversals net
+// out the way gnosispay.NetAmounts nets them.
+func CardEventEntries(events []gnosispay.CardEvent, accounts *AccountMap) ([]Entry, error) {
+	m, err := accounts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(events))
+	for i, e := range events {
+		if !moved(e, events) {
+			continue
+		}
+		billed, err := e.SignedAmount()
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		name := merchantName(e)
+
+		expense := Posting{
+			Account: m.lookup(m.Expenses, e.Mcc, name),
+			Amount:  billed.Neg(),
+		}
+		if e.TransactionCurrency != nil && e.TransactionCurrency.Code != billed.Currency.Code {
+			original, err := e.TransactionMoney()
+			if err != nil {
+				return nil, fmt.Errorf("event %d: %w", i, err)
+			}
+			if billed.Sign() > 0 {
+				original = original.Abs().Neg()
+			} else {
+				original = original.Abs()
+			}
+			price := billed.Abs()
+			expense.Amount = original
+			expense.Price = &price
+		}
+
+		entry := Entry{
+			Date:      postedAt(e),
+			Pending:   e.IsPending,
+			Payee:     name,
+			Narration: strings.TrimSpace(string(e.Kind) + " " + strings.TrimSpace(merchantCity(e)+" "+merchantCountry(e))),
+			Meta:      []Meta{{Key: "gnosispay-id", Value: e.ID()}},
+			Postings: []Posting{
+				expense,
+				{Account: m.Safe, Amount: billed},
+			},
+		}
+		if e.Mcc != "" {
+			entry.Meta = append(entry.Meta, Meta{Key: "mcc", Value: e.Mcc})
+		}
+		for _, h := range txHashes(e) {
+			entry.Meta = append(entry.Meta, Meta{Key: "tx", Value: h})
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// IbanOrderEntries converts Monerium IBAN orders into ledger entries.
+// Issued orders (incoming SEPA transfers) credit the Safe account and
+// redeemed orders (outgoing transfers) debit it. Rejected orders are
+// skipped and orders that are not yet processed are marked pending.
+func IbanOrderEntries(orders []gnosispay.IbanOrder, accounts *AccountMap) ([]Entry, error) {
+	m, err := accounts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(orders))
+	for _, o := range orders {
+		if strings.EqualFold(o.State, "rejected") {
+			continue
+		}
+		amount, err := o.Money()
+		if err != nil {
+			return nil, fmt.Errorf("order %s: %w", o.Id, err)
+		}
+		if o.Meta == nil {
+			return nil, fmt.Errorf("order %s: missing placedAt", o.Id)
+		}
+		placed, err := time.Parse(time.RFC3339, o.Meta.PlacedAt)
+		if err != nil {
+			return nil, fmt.Errorf("order %s: invalid placedAt: %w", o.Id, err)
+		}
+
+		var name, iban string
+		if o.Counterpart != nil {
+			name = o.Counterpart.Details.Name
+			iban = o.Counterpart.Identifier.Iban
+		}
+
+		fallback := m.Outgoing
+		amount = amount.Abs()
+		if strings.EqualFold(o.Kind, "issue") {
+			fallback = m.Incoming
+		} else {
+			amount = amount.Neg()
+		}
+
+		entry := Entry{
+			Date:      placed,
+			Pending:   !strings.EqualFold(o.State, "processed"),
+			Payee:     name,
+			Narration: o.Memo,
+			Meta:      []Meta{{Key: "monerium-id", Value: o.Id}},
+			Postings: []Posting{
+				{Account: m.Safe, Amount: amount},
+				{Account: m.lookup(fallback, "", name, o.Memo), Amount: amount.Neg()},
+			},
+		}
+		if iban != "" {
+			entry.Meta = append(entry.Meta, Meta{Key: "iban", Value: iban})
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// WriteBeancount writes entries in Beancount syntax. Metadata keys that
+// repeat get a numeric suffix, since Beancount requires unique keys.
+func WriteBeancount(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	for i, e := range entries {
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		flag := "*"
+		if e.Pending {
+			flag = "!"
+		}
+		fmt.Fprintf(bw, "%s %s %s %s\n", e.Date.UTC().Format(time.DateOnly), flag, beancountString(e.Payee), beancountString(e.Narration))
+
+		seen := map[string]int{}
+		for _, meta := range e.Meta {
+			key := beancountKey(meta.Key)
+			seen[key]++
+			if n := seen[key]; n > 1 {
+				key = fmt.Sprintf("%s-%d", key, n)
+			}
+			fmt.Fprintf(bw, "  %s: %s\n", key, beancountString(meta.Value))
+		}
+		for _, p := range e.Postings {
+			writePosting(bw, p)
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteHledger writes entries in hledger journal syntax. Metadata becomes
+// tags in the transaction comment.
+func WriteHledger(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	for i, e := range entries {
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		flag := "*"
+		if e.Pending {
+			flag = "!"
+		}
+		desc := hledgerText(e.Payee)
+		if n := hledgerText(e.Narration); n != "" {
+			desc += " | " + n
+		}
+		fmt.Fprintf(bw, "%s %s %s", e.Date.UTC().Format(time.DateOnly), flag, desc)
+		if len(e.Meta) > 0 {
+			tags := make([]string, len(e.Meta))
+			for j, meta := range e.Meta {
+				tags[j] = meta.Key + ":" + hledgerText(strings.ReplaceAll(meta.Value, ",", " "))
+			}
+			fmt.Fprintf(bw, "  ; %s", strings.Join(tags, ", "))
+		}
+		bw.WriteString("\n")
+		for _, p := range e.Postings {
+			writePosting(bw, p)
+		}
+	}
+	return bw.Flush()
+}
+
+// writePosting writes a posting line. Both formats share the syntax:
+// two spaces separate the account from the amount.
+func writePosting(w *bufio.Writer, p Posting) {
+	fmt.Fprintf(w, "  %-40s  %s %s", p.Account, p.Amount.Decimal(), p.Amount.Currency.Code)
+	if p.Price != nil {
+		fmt.Fprintf(w, " @@ %s %s", p.Price.Decimal(), p.Price.Currency.Code)
+	}
+	w.WriteString("\n")
+}
+
+func beancountString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.Join(strings.Fields(s), " ") + `"`
+}
+
+func beancountKey(k string) string {
+	k = strings.ToLower(k)
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, k)
+}
+
+// hledgerText strips characters that would end the description or start
+// a comment.
+func hledgerText(s string) string {
+	s = strings.NewReplacer("|", "/", ";", ",").Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
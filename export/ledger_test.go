@@ -0,0 +1,121 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+func loadOrders(t *testing.T) []gnosispay.IbanOrder {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "orders.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orders []gnosispay.IbanOrder
+	if err := json.Unmarshal(data, &orders); err != nil {
+		t.Fatal(err)
+	}
+	return orders
+}
+
+var testAccounts = &AccountMap{
+	Rules: []Rule{
+		{MCC: []string{"5411"}, Account: "Expenses:Groceries"},
+		{Merchant: "dishoom", Account: "Expenses:Dining"},
+		{Pattern: regexp.MustCompile(`(?i)taxi|uber`), Account: "Expenses:Transport"},
+		{Pattern: regexp.MustCompile(`^Salary`), Account: "Income:Salary"},
+		{Merchant: "Hausverwaltung", Account: "Expenses:Rent"},
+	},
+	Safe: "Assets:GnosisPay:EURe",
+}
+
+func ledgerEntries(t *testing.T) []Entry {
+	t.Helper()
+	orders, err := IbanOrderEntries(loadOrders(t), testAccounts)
+	if err != nil {
+		t.Fatalf("IbanOrderEntries() error = %v", err)
+	}
+	events, err := CardEventEntries(append(loadEvents(t), unmoved()...), testAccounts)
+	if err != nil {
+		t.Fatalf("CardEventEntries() error = %v", err)
+	}
+	return append(orders, events...)
+}
+
+func TestWriteBeancount(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteBeancount(&buf, ledgerEntries(t)); err != nil {
+		t.Fatalf("WriteBeancount() error = %v", err)
+	}
+	checkGolden(t, "ledger.beancount.golden", buf.Bytes())
+}
+
+func TestWriteHledger(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHledger(&buf, ledgerEntries(t)); err != nil {
+		t.Fatalf("WriteHledger() error = %v", err)
+	}
+	checkGolden(t, "ledger.journal.golden", buf.Bytes())
+}
+
+func TestCardEventEntries_Balanced(t *testing.T) {
+	entries, err := CardEventEntries(loadEvents(t), nil)
+	if err != nil {
+		t.Fatalf("CardEventEntries() error = %v", err)
+	}
+
+	for _, e := range entries {
+		var sum gnosispay.Money
+		for i, p := range e.Postings {
+			weight := p.Amount
+			if p.Price != nil {
+				weight = *p.Price
+				if p.Amount.Sign() < 0 {
+					weight = weight.Neg()
+				}
+			}
+			if i == 0 {
+				sum = weight
+				continue
+			}
+			if sum, err = sum.Add(weight); err != nil {
+				t.Fatalf("entry %q: %v", e.Payee, err)
+			}
+		}
+		if !sum.IsZero() {
+			t.Errorf("entry %q does not balance: %v", e.Payee, sum)
+		}
+		if e.Postings[0].Account != "Expenses:Uncategorized" {
+			t.Errorf("entry %q posted to %s, want default expense account", e.Payee, e.Postings[0].Account)
+		}
+	}
+}
+
+func TestAccountMap_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"spaces":          "Expenses:Food and Drink",
+		"unknown root":    "Spending:Food",
+		"lowercase root":  "expenses:Food",
+		"lowercase":       "Expenses:food",
+		"root only":       "Expenses",
+		"empty component": "Expenses::Food",
+		"punctuation":     "Expenses:Food&Drink",
+	}
+	for name, account := range tests {
+		_, err := CardEventEntries(nil, &AccountMap{Rules: []Rule{{MCC: []string{"5411"}, Account: account}}})
+		if err == nil {
+			t.Errorf("%s: CardEventEntries() accepted account %q", name, account)
+		}
+	}
+	for _, account := range []string{"Expenses:Food-Drink", "Assets:GnosisPay:EURe", "Liabilities:2025", "Expenses:Café"} {
+		if _, err := CardEventEntries(nil, &AccountMap{Safe: account}); err != nil {
+			t.Errorf("CardEventEntries() rejected account %q: %v", account, err)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+// OFXOptions configures WriteOFX.
+type OFXOptions struct {
+	// AccountID identifies the card account, e.g. the card's last four
+	// digits or the Safe address. Required.
+	AccountID string
+
+	// Currency is the statement's default currency. Events billed in any
+	// other currency are rejected. Required.
+	Currency gnosispay.CurrencyCode
+
+	// Start and End bound the statement. When zero they are derived from
+	// the events.
+	Start, End time.Time
+
+	// LedgerBalance is the closing balance reported on the statement.
+	LedgerBalance gnosispay.Money
+
+	// IncludePending adds pending events. OFX has no notion of pending
+	// transactions, so most importers will book them as cleared.
+	IncludePending bool
+
+	// Now returns the server time written to the signon response.
+	// Defaults to time.Now.
+	Now func() time.Time
+}
+
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	Signon  struct {
+		Response struct {
+			Status   ofxStatus `xml:"STATUS"`
+			DTServer string    `xml:"DTSERVER"`
+			Language string    `xml:"LANGUAGE"`
+		} `xml:"SONRS"`
+	} `xml:"SIGNONMSGSRSV1"`
+	CreditCard struct {
+		Response struct {
+			TrnUID    string    `xml:"TRNUID"`
+			Status    ofxStatus `xml:"STATUS"`
+			Statement struct {
+				CurDef  string `xml:"CURDEF"`
+				Account struct {
+					AcctID string `xml:"ACCTID"`
+				} `xml:"CCACCTFROM"`
+				TranList struct {
+					DTStart      string       `xml:"DTSTART"`
+					DTEnd        string       `xml:"DTEND"`
+					Transactions []ofxStmtTrn `xml:"STMTTRN"`
+				} `xml:"BANKTRANLIST"`
+				LedgerBal struct {
+					BalAmt string `xml:"BALAMT"`
+					DTAsOf string `xml:"DTASOF"`
+				} `xml:"LEDGERBAL"`
+			} `xml:"CCSTMTRS"`
+		} `xml:"CCSTMTTRNRS"`
+	} `xml:"CREDITCARDMSGSRSV1"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxStmtTrn struct {
+	TrnType      string           `xml:"TRNTYPE"`
+	DTPosted     string           `xml:"DTPOSTED"`
+	DTUser       string           `xml:"DTUSER"`
+	TrnAmt       string           `xml:"TRNAMT"`
+	FitID        string           `xml:"FITID"`
+	SIC          string           `xml:"SIC,omitempty"`
+	Name         string           `xml:"NAME,omitempty"`
+	Memo         string           `xml:"MEMO,omitempty"`
+	OrigCurrency *ofxOrigCurrency `xml:"ORIGCURRENCY,omitempty"`
+}
+
+type ofxOrigCurrency struct {
+	CurRate string `xml:"CURRATE"`
+	CurSym  string `xml:"CURSYM"`
+}
+
+// WriteOFX writes events as an OFX 2.1.1 credit card statement.
+//
+// The merchant becomes the payee NAME, the MCC is written as SIC, and the
+// MEMO carries the merchant location, the event status and the Gnosis Chain
+// transaction hashes. Events charged in a foreign currency get an
+// ORIGCURRENCY aggregate with the effective conversion rate.
+func WriteOFX(w io.Writer, events []gnosispay.CardEvent, opts OFXOptions) error {
+	if opts.AccountID == "" {
+		return fmt.Errorf("account ID cannot be empty")
+	}
+	if err := opts.Currency.Validate(); err != nil {
+		return err
+	}
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+
+	var doc ofxDocument
+	doc.Signon.Response.Status = ofxStatus{Code: 0, Severity: "INFO"}
+	doc.Signon.Response.DTServer = ofxTime(now())
+	doc.Signon.Response.Language = "ENG"
+
+	rs := &doc.CreditCard.Response
+	rs.TrnUID = "0"
+	rs.Status = ofxStatus{Code: 0, Severity: "INFO"}
+	rs.Statement.CurDef = opts.Currency.String()
+	rs.Statement.Account.AcctID = opts.AccountID
+
+	start, end := opts.Start, opts.End
+	for i, e := range events {
+		if (e.IsPending && !opts.IncludePending) || !moved(e, events) {
+			continue
+		}
+		trn, err := ofxTransaction(e, opts.Currency)
+		if err != nil {
+			return fmt.Errorf("event %d: %w", i, err)
+		}
+		rs.Statement.TranList.Transactions = append(rs.Statement.TranList.Transactions, trn)
+
+		posted := postedAt(e)
+		if opts.Start.IsZero() && (start.IsZero() || posted.Before(start)) {
+			start = posted
+		}
+		if opts.End.IsZero() && posted.After(end) {
+			end = posted
+		}
+	}
+	if start.IsZero() {
+		start = now()
+	}
+	if end.IsZero() {
+		end = now()
+	}
+	rs.Statement.TranList.DTStart = ofxTime(start)
+	rs.Statement.TranList.DTEnd = ofxTime(end)
+
+	balance := opts.LedgerBalance
+	if balance.Currency.Code == "" {
+		balance.Currency = opts.Currency.Currency()
+	}
+	rs.Statement.LedgerBal.BalAmt = balance.Decimal()
+	rs.Statement.LedgerBal.DTAsOf = ofxTime(end)
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func ofxTransaction(e gnosispay.CardEvent, currency gnosispay.CurrencyCode) (ofxStmtTrn, error) {
+	amount, err := e.SignedAmount()
+	if err != nil {
+		return ofxStmtTrn{}, err
+	}
+	if amount.Currency.Code != currency.String() {
+		return ofxStmtTrn{}, fmt.Errorf("billing currency %s does not match statement currency %s",
+			amount.Currency.Code, currency)
+	}
+
+	trn := ofxStmtTrn{
+		TrnType:  "DEBIT",
+		DTPosted: ofxTime(postedAt(e)),
+		DTUser:   ofxTime(e.CreatedAt),
+		TrnAmt:   amount.Decimal(),
+		FitID:    e.ID(),
+		SIC:      e.Mcc,
+		Name:     truncate(merchantName(e), 32),
+		Memo:     truncate(memo(e), 255),
+	}
+	if isCredit(e) {
+		trn.TrnType = "CREDIT"
+	}
+
+	if e.TransactionCurrency != nil && e.TransactionCurrency.Code != amount.Currency.Code {
+		rate, err := e.ConversionRate()
+		if err != nil {
+			return ofxStmtTrn{}, err
+		}
+		trn.OrigCurrency = &ofxOrigCurrency{CurRate: rate.FloatString(8), CurSym: e.TransactionCurrency.Code}
+	}
+	return trn, nil
+}
+
+// memo summarises the details that do not have a dedicated OFX or QIF field.
+func memo(e gnosispay.CardEvent) string {
+	var parts []string
+	if loc := strings.TrimSpace(merchantCity(e) + " " + merchantCountry(e)); loc != "" {
+		parts = append(parts, loc)
+	}
+	if e.Mcc != "" {
+		parts = append(parts, "MCC "+e.Mcc)
+	}
+	if e.TransactionCurrency != nil && e.BillingCurrency != nil && e.TransactionCurrency.Code != e.BillingCurrency.Code {
+		if m, err := e.TransactionMoney(); err == nil {
+			parts = append(parts, m.String())
+		}
+	}
+	if e.IsPending {
+		parts = append(parts, "pending")
+	}
+	if hashes := txHashes(e); len(hashes) > 0 {
+		parts = append(parts, "tx "+strings.Join(hashes, ","))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+// QIFOptions configures WriteQIF.
+type QIFOptions struct {
+	// AccountName, when set, prefixes the output with an account header so
+	// importers file the transactions under that account.
+	AccountName string
+
+	// DateFormat is the Go layout used for the D field. Defaults to
+	// "01/02/2006", the US ordering most importers expect.
+	DateFormat string
+}
+
+// WriteQIF writes events as a QIF credit card register.
+//
+// Cleared events are flagged with "C*"; pending events carry no cleared
+// flag so they show up as uncleared in the register. The MCC is written as
+// the category and the memo carries location and transaction hashes.
+func WriteQIF(w io.Writer, events []gnosispay.CardEvent, opts *QIFOptions) error {
+	if opts == nil {
+		opts = &QIFOptions{}
+	}
+	layout := opts.DateFormat
+	if layout == "" {
+		layout = "01/02/2006"
+	}
+
+	bw := bufio.NewWriter(w)
+	if opts.AccountName != "" {
+		fmt.Fprintf(bw, "!Account\nN%s\nTCCard\n^\n", qifText(opts.AccountName))
+	}
+	fmt.Fprint(bw, "!Type:CCard\n")
+
+	for i, e := range events {
+		if !moved(e, events) {
+			continue
+		}
+		amount, err := e.SignedAmount()
+		if err != nil {
+			return fmt.Errorf("event %d: %w", i, err)
+		}
+
+		fmt.Fprintf(bw, "D%s\n", postedAt(e).UTC().Format(layout))
+		fmt.Fprintf(bw, "T%s\n", amount.Decimal())
+		if !e.IsPending {
+			fmt.Fprint(bw, "C*\n")
+		}
+		fmt.Fprintf(bw, "N%s\n", e.ID()[:12])
+		if name := merchantName(e); name != "" {
+			fmt.Fprintf(bw, "P%s\n", qifText(name))
+		}
+		if m := memo(e); m != "" {
+			fmt.Fprintf(bw, "M%s\n", qifText(m))
+		}
+		if e.Mcc != "" {
+			fmt.Fprintf(bw, "LMCC %s\n", e.Mcc)
+		}
+		fmt.Fprint(bw, "^\n")
+	}
+
+	return bw.Flush()
+}
+
+// qifText strips line breaks, which would start a new QIF field.
+func qifText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
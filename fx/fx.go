@@ -0,0 +1,191 @@
+// Package fx analyses the currency conversion of card events paid in a
+// foreign currency.
+//
+// Every card event carries the amount the merchant charged (the
+// transaction amount) and the amount booked to the card (the billing
+// amount). Their ratio, [gnosispay.CardEvent.ConversionRate], is the
+// effective rate the cardholder got. Compared against a table of reference
+// rates, such as the ECB's, it reveals the markup paid on each payment and
+// in total. The same table converts
+// totals into a single reporting currency.
+//
+// Only approved payments are analysed for markups; declined and reversed
+// payments, refunds and reversals are not.
+package fx
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+// Foreign reports whether the event was charged in a currency other than
+// the one it was billed in.
+func Foreign(e gnosispay.CardEvent) bool {
+	return e.BillingCurrency != nil && e.TransactionCurrency != nil &&
+		e.BillingCurrency.Code != "" && e.TransactionCurrency.Code != "" &&
+		e.BillingCurrency.Code != e.TransactionCurrency.Code
+}
+
+// Markup compares the conversion of one payment to the reference rate.
+type Markup struct {
+	Event gnosispay.CardEvent
+
+	// Effective and Reference are billing units per transaction unit.
+	Effective *big.Rat
+	Reference *big.Rat
+
+	// Billed is the amount booked to the card, AtReference what the
+	// transaction amount comes to at the reference rate, both positive
+	// and in the billing currency.
+	Billed      gnosispay.Money
+	AtReference gnosispay.Money
+
+	// Markup is Billed minus AtReference: what the conversion cost beyond
+	// the reference rate. It is negative when the cardholder got a better
+	// rate.
+	Markup gnosispay.Money
+}
+
+// Percent returns the markup relative to the reference rate, e.g. 1.5 for
+// an effective rate 1.5% worse than the reference. It returns 0 when
+// either rate is missing or zero.
+func (m Markup) Percent() float64 {
+	if m.Effective == nil || m.Reference == nil || m.Reference.Sign() == 0 {
+		return 0
+	}
+	p, _ := new(big.Rat).Quo(m.Effective, m.Reference).Float64()
+	return (p - 1) * 100
+}
+
+// Summary totals the markups of the payments billed in one currency.
+type Summary struct {
+	Currency    string
+	Count       int
+	Billed      gnosispay.Money
+	AtReference gnosispay.Money
+	Markup      gnosispay.Money
+}
+
+// Percent returns the total markup relative to the amounts at the
+// reference rate, which weighs each payment by its size.
+func (s Summary) Percent() float64 {
+	if s.AtReference.IsZero() {
+		return 0
+	}
+	p, _ := new(big.Rat).SetFrac(s.Markup.MinorUnits(), s.AtReference.MinorUnits()).Float64()
+	return p * 100
+}
+
+// Report is the result of Analyze.
+type Report struct {
+	Markups   []Markup
+	Summaries []Summary // Ordered by currency.
+
+	// Foreign payments left out of the report.
+	Unrated int // No reference rate for the day.
+	Invalid int // Missing currency or bad amount.
+}
+
+// Analyze computes the markup of every approved foreign payment in events
+// against the reference rates on the day each was created.
+func Analyze(events []gnosispay.CardEvent, rates *Rates) (*Report, error) {
+	r := &Report{}
+	byCurrency := map[string]int{}
+	for _, e := range events {
+		if e.Kind != gnosispay.CardEventPayment || !e.Counts() || !Foreign(e) {
+			continue
+		}
+		m, err := markup(e, rates)
+		if errors.Is(err, ErrNoRate) {
+			r.Unrated++
+			continue
+		}
+		if err != nil {
+			r.Invalid++
+			continue
+		}
+		r.Markups = append(r.Markups, m)
+
+		i, ok := byCurrency[m.Billed.Currency.Code]
+		if !ok {
+			zero := gnosispay.NewMoney(0, m.Billed.Currency)
+			r.Summaries = append(r.Summaries, Summary{
+				Currency: m.Billed.Currency.Code, Billed: zero, AtReference: zero, Markup: zero,
+			})
+			i = len(r.Summaries) - 1
+			byCurrency[m.Billed.Currency.Code] = i
+		}
+		s := &r.Summaries[i]
+		s.Count++
+		if s.Billed, err = s.Billed.Add(m.Billed); err != nil {
+			return nil, err
+		}
+		if s.AtReference, err = s.AtReference.Add(m.AtReference); err != nil {
+			return nil, err
+		}
+		if s.Markup, err = s.Markup.Add(m.Markup); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(r.Summaries, func(i, j int) bool { return r.Summaries[i].Currency < r.Summaries[j].Currency })
+	return r, nil
+}
+
+func markup(e gnosispay.CardEvent, rates *Rates) (Markup, error) {
+	effective, err := e.ConversionRate()
+	if err != nil {
+		return Markup{}, err
+	}
+	billing, _ := e.BillingMoney()
+	original, _ := e.TransactionMoney()
+	reference, err := rates.Rate(e.CreatedAt,
+		gnosispay.CurrencyCode(original.Currency.Code), gnosispay.CurrencyCode(billing.Currency.Code))
+	if err != nil {
+		return Markup{}, err
+	}
+
+	atReference := convert(original.Abs(), billing.Currency, reference)
+	diff, err := billing.Abs().Sub(atReference)
+	if err != nil {
+		return Markup{}, err
+	}
+	return Markup{
+		Event:       e,
+		Effective:   effective,
+		Reference:   reference,
+		Billed:      billing.Abs(),
+		AtReference: atReference,
+		Markup:      diff,
+	}, nil
+}
+
+// Net converts the SignedAmount of every event into currency to at the
+// reference rate on the day it was created, and sums them. Reversals that
+// gnosispay.CardEvent.DoubleCounted flags are skipped, as in
+// gnosispay.NetAmounts.
+func (r *Rates) Net(events []gnosispay.CardEvent, to gnosispay.Currency) (gnosispay.Money, error) {
+	total := gnosispay.NewMoney(0, to)
+	for _, e := range events {
+		if e.DoubleCounted(events) {
+			continue
+		}
+		m, err := e.SignedAmount()
+		if err != nil {
+			return gnosispay.Money{}, err
+		}
+		if m.IsZero() {
+			continue
+		}
+		converted, err := r.Convert(m, to, e.CreatedAt)
+		if err != nil {
+			return gnosispay.Money{}, err
+		}
+		if total, err = total.Add(converted); err != nil {
+			return gnosispay.Money{}, err
+		}
+	}
+	return total, nil
+}
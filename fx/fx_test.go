@@ -0,0 +1,221 @@
+package fx
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/internal/eventtest"
+)
+
+func day(d int) time.Time {
+	return time.Date(2025, 1, d, 14, 0, 0, 0, time.UTC)
+}
+
+func testRates(t *testing.T) *Rates {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "eurofxref.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rates, err := ParseECB(f)
+	if err != nil {
+		t.Fatalf("ParseECB() error = %v", err)
+	}
+	return rates
+}
+
+func TestRates_Rate(t *testing.T) {
+	rates := testRates(t)
+	tests := []struct {
+		name     string
+		day      time.Time
+		from, to gnosispay.CurrencyCode
+		want     string
+		wantErr  error
+	}{
+		{"per euro", day(3), gnosispay.EUR, gnosispay.USD, "1.04000000", nil},
+		{"inverse", day(3), gnosispay.USD, gnosispay.EUR, "0.96153846", nil},
+		{"crossed", day(3), gnosispay.USD, gnosispay.GBP, "0.79807692", nil},
+		{"weekend uses Friday", day(5), gnosispay.EUR, gnosispay.USD, "1.04000000", nil},
+		{"N/A uses the day before", day(3), gnosispay.EUR, "CHF", "0.94000000", nil},
+		{"same currency", day(20), gnosispay.USD, gnosispay.USD, "1.00000000", nil},
+		{"too old", day(20), gnosispay.EUR, gnosispay.USD, "", ErrNoRate},
+		{"before the table", day(1), gnosispay.EUR, gnosispay.USD, "", ErrNoRate},
+		{"unknown currency", day(3), gnosispay.EUR, "XAU", "", ErrNoRate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rates.Rate(tt.day, tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Rate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.FloatString(8) != tt.want {
+				t.Errorf("Rate() = %s, want %s", got.FloatString(8), tt.want)
+			}
+		})
+	}
+}
+
+func TestRates_Set(t *testing.T) {
+	// A literal works as well as NewRates.
+	rates := &Rates{Base: gnosispay.EUR}
+	for _, bad := range []*big.Rat{nil, new(big.Rat), big.NewRat(-1, 1)} {
+		if err := rates.Set(day(3), gnosispay.USD, bad); err == nil {
+			t.Errorf("Set(%v) succeeded", bad)
+		}
+	}
+	if err := rates.Set(day(3), gnosispay.USD, big.NewRat(104, 100)); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, err := rates.Rate(day(3), gnosispay.USD, gnosispay.EUR); err != nil || got.FloatString(4) != "0.9615" {
+		t.Errorf("Rate() = %v, %v", got, err)
+	}
+	if p := (Markup{Effective: big.NewRat(1, 1), Reference: new(big.Rat)}).Percent(); p != 0 {
+		t.Errorf("Percent() with a zero reference = %v, want 0", p)
+	}
+}
+
+func TestParseECB_Errors(t *testing.T) {
+	tests := []struct {
+		name, csv, want string
+	}{
+		{"empty", "", "reading ECB header"},
+		{"no date column", "USD, GBP\n1.04, 0.83\n", "must start with Date"},
+		{"bad date", "Date, USD\n3 Jan, 1.04\n", "line 2: invalid date"},
+		{"bad rate", "Date, USD\n2025-01-03, abc\n", `invalid USD rate "abc"`},
+		{"negative rate", "Date, USD\n2025-01-03, -1\n", "invalid USD rate"},
+		{"zero rate", "Date, USD\n2025-01-03, 0\n", "invalid USD rate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseECB(strings.NewReader(tt.csv))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseECB() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseECB_DailyFile(t *testing.T) {
+	rates, err := ParseECB(strings.NewReader("Date, USD, \n03 January 2025, 1.0400, \n"))
+	if err != nil {
+		t.Fatalf("ParseECB() error = %v", err)
+	}
+	got, err := rates.Rate(day(3), gnosispay.EUR, gnosispay.USD)
+	if err != nil || got.Cmp(big.NewRat(104, 100)) != 0 {
+		t.Errorf("Rate() = %v, %v, want 1.04", got, err)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	events := []gnosispay.CardEvent{
+		// 10.00 USD at 1.04 is 9.62 EUR; 9.85 EUR was billed.
+		eventtest.Payment(day(3), "985", eventtest.EUR, eventtest.Transaction("1000", eventtest.USD)),
+		// 1,000 JPY at 162.00 is 6.17 EUR; 6.30 EUR was billed.
+		eventtest.Payment(day(5), "630", eventtest.EUR, eventtest.Transaction("1000", eventtest.JPY)),
+		// Billed in pounds: 20.00 USD at 0.8300/1.0400 is 15.96 GBP.
+		eventtest.Payment(day(3), "1600", eventtest.GBP, eventtest.Transaction("2000", eventtest.USD)),
+		// Not analysed.
+		eventtest.Payment(day(3), "500", eventtest.EUR, eventtest.Transaction("500", eventtest.EUR)),
+		eventtest.Payment(day(3), "985", eventtest.EUR, eventtest.Status(gnosispay.CardEventInsufficientFunds), eventtest.Transaction("1000", eventtest.USD)),
+		eventtest.Payment(day(3), "985", eventtest.EUR, eventtest.Status(gnosispay.CardEventReversed), eventtest.Transaction("1000", eventtest.USD)),
+		eventtest.Refund(day(3), "985", eventtest.EUR, eventtest.Transaction("1000", eventtest.USD)),
+		// Skipped and counted.
+		eventtest.Payment(day(20), "985", eventtest.EUR, eventtest.Transaction("1000", eventtest.USD)),
+		eventtest.Payment(day(3), "985", eventtest.EUR, eventtest.Transaction("", eventtest.USD)),
+	}
+
+	r, err := Analyze(events, testRates(t))
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if r.Unrated != 1 || r.Invalid != 1 {
+		t.Errorf("Analyze() unrated, invalid = %d, %d, want 1, 1", r.Unrated, r.Invalid)
+	}
+
+	wantMarkups := []struct {
+		atReference, markup, percent string
+	}{
+		{"9.62 EUR", "0.23 EUR", "2.44"},
+		{"6.17 EUR", "0.13 EUR", "2.06"},
+		{"15.96 GBP", "0.04 GBP", "0.24"},
+	}
+	if len(r.Markups) != len(wantMarkups) {
+		t.Fatalf("Analyze() got %d markups, want %d", len(r.Markups), len(wantMarkups))
+	}
+	for i, want := range wantMarkups {
+		m := r.Markups[i]
+		if m.AtReference.String() != want.atReference || m.Markup.String() != want.markup {
+			t.Errorf("markup %d = %s at reference, %s markup; want %s, %s", i, m.AtReference, m.Markup, want.atReference, want.markup)
+		}
+		if got := big.NewRat(0, 1).SetFloat64(m.Percent()).FloatString(2); got != want.percent {
+			t.Errorf("markup %d percent = %s, want %s", i, got, want.percent)
+		}
+	}
+
+	wantSummaries := []struct {
+		currency                    string
+		count                       int
+		billed, atReference, markup string
+	}{
+		{"EUR", 2, "16.15 EUR", "15.79 EUR", "0.36 EUR"},
+		{"GBP", 1, "16.00 GBP", "15.96 GBP", "0.04 GBP"},
+	}
+	if len(r.Summaries) != len(wantSummaries) {
+		t.Fatalf("Analyze() got %d summaries, want %d", len(r.Summaries), len(wantSummaries))
+	}
+	for i, want := range wantSummaries {
+		s := r.Summaries[i]
+		if s.Currency != want.currency || s.Count != want.count || s.Billed.String() != want.billed ||
+			s.AtReference.String() != want.atReference || s.Markup.String() != want.markup {
+			t.Errorf("summary %d = %s %d %s %s %s, want %+v", i, s.Currency, s.Count, s.Billed, s.AtReference, s.Markup, want)
+		}
+	}
+	if got := big.NewRat(0, 1).SetFloat64(r.Summaries[0].Percent()).FloatString(2); got != "2.28" {
+		t.Errorf("EUR summary percent = %s, want 2.28", got)
+	}
+}
+
+func TestRates_Net(t *testing.T) {
+	rates := testRates(t)
+	events := []gnosispay.CardEvent{
+		eventtest.Payment(day(2), "2000", eventtest.EUR, eventtest.Transaction("2000", eventtest.EUR)),
+		// 8.30 GBP on Friday is 10.00 EUR.
+		eventtest.Payment(day(3), "830", eventtest.GBP, eventtest.Transaction("830", eventtest.GBP)),
+		eventtest.Refund(day(3), "415", eventtest.GBP, eventtest.Transaction("415", eventtest.GBP)),
+		// Reversed by status and event: neither counts.
+		eventtest.Payment(day(3), "961", eventtest.EUR, eventtest.Status(gnosispay.CardEventReversed), eventtest.Transaction("1000", eventtest.USD), eventtest.Merchant("Shop")),
+		eventtest.Reversal(day(3), "961", eventtest.EUR, eventtest.Transaction("1000", eventtest.USD), eventtest.Merchant("Shop")),
+	}
+
+	got, err := rates.Net(events, *eventtest.EUR)
+	if err != nil {
+		t.Fatalf("Net() error = %v", err)
+	}
+	if got.String() != "-25.00 EUR" {
+		t.Errorf("Net() = %s, want -25.00 EUR", got)
+	}
+
+	got, err = rates.Net(events, *eventtest.USD)
+	if err != nil {
+		t.Fatalf("Net() error = %v", err)
+	}
+	// -20.00 EUR at 1.0350 plus -4.15 GBP at 1.0400/0.8300.
+	if got.String() != "-25.90 USD" {
+		t.Errorf("Net() = %s, want -25.90 USD", got)
+	}
+
+	events = append(events, eventtest.Payment(day(20), "96", eventtest.EUR, eventtest.Transaction("100", eventtest.USD)))
+	if _, err := rates.Net(events, *eventtest.USD); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Net() error = %v, want ErrNoRate", err)
+	}
+}
@@ -0,0 +1,197 @@
+package fx
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+// defaultMaxAge is how far back Rates looks for a published rate. Reference
+// rates are not published on weekends and holidays.
+const defaultMaxAge = 7 * 24 * time.Hour
+
+const dayLayout = "2006-01-02"
+
+// ErrNoRate is returned when the table has no rate for a currency on or
+// shortly before the requested day.
+var ErrNoRate = errors.New("fx: no reference rate")
+
+// Rates is a table of daily reference rates, each quoted as units of a
+// currency per one unit of the base currency, as the ECB publishes them.
+// Rates between two other currencies are crossed through the base.
+//
+// A Rates is not safe for concurrent modification.
+type Rates struct {
+	Base gnosispay.CurrencyCode
+
+	// MaxAge is how far before the requested day a rate may have been
+	// published. Defaults to seven days.
+	MaxAge time.Duration
+
+	days map[string]map[gnosispay.CurrencyCode]*big.Rat
+}
+
+// NewRates returns an empty table quoted against base.
+func NewRates(base gnosispay.CurrencyCode) *Rates {
+	return &Rates{Base: base, days: map[string]map[gnosispay.CurrencyCode]*big.Rat{}}
+}
+
+// Set records that one unit of the base currency was worth perBase units
+// of code on day. The rate must be positive.
+func (r *Rates) Set(day time.Time, code gnosispay.CurrencyCode, perBase *big.Rat) error {
+	if perBase == nil || perBase.Sign() <= 0 {
+		return fmt.Errorf("fx: %s rate on %s must be positive", code, day.Format(dayLayout))
+	}
+	if r.days == nil {
+		r.days = map[string]map[gnosispay.CurrencyCode]*big.Rat{}
+	}
+	key := day.Format(dayLayout)
+	if r.days[key] == nil {
+		r.days[key] = map[gnosispay.CurrencyCode]*big.Rat{}
+	}
+	r.days[key][code] = new(big.Rat).Set(perBase)
+	return nil
+}
+
+// perBase returns the latest rate of code published on or before day,
+// within MaxAge.
+func (r *Rates) perBase(day time.Time, code gnosispay.CurrencyCode) (*big.Rat, error) {
+	if code == r.Base {
+		return big.NewRat(1, 1), nil
+	}
+	maxAge := r.MaxAge
+	if maxAge <= 0 {
+		maxAge = defaultMaxAge
+	}
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	for d := day; !d.Before(day.Add(-maxAge)); d = d.AddDate(0, 0, -1) {
+		if rate, ok := r.days[d.Format(dayLayout)][code]; ok {
+			return rate, nil
+		}
+	}
+	return nil, fmt.Errorf("%w for %s on %s", ErrNoRate, code, day.Format(dayLayout))
+}
+
+// Rate returns the reference rate on day as units of to per unit of from.
+// The day is taken in its own location, so pass event times in the zone
+// the reference rates are fixed in when it matters.
+func (r *Rates) Rate(day time.Time, from, to gnosispay.CurrencyCode) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	fromRate, err := r.perBase(day, from)
+	if err != nil {
+		return nil, err
+	}
+	toRate, err := r.perBase(day, to)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
+
+// Convert converts m into currency to at the reference rate on day,
+// rounding half away from zero to the currency's decimals.
+func (r *Rates) Convert(m gnosispay.Money, to gnosispay.Currency, day time.Time) (gnosispay.Money, error) {
+	rate, err := r.Rate(day, gnosispay.CurrencyCode(m.Currency.Code), gnosispay.CurrencyCode(to.Code))
+	if err != nil {
+		return gnosispay.Money{}, err
+	}
+	return convert(m, to, rate), nil
+}
+
+// convert multiplies m by rate, given in major units of to per major unit
+// of m's currency.
+func convert(m gnosispay.Money, to gnosispay.Currency, rate *big.Rat) gnosispay.Money {
+	v := new(big.Rat).SetInt(m.MinorUnits())
+	v.Mul(v, rate)
+	v.Mul(v, decimalScale(int(to.Decimals)-int(m.Currency.Decimals)))
+	return gnosispay.NewMoneyFromBig(round(v), to)
+}
+
+// decimalScale returns 10^n, which may be a fraction.
+func decimalScale(n int) *big.Rat {
+	p := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(n, -n))), nil)
+	if n < 0 {
+		return new(big.Rat).SetFrac(big.NewInt(1), p)
+	}
+	return new(big.Rat).SetInt(p)
+}
+
+// round rounds v to the nearest integer, halves away from zero.
+func round(v *big.Rat) *big.Int {
+	num := new(big.Int).Abs(v.Num())
+	q, rem := new(big.Int).QuoRem(num, v.Denom(), new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(v.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if v.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q
+}
+
+// ParseECB reads reference rates in the CSV format of the European Central
+// Bank: a header of "Date" followed by currency codes, then one row per
+// day with rates per euro. Both the daily file (dates like "17 October
+// 2025") and the history file (ISO dates) are accepted; "N/A" and empty
+// cells are skipped.
+func ParseECB(rd io.Reader) (*Rates, error) {
+	cr := csv.NewReader(rd)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("fx: reading ECB header: %w", err)
+	}
+	if len(header) == 0 || !strings.EqualFold(strings.TrimSpace(header[0]), "date") {
+		return nil, fmt.Errorf("fx: ECB header must start with Date")
+	}
+	codes := make([]gnosispay.CurrencyCode, len(header))
+	for i, h := range header[1:] {
+		codes[i+1] = gnosispay.CurrencyCode(strings.TrimSpace(h))
+	}
+
+	rates := NewRates(gnosispay.EUR)
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fx: reading ECB rates: %w", err)
+		}
+		day, err := parseECBDate(rec[0])
+		if err != nil {
+			return nil, fmt.Errorf("fx: line %d: %w", line, err)
+		}
+		for i, cell := range rec[1:] {
+			cell = strings.TrimSpace(cell)
+			if i+1 >= len(codes) || codes[i+1] == "" || cell == "" || cell == "N/A" {
+				continue
+			}
+			rate, ok := new(big.Rat).SetString(cell)
+			if !ok || rates.Set(day, codes[i+1], rate) != nil {
+				return nil, fmt.Errorf("fx: line %d: invalid %s rate %q", line, codes[i+1], cell)
+			}
+		}
+	}
+	return rates, nil
+}
+
+func parseECBDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{dayLayout, "02 January 2006", "2 January 2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
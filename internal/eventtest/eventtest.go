@@ -0,0 +1,105 @@
+// Package eventtest builds card events for the tests of the packages
+// that analyse them.
+//
+//	e := eventtest.Payment(at, "2500", eventtest.EUR,
+//		eventtest.Merchant("REWE"), eventtest.MCC("5411"))
+//
+// Amounts are in minor units of the billing currency, as the API reports
+// them.
+package eventtest
+
+import (
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+// Billing and transaction currencies.
+var (
+	EUR = &gnosispay.Currency{Symbol: "€", Code: "EUR", Decimals: 2}
+	GBP = &gnosispay.Currency{Symbol: "£", Code: "GBP", Decimals: 2}
+	USD = &gnosispay.Currency{Symbol: "$", Code: "USD", Decimals: 2}
+	JPY = &gnosispay.Currency{Symbol: "¥", Code: "JPY", Decimals: 0}
+)
+
+// Option sets a field of an event.
+type Option func(*gnosispay.CardEvent)
+
+// Payment returns an approved payment of amount billed in cur at at.
+func Payment(at time.Time, amount string, cur *gnosispay.Currency, opts ...Option) gnosispay.CardEvent {
+	return build(gnosispay.CardEventPayment, gnosispay.CardEventApproved, at, amount, cur, opts)
+}
+
+// Refund returns a refund of amount billed in cur at at.
+func Refund(at time.Time, amount string, cur *gnosispay.Currency, opts ...Option) gnosispay.CardEvent {
+	return build(gnosispay.CardEventRefund, "", at, amount, cur, opts)
+}
+
+// Reversal returns a reversal of amount billed in cur at at.
+func Reversal(at time.Time, amount string, cur *gnosispay.Currency, opts ...Option) gnosispay.CardEvent {
+	return build(gnosispay.CardEventReversal, "", at, amount, cur, opts)
+}
+
+func build(kind gnosispay.CardEventKind, status gnosispay.CardEventStatus, at time.Time, amount string, cur *gnosispay.Currency, opts []Option) gnosispay.CardEvent {
+	e := gnosispay.CardEvent{
+		CreatedAt:       at,
+		Kind:            kind,
+		Status:          status,
+		Merchant:        &gnosispay.Merchant{},
+		BillingAmount:   amount,
+		BillingCurrency: cur,
+	}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return e
+}
+
+// Status sets the status, for declined or reversed payments.
+func Status(s gnosispay.CardEventStatus) Option {
+	return func(e *gnosispay.CardEvent) { e.Status = s }
+}
+
+// Merchant sets the merchant's name.
+func Merchant(name string) Option {
+	return func(e *gnosispay.CardEvent) { e.Merchant.Name = name }
+}
+
+// City sets the merchant's city.
+func City(city string) Option {
+	return func(e *gnosispay.CardEvent) { e.Merchant.City = city }
+}
+
+// Country sets the merchant's country by its ISO 3166 alpha-2 code.
+func Country(alpha2 string) Option {
+	return func(e *gnosispay.CardEvent) { e.Merchant.Country = &gnosispay.Country{Alpha2: alpha2} }
+}
+
+// MCC sets the merchant category code.
+func MCC(code string) Option {
+	return func(e *gnosispay.CardEvent) { e.Mcc = code }
+}
+
+// Pending marks the event as not yet cleared.
+func Pending() Option {
+	return func(e *gnosispay.CardEvent) { e.IsPending = true }
+}
+
+// Transaction sets the amount the merchant charged, in minor units of cur.
+func Transaction(amount string, cur *gnosispay.Currency) Option {
+	return func(e *gnosispay.CardEvent) {
+		e.TransactionAmount, e.TransactionCurrency = amount, cur
+	}
+}
+
+// Thread sets the thread that links the event to related events.
+func Thread(id string) Option {
+	return func(e *gnosispay.CardEvent) { e.ThreadID = id }
+}
+
+// Hash adds an on-chain transaction with hash.
+func Hash(hash string) Option {
+	return func(e *gnosispay.CardEvent) {
+		e.Transactions = append(e.Transactions, gnosispay.Transaction{Hash: hash})
+	}
+}
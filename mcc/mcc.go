@@ -0,0 +1,307 @@
+// Package mcc describes ISO 18245 merchant category codes.
+//
+// An embedded table maps every code to a description and to one of a small
+// set of spending categories such as groceries, dining or travel. Mappings
+// can be overridden from a CSV file with the same layout as the embedded
+// table:
+//
+//	mcc,category,description
+//	5814,groceries,Fast Food Restaurants
+//	5999,shopping,
+//	4000-4099,transport,Rail
+//
+// An empty description keeps the existing one; a range applies to every code
+// it covers that has no exact entry.
+package mcc
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Category is a human-readable spending category.
+type Category string
+
+const (
+	Groceries     Category = "groceries"
+	Dining        Category = "dining"
+	Travel        Category = "travel"
+	Transport     Category = "transport"
+	Fuel          Category = "fuel"
+	Shopping      Category = "shopping"
+	Entertainment Category = "entertainment"
+	Health        Category = "health"
+	Utilities     Category = "utilities"
+	Education     Category = "education"
+	Services      Category = "services"
+	Financial     Category = "financial"
+	Cash          Category = "cash"
+	Government    Category = "government"
+	Other         Category = "other"
+)
+
+// Categories lists the known categories.
+func Categories() []Category {
+	return []Category{Groceries, Dining, Travel, Transport, Fuel, Shopping, Entertainment,
+		Health, Utilities, Education, Services, Financial, Cash, Government, Other}
+}
+
+// ParseCategory returns the category named s, ignoring case.
+func ParseCategory(s string) (Category, error) {
+	c := Category(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Categories() {
+		if c == known {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown merchant category %q", s)
+}
+
+// Code is an entry of the table.
+type Code struct {
+	MCC         string   `json:"mcc"`
+	Category    Category `json:"category"`
+	Description string   `json:"description"`
+}
+
+type span struct {
+	from, to int
+	code     Code
+}
+
+// Table maps merchant category codes to their descriptions and categories.
+// It is safe for concurrent use.
+type Table struct {
+	mu     sync.RWMutex
+	codes  map[int]Code
+	ranges []span
+}
+
+//go:embed codes.csv
+var embedded string
+
+// Default is the table used by the package-level functions.
+var Default = MustNewTable()
+
+// NewTable returns a table holding the embedded ISO 18245 codes.
+func NewTable() (*Table, error) {
+	t := &Table{codes: map[int]Code{}}
+	if err := t.Load(strings.NewReader(embedded)); err != nil {
+		return nil, fmt.Errorf("embedded table: %w", err)
+	}
+	return t, nil
+}
+
+// MustNewTable is like NewTable but panics on error.
+func MustNewTable() *Table {
+	t, err := NewTable()
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Lookup returns the entry for a 4-digit code. Codes without an exact entry
+// fall back to the narrowest range covering them.
+func (t *Table) Lookup(mcc string) (Code, bool) {
+	n, ok := parseCode(mcc)
+	if !ok {
+		return Code{}, false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if c, ok := t.codes[n]; ok {
+		return c, true
+	}
+	found, ok := t.covering(n, n)
+	if !ok {
+		return Code{}, false
+	}
+	found.MCC = mcc
+	return found, true
+}
+
+// covering returns the narrowest range entry spanning from through to.
+// The caller holds t.mu.
+func (t *Table) covering(from, to int) (Code, bool) {
+	var (
+		found Code
+		width = -1
+	)
+	for _, s := range t.ranges {
+		if from >= s.from && to <= s.to && (width < 0 || s.to-s.from < width) {
+			found, width = s.code, s.to-s.from
+		}
+	}
+	return found, width >= 0
+}
+
+// Category returns the category of mcc, or Other when it is unknown.
+func (t *Table) Category(mcc string) Category {
+	if c, ok := t.Lookup(mcc); ok {
+		return c.Category
+	}
+	return Other
+}
+
+// Describe returns the description of mcc, or mcc itself when it is
+// unknown.
+func (t *Table) Describe(mcc string) string {
+	if c, ok := t.Lookup(mcc); ok && c.Description != "" {
+		return c.Description
+	}
+	return mcc
+}
+
+// Codes returns the exact codes mapped to cat, sorted. Range entries are
+// not expanded.
+func (t *Table) Codes(cat Category) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var out []string
+	for _, c := range t.codes {
+		if c.Category == cat {
+			out = append(out, c.MCC)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Set maps a code or an inclusive range such as "3000-3350" to cat. An
+// empty description keeps the existing one, or else takes that of the
+// narrowest range covering the code.
+func (t *Table) Set(mcc string, cat Category, description string) error {
+	from, to, err := parseRange(mcc)
+	if err != nil {
+		return err
+	}
+	if _, err := ParseCategory(string(cat)); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if from == to {
+		if description == "" {
+			if c, ok := t.codes[from]; ok {
+				description = c.Description
+			} else if c, ok := t.covering(from, to); ok {
+				description = c.Description
+			}
+		}
+		t.codes[from] = Code{MCC: mcc, Category: cat, Description: description}
+		return nil
+	}
+	for i, s := range t.ranges {
+		if s.from == from && s.to == to {
+			if description == "" {
+				description = s.code.Description
+			}
+			t.ranges[i].code = Code{MCC: mcc, Category: cat, Description: description}
+			return nil
+		}
+	}
+	if description == "" {
+		if c, ok := t.covering(from, to); ok {
+			description = c.Description
+		}
+	}
+	t.ranges = append(t.ranges, span{from, to, Code{MCC: mcc, Category: cat, Description: description}})
+	return nil
+}
+
+// Load applies the mappings of a CSV document with mcc, category and
+// description columns. A leading header row is skipped. Rows are applied in
+// order; on error the rows before the failing one remain applied.
+func (t *Table) Load(r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if line == 1 && strings.EqualFold(rec[0], "mcc") {
+			continue
+		}
+		if len(rec) < 2 || len(rec) > 3 {
+			return fmt.Errorf("line %d: want mcc,category[,description], got %d fields", line, len(rec))
+		}
+		var description string
+		if len(rec) == 3 {
+			description = strings.TrimSpace(rec[2])
+		}
+		cat, err := ParseCategory(rec[1])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := t.Set(strings.TrimSpace(rec[0]), cat, description); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// LoadFile applies the mappings of the CSV file at path.
+func (t *Table) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := t.Load(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Lookup returns the entry for mcc in the Default table.
+func Lookup(mcc string) (Code, bool) { return Default.Lookup(mcc) }
+
+// CategoryOf returns the category of mcc in the Default table.
+func CategoryOf(mcc string) Category { return Default.Category(mcc) }
+
+// Describe returns the description of mcc in the Default table.
+func Describe(mcc string) string { return Default.Describe(mcc) }
+
+// Codes returns the codes mapped to cat in the Default table.
+func Codes(cat Category) []string { return Default.Codes(cat) }
+
+// LoadFile applies overrides from the CSV file at path to the Default table.
+func LoadFile(path string) error { return Default.LoadFile(path) }
+
+func parseCode(mcc string) (int, bool) {
+	if len(mcc) != 4 || strings.Trim(mcc, "0123456789") != "" {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(mcc)
+	return n, true
+}
+
+func parseRange(s string) (from, to int, err error) {
+	lo, hi, isRange := strings.Cut(s, "-")
+	from, ok := parseCode(lo)
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid merchant category code %q", s)
+	}
+	if !isRange {
+		return from, from, nil
+	}
+	to, ok = parseCode(hi)
+	if !ok || to < from {
+		return 0, 0, fmt.Errorf("invalid merchant category code range %q", s)
+	}
+	return from, to, nil
+}
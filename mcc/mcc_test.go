@@ -0,0 +1,125 @@
+package mcc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		mcc  string
+		cat  Category
+		desc string
+		ok   bool
+	}{
+		{"5411", Groceries, "Grocery Stores and Supermarkets", true},
+		{"5812", Dining, "Eating Places and Restaurants", true},
+		{"6011", Cash, "Financial Institutions - Automated Cash Disbursements", true},
+		{"3012", Travel, "Airlines", true},
+		{"3750", Travel, "Hotels, Motels and Resorts", true},
+		{"1234", Other, "1234", false},
+		{"541", Other, "541", false},
+		{"54a1", Other, "54a1", false},
+		{"", Other, "", false},
+	}
+	for _, tt := range tests {
+		c, ok := Lookup(tt.mcc)
+		if ok != tt.ok || (ok && (c.MCC != tt.mcc || c.Category != tt.cat)) {
+			t.Errorf("Lookup(%q) = %+v, %v", tt.mcc, c, ok)
+		}
+		if got := CategoryOf(tt.mcc); got != tt.cat {
+			t.Errorf("CategoryOf(%q) = %q, want %q", tt.mcc, got, tt.cat)
+		}
+		if got := Describe(tt.mcc); got != tt.desc {
+			t.Errorf("Describe(%q) = %q, want %q", tt.mcc, got, tt.desc)
+		}
+	}
+}
+
+func TestEmbeddedTable(t *testing.T) {
+	for _, cat := range Categories() {
+		if cat != Other && len(Codes(cat)) == 0 {
+			t.Errorf("no codes in category %q", cat)
+		}
+	}
+	codes := Codes(Groceries)
+	if !contains(codes, "5411") || contains(codes, "5812") {
+		t.Errorf("Codes(Groceries) = %v", codes)
+	}
+}
+
+func TestTable_Load(t *testing.T) {
+	tab := MustNewTable()
+	err := tab.Load(strings.NewReader(`mcc,category,description
+# Coffee to go counts as groceries.
+5814,Groceries,
+3005,transport,
+5999,shopping,Online marketplaces
+3500-3510,transport,Rail partners
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := tab.Lookup("5814"); c.Category != Groceries || c.Description != "Fast Food Restaurants" {
+		t.Errorf("5814 = %+v", c)
+	}
+	// A code only a range covered keeps the range's description.
+	if c, _ := tab.Lookup("3005"); c.Category != Transport || c.Description != "Airlines" {
+		t.Errorf("3005 = %+v", c)
+	}
+	if got := tab.Describe("5999"); got != "Online marketplaces" {
+		t.Errorf("5999 description = %q", got)
+	}
+	// The narrower range wins over the embedded hotel range.
+	if got := tab.Category("3505"); got != Transport {
+		t.Errorf("3505 = %q, want transport", got)
+	}
+	if got := tab.Category("3600"); got != Travel {
+		t.Errorf("3600 = %q, want travel", got)
+	}
+	// Overrides do not leak into other tables.
+	if got := CategoryOf("5814"); got != Dining {
+		t.Errorf("default 5814 = %q, want dining", got)
+	}
+
+	for _, bad := range []string{
+		"5814,snacks\n",
+		"58140,dining\n",
+		"5900-5800,dining\n",
+		"5814\n",
+		"5814,dining,Fast food,extra\n",
+	} {
+		if err := MustNewTable().Load(strings.NewReader(bad)); err == nil {
+			t.Errorf("Load(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestTable_LoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcc.csv")
+	if err := os.WriteFile(path, []byte("7995,shopping\n7995,nonsense\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tab := MustNewTable()
+	err := tab.LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("LoadFile error = %v, want a line 2 error", err)
+	}
+	if got := tab.Category("7995"); got != Shopping {
+		t.Errorf("7995 = %q, want the row before the error applied", got)
+	}
+	if err := tab.LoadFile(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Error("LoadFile of a missing file succeeded")
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
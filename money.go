@@ -0,0 +1,323 @@
+package gnosispay
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Money is an exact monetary amount stored as an integer number of minor
+// units (e.g. cents) together with its Currency. The zero value is zero
+// in an unspecified currency.
+//
+// Amounts are kept in a big.Int because token balances are expressed in
+// 18-decimal base units, which overflow an int64 for modest sums.
+type Money struct {
+	minor    *big.Int
+	Currency Currency
+}
+
+// NewMoney returns an amount of minor units in the given currency.
+func NewMoney(minor int64, c Currency) Money {
+	return Money{minor: big.NewInt(minor), Currency: c}
+}
+
+// NewMoneyFromBig returns an amount of minor units in the given currency.
+// The value is copied.
+func NewMoneyFromBig(minor *big.Int, c Currency) Money {
+	return Money{minor: new(big.Int).Set(minor), Currency: c}
+}
+
+// ParseMinorUnits parses an integer string of minor units, the format the
+// API uses for card event amounts and account balances.
+func ParseMinorUnits(s string, c Currency) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, fmt.Errorf("amount cannot be empty")
+	}
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount %q: not an integer", s)
+	}
+	return Money{minor: n, Currency: c}, nil
+}
+
+// ParseMoney parses a decimal string in major units such as "12.34" or
+// "-0.5". It fails if s has more fractional digits than c.Decimals allows,
+// unless the extra digits are all zero.
+func ParseMoney(s string, c Currency) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, fmt.Errorf("amount cannot be empty")
+	}
+	if c.Decimals < 0 {
+		return Money{}, fmt.Errorf("invalid currency %q: negative decimals", c.Code)
+	}
+
+	neg := false
+	digits := s
+	switch digits[0] {
+	case '-':
+		neg = true
+		digits = digits[1:]
+	case '+':
+		digits = digits[1:]
+	}
+
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+
+	decimals := int(c.Decimals)
+	if len(frac) > decimals {
+		if strings.Trim(frac[decimals:], "0") != "" {
+			return Money{}, fmt.Errorf("invalid amount %q: %s allows %d decimals", s, c.Code, decimals)
+		}
+		frac = frac[:decimals]
+	}
+	frac += strings.Repeat("0", decimals-len(frac))
+
+	n, ok := new(big.Int).SetString("0"+whole+frac, 10)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+	if neg {
+		n.Neg(n)
+	}
+	return Money{minor: n, Currency: c}, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) big() *big.Int {
+	if m.minor == nil {
+		return new(big.Int)
+	}
+	return m.minor
+}
+
+// MinorUnits returns a copy of the amount in minor units.
+func (m Money) MinorUnits() *big.Int {
+	return new(big.Int).Set(m.big())
+}
+
+// Int64 returns the amount in minor units and whether it fits in an int64.
+func (m Money) Int64() (int64, bool) {
+	n := m.big()
+	return n.Int64(), n.IsInt64()
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of m.
+func (m Money) Sign() int {
+	return m.big().Sign()
+}
+
+// IsZero reports whether m is zero.
+func (m Money) IsZero() bool {
+	return m.Sign() == 0
+}
+
+// SameCurrency reports whether m and o are in the same currency.
+func (m Money) SameCurrency(o Money) bool {
+	return m.Currency.Code == o.Currency.Code && m.Currency.Decimals == o.Currency.Decimals
+}
+
+func (m Money) checkCurrency(o Money) error {
+	if !m.SameCurrency(o) {
+		return fmt.Errorf("currency mismatch: %s (%d decimals) and %s (%d decimals)",
+			m.Currency.Code, m.Currency.Decimals, o.Currency.Code, o.Currency.Decimals)
+	}
+	return nil
+}
+
+// Add returns m + o. Both must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+	return Money{minor: new(big.Int).Add(m.big(), o.big()), Currency: m.Currency}, nil
+}
+
+// Sub returns m - o. Both must be in the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+	return Money{minor: new(big.Int).Sub(m.big(), o.big()), Currency: m.Currency}, nil
+}
+
+// Mul returns m multiplied by n.
+func (m Money) Mul(n int64) Money {
+	return Money{minor: new(big.Int).Mul(m.big(), big.NewInt(n)), Currency: m.Currency}
+}
+
+// Neg returns -m.
+func (m Money) Neg() Money {
+	return Money{minor: new(big.Int).Neg(m.big()), Currency: m.Currency}
+}
+
+// Abs returns |m|.
+func (m Money) Abs() Money {
+	return Money{minor: new(big.Int).Abs(m.big()), Currency: m.Currency}
+}
+
+// Cmp compares m and o and returns -1, 0 or +1. Both must be in the same
+// currency.
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return 0, err
+	}
+	return m.big().Cmp(o.big()), nil
+}
+
+// Equal reports whether m and o have the same currency and amount.
+func (m Money) Equal(o Money) bool {
+	return m.SameCurrency(o) && m.big().Cmp(o.big()) == 0
+}
+
+// Decimal returns the amount in major units as a plain decimal string,
+// e.g. "-12.30", with exactly Currency.Decimals fractional digits.
+func (m Money) Decimal() string {
+	n := m.big()
+	digits := new(big.Int).Abs(n).String()
+	decimals := int(m.Currency.Decimals)
+
+	sign := ""
+	if n.Sign() < 0 {
+		sign = "-"
+	}
+	if decimals <= 0 {
+		return sign + digits
+	}
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	cut := len(digits) - decimals
+	return sign + digits[:cut] + "." + digits[cut:]
+}
+
+// String returns the amount followed by the currency code, e.g. "12.30 EUR".
+func (m Money) String() string {
+	if m.Currency.Code == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.Currency.Code
+}
+
+// Format returns the amount prefixed with the currency symbol, e.g.
+// "€12.30" or "-$4.00". It falls back to String when no symbol is known.
+func (m Money) Format() string {
+	symbol := m.Currency.Symbol
+	if symbol == "" {
+		return m.String()
+	}
+	d := m.Decimal()
+	if strings.HasPrefix(d, "-") {
+		return "-" + symbol + d[1:]
+	}
+	return symbol + d
+}
+
+type moneyJSON struct {
+	Amount   string   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// MarshalJSON encodes m as an object holding the decimal amount and the
+// full currency, so it can be decoded without losing precision.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON decodes the format written by MarshalJSON.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parsed, err := ParseMoney(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// BillingMoney returns the billing amount in the billing currency.
+func (e CardEvent) BillingMoney() (Money, error) {
+	if e.BillingCurrency == nil {
+		return Money{}, fmt.Errorf("card event has no billing currency")
+	}
+	return ParseMinorUnits(e.BillingAmount, *e.BillingCurrency)
+}
+
+// TransactionMoney returns the transaction amount in the currency the
+// merchant charged.
+func (e CardEvent) TransactionMoney() (Money, error) {
+	if e.TransactionCurrency == nil {
+		return Money{}, fmt.Errorf("card event has no transaction currency")
+	}
+	return ParseMinorUnits(e.TransactionAmount, *e.TransactionCurrency)
+}
+
+// ConversionRate returns the rate applied to the event as billing units per
+// transaction unit, in major units: what the transaction amount is
+// multiplied by to get the billed amount. For a payment of 10.00 USD
+// billed as 9.35 EUR it is 0.935. Signs are ignored.
+func (e CardEvent) ConversionRate() (*big.Rat, error) {
+	billing, err := e.BillingMoney()
+	if err != nil {
+		return nil, err
+	}
+	original, err := e.TransactionMoney()
+	if err != nil {
+		return nil, err
+	}
+	if original.IsZero() {
+		return nil, fmt.Errorf("transaction amount is zero")
+	}
+	rate := new(big.Rat).SetFrac(billing.Abs().MinorUnits(), original.Abs().MinorUnits())
+	scale := new(big.Rat).SetFrac(
+		new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(original.Currency.Decimals)), nil),
+		new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(billing.Currency.Decimals)), nil),
+	)
+	return rate.Mul(rate, scale), nil
+}
+
+// TotalMoney returns the total balance in c. The API reports balances in
+// the base units of the Safe's token, so c.Decimals must match the token.
+func (b AccountBalances) TotalMoney(c Currency) (Money, error) {
+	return ParseMinorUnits(b.Total, c)
+}
+
+// SpendableMoney returns the spendable balance in c.
+func (b AccountBalances) SpendableMoney(c Currency) (Money, error) {
+	return ParseMinorUnits(b.Spendable, c)
+}
+
+// PendingMoney returns the pending balance in c.
+func (b AccountBalances) PendingMoney(c Currency) (Money, error) {
+	return ParseMinorUnits(b.Pending, c)
+}
+
+// Money returns the order amount. Monerium reports amounts as decimal
+// strings with a lower-case currency code, e.g. "100.5" and "eur".
+func (o IbanOrder) Money() (Money, error) {
+	code := CurrencyCode(strings.ToUpper(o.Currency))
+	if err := code.Validate(); err != nil {
+		return Money{}, err
+	}
+	return ParseMoney(o.Amount, code.Currency())
+}
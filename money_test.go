@@ -0,0 +1,188 @@
+package gnosispay
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var (
+	testEUR  = Currency{Symbol: "€", Code: "EUR", Decimals: 2, Name: "Euro"}
+	testJPY  = Currency{Symbol: "¥", Code: "JPY", Decimals: 0, Name: "Yen"}
+	testEURe = Currency{Symbol: "EURe", Code: "EURe", Decimals: 18}
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency Currency
+		want     string
+		wantErr  bool
+	}{
+		{name: "whole amount", amount: "12", currency: testEUR, want: "12.00"},
+		{name: "fractional amount", amount: "12.3", currency: testEUR, want: "12.30"},
+		{name: "negative amount", amount: "-0.05", currency: testEUR, want: "-0.05"},
+		{name: "leading dot", amount: ".5", currency: testEUR, want: "0.50"},
+		{name: "trailing zeros beyond decimals", amount: "1.2300", currency: testEUR, want: "1.23"},
+		{name: "zero decimal currency", amount: "1500", currency: testJPY, want: "1500"},
+		{name: "too many decimals", amount: "1.234", currency: testEUR, wantErr: true},
+		{name: "decimals on zero decimal currency", amount: "1.5", currency: testJPY, wantErr: true},
+		{name: "empty", amount: "", currency: testEUR, wantErr: true},
+		{name: "lone dot", amount: ".", currency: testEUR, wantErr: true},
+		{name: "letters", amount: "1e3", currency: testEUR, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.amount, tt.currency)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMoney() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Decimal() != tt.want {
+				t.Errorf("ParseMoney() = %v, want %v", got.Decimal(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMinorUnits(t *testing.T) {
+	got, err := ParseMinorUnits("2500000000000000000", testEURe)
+	if err != nil {
+		t.Fatalf("ParseMinorUnits() error = %v", err)
+	}
+	if want := "2.500000000000000000"; got.Decimal() != want {
+		t.Errorf("ParseMinorUnits() = %v, want %v", got.Decimal(), want)
+	}
+
+	if _, err := ParseMinorUnits("12.50", testEUR); err == nil {
+		t.Error("ParseMinorUnits() expected error for decimal input")
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a := NewMoney(1050, testEUR)
+	b := NewMoney(-275, testEUR)
+
+	sum, err := a.Add(b)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if sum.String() != "7.75 EUR" {
+		t.Errorf("Add() = %v, want 7.75 EUR", sum)
+	}
+
+	diff, err := a.Sub(b)
+	if err != nil {
+		t.Fatalf("Sub() error = %v", err)
+	}
+	if diff.Decimal() != "13.25" {
+		t.Errorf("Sub() = %v, want 13.25", diff.Decimal())
+	}
+
+	if got := b.Mul(3).Decimal(); got != "-8.25" {
+		t.Errorf("Mul() = %v, want -8.25", got)
+	}
+	if got := b.Abs().Decimal(); got != "2.75" {
+		t.Errorf("Abs() = %v, want 2.75", got)
+	}
+	if got := a.Neg().Sign(); got != -1 {
+		t.Errorf("Neg().Sign() = %v, want -1", got)
+	}
+
+	cmp, err := a.Cmp(b)
+	if err != nil || cmp != 1 {
+		t.Errorf("Cmp() = %v, %v, want 1, nil", cmp, err)
+	}
+
+	if _, err := a.Add(NewMoney(1, testJPY)); err == nil {
+		t.Error("Add() expected currency mismatch error")
+	}
+	if _, err := a.Cmp(NewMoney(1, testJPY)); err == nil {
+		t.Error("Cmp() expected currency mismatch error")
+	}
+
+	var zero Money
+	if !zero.IsZero() || zero.Decimal() != "0" {
+		t.Errorf("zero Money = %q, want 0", zero.Decimal())
+	}
+}
+
+func TestMoney_Format(t *testing.T) {
+	tests := []struct {
+		name  string
+		money Money
+		want  string
+	}{
+		{name: "euro", money: NewMoney(1230, testEUR), want: "€12.30"},
+		{name: "negative euro", money: NewMoney(-5, testEUR), want: "-€0.05"},
+		{name: "yen", money: NewMoney(1500, testJPY), want: "¥1500"},
+		{name: "no symbol", money: NewMoney(100, Currency{Code: "XYZ", Decimals: 2}), want: "1.00 XYZ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.money.Format(); got != tt.want {
+				t.Errorf("Format() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	orig, _ := ParseMinorUnits("123456789012345678901", testEURe)
+
+	data, err := json.Marshal(orig)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var got Money
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !got.Equal(orig) {
+		t.Errorf("round trip = %v, want %v", got, orig)
+	}
+}
+
+func TestEntityMoneyAccessors(t *testing.T) {
+	event := CardEvent{
+		BillingAmount:       "1999",
+		BillingCurrency:     &testEUR,
+		TransactionAmount:   "3000",
+		TransactionCurrency: &testJPY,
+	}
+	if m, err := event.BillingMoney(); err != nil || m.String() != "19.99 EUR" {
+		t.Errorf("BillingMoney() = %v, %v", m, err)
+	}
+	if m, err := event.TransactionMoney(); err != nil || m.String() != "3000 JPY" {
+		t.Errorf("TransactionMoney() = %v, %v", m, err)
+	}
+	if _, err := (CardEvent{BillingAmount: "1"}).BillingMoney(); err == nil {
+		t.Error("BillingMoney() expected error without currency")
+	}
+
+	if r, err := event.ConversionRate(); err != nil || r.FloatString(8) != "0.00666333" {
+		t.Errorf("ConversionRate() = %v, %v, want 0.00666333", r, err)
+	}
+	refund := CardEvent{BillingAmount: "-935", BillingCurrency: &testEUR, TransactionAmount: "-1000", TransactionCurrency: &testEUR}
+	if r, err := refund.ConversionRate(); err != nil || r.FloatString(3) != "0.935" {
+		t.Errorf("ConversionRate() of a refund = %v, %v, want 0.935", r, err)
+	}
+	if _, err := (CardEvent{BillingAmount: "0", BillingCurrency: &testEUR, TransactionAmount: "0", TransactionCurrency: &testJPY}).ConversionRate(); err == nil {
+		t.Error("ConversionRate() expected error for a zero transaction amount")
+	}
+
+	balances := AccountBalances{Total: "1500000000000000000", Spendable: "1000000000000000000", Pending: "500000000000000000"}
+	total, _ := balances.TotalMoney(testEURe)
+	spendable, _ := balances.SpendableMoney(testEURe)
+	pending, _ := balances.PendingMoney(testEURe)
+	if sum, _ := spendable.Add(pending); !sum.Equal(total) {
+		t.Errorf("spendable + pending = %v, want %v", sum, total)
+	}
+
+	order := IbanOrder{Amount: "100.5", Currency: "eur"}
+	if m, err := order.Money(); err != nil || m.String() != "100.50 EUR" {
+		t.Errorf("IbanOrder.Money() = %v, %v", m, err)
+	}
+}
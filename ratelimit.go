@@ -0,0 +1,90 @@
+package gnosispay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces out requests so that at most burst of them go out
+// back to back, and the rest follow one per interval.
+type rateLimiter struct {
+	interval time.Duration
+	burst    int
+
+	mu sync.Mutex
+	// tat is the theoretical arrival time of the next request were the
+	// requests sent exactly one interval apart.
+	tat time.Time
+}
+
+func newRateLimiter(perSecond float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / perSecond),
+		burst:    burst,
+	}
+}
+
+// reserve claims the next slot and returns how long to wait for it.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.tat.Before(now) {
+		l.tat = now
+	}
+	l.tat = l.tat.Add(l.interval)
+	return max(l.tat.Sub(now)-time.Duration(l.burst)*l.interval, 0)
+}
+
+// cancel gives back a slot claimed by reserve that will not be used, so
+// that later requests do not wait for it.
+func (l *rateLimiter) cancel(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tat = l.tat.Add(-l.interval)
+	if l.tat.Before(now) {
+		l.tat = now
+	}
+}
+
+// wait blocks until a request may be sent or ctx is done. A request
+// abandoned because ctx is done does not count against the limit.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	delay := l.reserve(time.Now())
+	if err := ctx.Err(); err != nil {
+		l.cancel(time.Now())
+		return err
+	}
+	if delay == 0 {
+		return nil
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		l.cancel(time.Now())
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// SetRateLimit is a client option that limits the client to perSecond
+// requests per second on average, allowing bursts of up to burst requests.
+// The limit is shared by every service and goroutine using the client.
+func SetRateLimit(perSecond float64, burst int) ClientOpt {
+	return func(c *Client) error {
+		if perSecond <= 0 {
+			return fmt.Errorf("invalid rate limit %v: must be positive", perSecond)
+		}
+		if burst < 1 {
+			return fmt.Errorf("invalid burst %d: must be at least 1", burst)
+		}
+		c.limiter = newRateLimiter(perSecond, burst)
+		return nil
+	}
+}
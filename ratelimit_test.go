@@ -0,0 +1,96 @@
+package gnosispay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_reserve(t *testing.T) {
+	l := newRateLimiter(10, 2) // one request per 100ms, bursts of 2
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		at   time.Duration
+		want time.Duration
+	}{
+		{0, 0},
+		{0, 0},
+		{0, 100 * time.Millisecond},
+		{0, 200 * time.Millisecond},
+		// After a second of silence the burst is available again.
+		{time.Second, 0},
+		{time.Second, 0},
+		{time.Second, 100 * time.Millisecond},
+	}
+	for i, s := range steps {
+		if got := l.reserve(now.Add(s.at)); got != s.want {
+			t.Errorf("step %d: reserve() = %v, want %v", i, got, s.want)
+		}
+	}
+}
+
+func TestRateLimiter_cancel(t *testing.T) {
+	l := newRateLimiter(10, 1)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	l.reserve(now)
+	if got := l.reserve(now); got != 100*time.Millisecond {
+		t.Fatalf("second reserve() = %v, want 100ms", got)
+	}
+	// The second request gives up, so the third takes its slot.
+	l.cancel(now)
+	if got := l.reserve(now); got != 100*time.Millisecond {
+		t.Errorf("reserve() after cancel = %v, want 100ms", got)
+	}
+}
+
+func TestRateLimiter_waitCancelled(t *testing.T) {
+	l := newRateLimiter(1, 1)
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Abandoned waits must not push back the next request.
+	for range 3 {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		if err := l.wait(ctx); err != context.DeadlineExceeded {
+			t.Fatalf("wait() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+		cancel()
+	}
+	if d := l.reserve(time.Now()); d > time.Second {
+		t.Errorf("reserve() after cancelled waits = %v, want at most 1s", d)
+	}
+}
+
+func TestSetRateLimit(t *testing.T) {
+	if _, err := New(nil, SetRateLimit(0, 1)); err == nil {
+		t.Error("SetRateLimit(0, 1) expected error")
+	}
+	if _, err := New(nil, SetRateLimit(1, 0)); err == nil {
+		t.Error("SetRateLimit(1, 0) expected error")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client, err := New(nil, SetBaseURL(server.URL), SetRateLimit(1, 1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	req, _ := client.NewRequest(ctx, http.MethodGet, "/", nil)
+	if err := client.Do(ctx, req, nil); err != nil {
+		t.Fatalf("first Do() error = %v", err)
+	}
+
+	// The second request has to wait a second, longer than the deadline.
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	req, _ = client.NewRequest(ctx, http.MethodGet, "/", nil)
+	if err := client.Do(ctx, req, nil); err != context.DeadlineExceeded {
+		t.Errorf("second Do() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,97 @@
+package receipts
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+// indexHeader is the header of the CSV index in a bundle.
+var indexHeader = []string{
+	"id", "created_at", "kind", "status", "merchant", "amount", "currency", "note",
+	"file", "name", "content_type", "size", "sha256",
+}
+
+// WriteBundle writes a ZIP archive of the receipts of events to w, with an
+// index.csv listing every event and a receipts directory of its files.
+// Events with several attachments get a row per attachment; events without
+// one get a single row with empty file columns, so missing receipts show
+// up in the index.
+func WriteBundle(ctx context.Context, w io.Writer, s Store, events []gnosispay.CardEvent) error {
+	zw := zip.NewWriter(w)
+	var rows [][]string
+	n := 0
+	for _, e := range events {
+		id := e.ID()
+		atts, err := s.List(ctx, id)
+		if err != nil {
+			return err
+		}
+		note, err := s.Note(ctx, id)
+		if err != nil {
+			return err
+		}
+		row := indexRow(e, note)
+		if len(atts) == 0 {
+			rows = append(rows, append(row, "", "", "", "", ""))
+			continue
+		}
+		for _, a := range atts {
+			n++
+			file := fmt.Sprintf("receipts/%s_%03d_%s", e.CreatedAt.UTC().Format(time.DateOnly), n, a.Name)
+			if err := copyAttachment(ctx, zw, s, id, file, a); err != nil {
+				return err
+			}
+			rows = append(rows, append(row[:len(row):len(row)],
+				file, a.Name, a.ContentType, fmt.Sprint(a.Size), a.SHA256))
+		}
+	}
+
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: "index.csv", Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(f)
+	if err := cw.Write(indexHeader); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func indexRow(e gnosispay.CardEvent, note string) []string {
+	var merchant string
+	if e.Merchant != nil {
+		merchant = strings.TrimSpace(e.Merchant.Name)
+	}
+	amount, currency := e.BillingAmount, ""
+	if m, err := e.BillingMoney(); err == nil {
+		amount, currency = m.Decimal(), m.Currency.Code
+	}
+	return []string{
+		e.ID(), e.CreatedAt.UTC().Format(time.RFC3339), string(e.Kind), string(e.Status),
+		merchant, amount, currency, note,
+	}
+}
+
+func copyAttachment(ctx context.Context, zw *zip.Writer, s Store, event, file string, a Attachment) error {
+	rc, err := s.Open(ctx, event, a.ID)
+	if err != nil {
+		return fmt.Errorf("receipts: opening %s: %w", a.Name, err)
+	}
+	defer rc.Close()
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: file, Method: zip.Deflate, Modified: a.AddedAt})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, rc)
+	return err
+}
@@ -0,0 +1,203 @@
+package receipts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metaFile holds an event's ID, note and attachment list in its
+// directory.
+const metaFile = "meta.json"
+
+// DirStore keeps attachments in a directory tree: one subdirectory per
+// event, named after a hash of its ID, holding the files and a JSON index.
+type DirStore struct {
+	Root string
+
+	// Now returns the time recorded on new attachments. Defaults to
+	// time.Now.
+	Now func() time.Time
+
+	mu sync.Mutex
+}
+
+type dirMeta struct {
+	Event       string       `json:"event"`
+	Note        string       `json:"note,omitempty"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+func (s *DirStore) Add(ctx context.Context, event, name string, r io.Reader) (Attachment, error) {
+	a, data, err := Read(name, r)
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	meta, err := s.load(event)
+	if err != nil {
+		return Attachment{}, err
+	}
+	for _, existing := range meta.Attachments {
+		if existing.ID == a.ID {
+			return existing, nil
+		}
+	}
+	if err := os.MkdirAll(s.dir(event), 0o700); err != nil {
+		return Attachment{}, err
+	}
+	if err := writeFile(filepath.Join(s.dir(event), fileName(a)), data); err != nil {
+		return Attachment{}, err
+	}
+	a.AddedAt = s.now()
+	meta.Attachments = append(meta.Attachments, a)
+	if err := s.save(event, meta); err != nil {
+		return Attachment{}, err
+	}
+	return a, nil
+}
+
+func (s *DirStore) List(ctx context.Context, event string) ([]Attachment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	meta, err := s.load(event)
+	if err != nil {
+		return nil, err
+	}
+	return meta.Attachments, nil
+}
+
+func (s *DirStore) Open(ctx context.Context, event, id string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	meta, err := s.load(event)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range meta.Attachments {
+		if a.ID == id {
+			return os.Open(filepath.Join(s.dir(event), fileName(a)))
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *DirStore) Delete(ctx context.Context, event, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	meta, err := s.load(event)
+	if err != nil {
+		return err
+	}
+	for i, a := range meta.Attachments {
+		if a.ID != id {
+			continue
+		}
+		meta.Attachments = append(meta.Attachments[:i], meta.Attachments[i+1:]...)
+		if err := s.save(event, meta); err != nil {
+			return err
+		}
+		err := os.Remove(filepath.Join(s.dir(event), fileName(a)))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return ErrNotFound
+}
+
+func (s *DirStore) Note(ctx context.Context, event string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	meta, err := s.load(event)
+	if err != nil {
+		return "", err
+	}
+	return meta.Note, nil
+}
+
+func (s *DirStore) SetNote(ctx context.Context, event, note string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	meta, err := s.load(event)
+	if err != nil {
+		return err
+	}
+	meta.Note = strings.TrimSpace(note)
+	if err := os.MkdirAll(s.dir(event), 0o700); err != nil {
+		return err
+	}
+	return s.save(event, meta)
+}
+
+// dir returns the directory of an event. Store callers may pass any
+// string, including characters that are not portable in file names, so the
+// directory is named after a hash instead.
+func (s *DirStore) dir(event string) string {
+	sum := sha256.Sum256([]byte(event))
+	return filepath.Join(s.Root, hex.EncodeToString(sum[:16]))
+}
+
+func (s *DirStore) load(event string) (*dirMeta, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir(event), metaFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &dirMeta{Event: event}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var meta dirMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func (s *DirStore) save(event string, meta *dirMeta) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(s.dir(event), metaFile), data)
+}
+
+func (s *DirStore) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// fileName is the name an attachment's content is stored under, keeping
+// the original extension so the files open directly.
+func fileName(a Attachment) string {
+	return a.ID + strings.ToLower(path.Ext(a.Name))
+}
+
+// writeFile replaces the file at name atomically.
+func writeFile(name string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
@@ -0,0 +1,166 @@
+// Package receipts keeps receipts and notes for card events in a local
+// store, finds payments that still need a receipt and bundles everything
+// for an expense workflow.
+//
+// Attachments are keyed by gnosispay.CardEvent.ID, which stays the same
+// across syncs and when a pending event clears, so a receipt can be
+// attached right after the purchase.
+//
+// DirStore keeps attachments in a directory; package store provides a
+// SQLite implementation next to the synced account data.
+package receipts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/fx"
+)
+
+// MaxSize is the largest attachment Read accepts.
+const MaxSize = 32 << 20
+
+var (
+	// ErrNotFound is returned for an attachment that is not in the store.
+	ErrNotFound = errors.New("receipts: attachment not found")
+	// ErrTooLarge is returned for content over MaxSize.
+	ErrTooLarge = errors.New("receipts: attachment too large")
+)
+
+// Attachment describes a file attached to a card event.
+type Attachment struct {
+	// ID identifies the attachment within its event. It is derived from
+	// the content, so attaching the same file twice yields one attachment.
+	ID          string    `json:"id"`
+	Name        string    `json:"name"` // Base name of the original file.
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"` // Hex digest of the content.
+	AddedAt     time.Time `json:"addedAt"`
+}
+
+// Store keeps attachments and a note per card event. Events are identified
+// by gnosispay.CardEvent.ID.
+type Store interface {
+	// Add stores the content of r as an attachment named name. Adding
+	// content the event already has returns the existing attachment.
+	Add(ctx context.Context, event, name string, r io.Reader) (Attachment, error)
+	// List returns the attachments of an event, oldest first.
+	List(ctx context.Context, event string) ([]Attachment, error)
+	// Open returns the content of an attachment, or ErrNotFound.
+	Open(ctx context.Context, event, id string) (io.ReadCloser, error)
+	// Delete removes an attachment, or returns ErrNotFound.
+	Delete(ctx context.Context, event, id string) error
+
+	// Note returns the note on an event, or "" if there is none.
+	Note(ctx context.Context, event string) (string, error)
+	// SetNote replaces the note on an event; an empty note removes it.
+	SetNote(ctx context.Context, event, note string) error
+}
+
+// Read reads an attachment's content from r and describes it, leaving
+// AddedAt to the caller. Store implementations use it to validate names
+// and derive IDs the same way.
+func Read(name string, r io.Reader) (Attachment, []byte, error) {
+	name = path.Base(strings.ReplaceAll(strings.TrimSpace(name), `\`, "/"))
+	if name == "" || name == "." || name == "/" || name == ".." {
+		return Attachment{}, nil, fmt.Errorf("receipts: attachment name is required")
+	}
+	data, err := io.ReadAll(io.LimitReader(r, MaxSize+1))
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+	if len(data) > MaxSize {
+		return Attachment{}, nil, ErrTooLarge
+	}
+	if len(data) == 0 {
+		return Attachment{}, nil, fmt.Errorf("receipts: attachment %s is empty", name)
+	}
+
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	contentType := mime.TypeByExtension(strings.ToLower(path.Ext(name)))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return Attachment{
+		ID:          digest[:16],
+		Name:        name,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		SHA256:      digest,
+	}, data, nil
+}
+
+// MissingOptions configures Missing.
+type MissingOptions struct {
+	// Min is the smallest billing amount that needs a receipt. The zero
+	// value means every payment does.
+	Min gnosispay.Money
+
+	// Rates converts amounts billed in a currency other than Min's. Without
+	// Rates, or without a rate for the day, such payments always need a
+	// receipt.
+	Rates *fx.Rates
+}
+
+// Requires reports whether e needs a receipt: it is an approved payment
+// that is not below o.Min. A nil o requires one for every payment.
+func (o *MissingOptions) Requires(e gnosispay.CardEvent) bool {
+	if e.Kind != gnosispay.CardEventPayment || !e.Counts() {
+		return false
+	}
+	return o == nil || !o.below(e)
+}
+
+// Missing returns the events that need a receipt, as decided by
+// opts.Requires, but have no attachment. A note alone does not count as a
+// receipt.
+func Missing(ctx context.Context, s Store, events []gnosispay.CardEvent, opts *MissingOptions) ([]gnosispay.CardEvent, error) {
+	var missing []gnosispay.CardEvent
+	for _, e := range events {
+		if !opts.Requires(e) {
+			continue
+		}
+		atts, err := s.List(ctx, e.ID())
+		if err != nil {
+			return nil, err
+		}
+		if len(atts) == 0 {
+			missing = append(missing, e)
+		}
+	}
+	return missing, nil
+}
+
+// below reports whether e is known to be billed less than o.Min.
+func (o *MissingOptions) below(e gnosispay.CardEvent) bool {
+	if o.Min.Currency.Code == "" {
+		return false
+	}
+	m, err := e.BillingMoney()
+	if err != nil {
+		return false
+	}
+	m = m.Abs()
+	if m.Currency.Code != o.Min.Currency.Code {
+		if o.Rates == nil {
+			return false
+		}
+		if m, err = o.Rates.Convert(m, o.Min.Currency, e.CreatedAt); err != nil {
+			return false
+		}
+	}
+	c, err := m.Cmp(o.Min)
+	return err == nil && c < 0
+}
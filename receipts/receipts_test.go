@@ -0,0 +1,262 @@
+package receipts
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/fx"
+	"github.com/guarilha/go-gnosispay/internal/eventtest"
+)
+
+func day(d int) time.Time {
+	return time.Date(2025, 1, d, 12, 0, 0, 0, time.UTC)
+}
+
+func newDirStore(t *testing.T) *DirStore {
+	return &DirStore{Root: t.TempDir(), Now: func() time.Time { return time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC) }}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name, content   string
+		wantName        string
+		wantContentType string
+		wantErr         string
+	}{
+		{"invoice.pdf", "%PDF-1.7", "invoice.pdf", "application/pdf", ""},
+		{`C:\scans\Taxi.PNG`, "png", "Taxi.PNG", "image/png", ""},
+		{"../../etc/passwd", "root", "passwd", "text/plain; charset=utf-8", ""},
+		{"receipt", "<html></html>", "receipt", "text/html; charset=utf-8", ""},
+		{"", "x", "", "", "name is required"},
+		{"..", "x", "", "", "name is required"},
+		{"empty.pdf", "", "", "", "is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, data, err := Read(tt.name, strings.NewReader(tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Read() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if a.Name != tt.wantName || a.ContentType != tt.wantContentType || string(data) != tt.content {
+				t.Errorf("Read() = %q, %q, %q; want %q, %q, %q", a.Name, a.ContentType, data, tt.wantName, tt.wantContentType, tt.content)
+			}
+			if a.Size != int64(len(tt.content)) || len(a.ID) != 16 || !strings.HasPrefix(a.SHA256, a.ID) {
+				t.Errorf("Read() size, ID, digest = %d, %q, %q", a.Size, a.ID, a.SHA256)
+			}
+		})
+	}
+
+	if _, _, err := Read("big.pdf", io.LimitReader(zeros{}, MaxSize+1)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Read() error = %v, want ErrTooLarge", err)
+	}
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestDirStore(t *testing.T) {
+	ctx := context.Background()
+	s := newDirStore(t)
+	id := eventtest.Payment(day(3), "2500", eventtest.EUR, eventtest.Merchant("Taxi"), eventtest.Thread("thread-0xAB"), eventtest.Hash("0xAB")).ID()
+
+	a, err := s.Add(ctx, id, "taxi.pdf", strings.NewReader("receipt 1"))
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if !a.AddedAt.Equal(s.Now()) {
+		t.Errorf("Add() AddedAt = %s, want %s", a.AddedAt, s.Now())
+	}
+	again, err := s.Add(ctx, id, "copy.pdf", strings.NewReader("receipt 1"))
+	if err != nil || again != a {
+		t.Errorf("Add() of the same content = %+v, %v, want %+v", again, err, a)
+	}
+	b, err := s.Add(ctx, id, "tip.jpg", strings.NewReader("receipt 2"))
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	// A new store on the same directory sees the same data.
+	s = &DirStore{Root: s.Root}
+	atts, err := s.List(ctx, id)
+	if err != nil || len(atts) != 2 || atts[0].ID != a.ID || atts[1].ID != b.ID {
+		t.Fatalf("List() = %+v, %v, want [%s %s]", atts, err, a.ID, b.ID)
+	}
+	rc, err := s.Open(ctx, id, b.ID)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "receipt 2" {
+		t.Errorf("Open() content = %q, want %q", data, "receipt 2")
+	}
+
+	if err := s.Delete(ctx, id, a.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := s.Delete(ctx, id, a.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() again error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Open(ctx, id, a.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open() deleted error = %v, want ErrNotFound", err)
+	}
+	if atts, _ := s.List(ctx, "other"); len(atts) != 0 {
+		t.Errorf("List() of another event = %+v, want none", atts)
+	}
+
+	if err := s.SetNote(ctx, id, "  Client dinner  "); err != nil {
+		t.Fatalf("SetNote() error = %v", err)
+	}
+	if note, err := s.Note(ctx, id); err != nil || note != "Client dinner" {
+		t.Errorf("Note() = %q, %v, want %q", note, err, "Client dinner")
+	}
+	if note, err := s.Note(ctx, "other"); err != nil || note != "" {
+		t.Errorf("Note() of another event = %q, %v, want empty", note, err)
+	}
+}
+
+func TestMissing(t *testing.T) {
+	ctx := context.Background()
+	s := newDirStore(t)
+
+	withReceipt := eventtest.Payment(day(3), "20000", eventtest.EUR, eventtest.Merchant("Hotel"), eventtest.Thread("thread-0x1"), eventtest.Hash("0x1"))
+	withNote := eventtest.Payment(day(3), "2500", eventtest.EUR, eventtest.Merchant("Taxi"), eventtest.Thread("thread-0x2"), eventtest.Hash("0x2"))
+	small := eventtest.Payment(day(3), "350", eventtest.EUR, eventtest.Merchant("Coffee"), eventtest.Thread("thread-0x3"), eventtest.Hash("0x3"))
+	declined := eventtest.Payment(day(3), "20000", eventtest.EUR, eventtest.Status(gnosispay.CardEventInsufficientFunds), eventtest.Merchant("Hotel"), eventtest.Thread("thread-0x4"), eventtest.Hash("0x4"))
+	dollars := eventtest.Payment(day(3), "1100", eventtest.USD, eventtest.Merchant("Diner"), eventtest.Thread("thread-0x5"), eventtest.Hash("0x5"))
+	fewDollars := eventtest.Payment(day(3), "500", eventtest.USD, eventtest.Merchant("Kiosk"), eventtest.Thread("thread-0x6"), eventtest.Hash("0x6"))
+	refund := eventtest.Refund(day(4), "5000", eventtest.EUR, eventtest.Merchant("Hotel"), eventtest.Thread("thread-0x7"), eventtest.Hash("0x7"))
+
+	if _, err := s.Add(ctx, withReceipt.ID(), "hotel.pdf", strings.NewReader("invoice")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetNote(ctx, withNote.ID(), "lost the receipt"); err != nil {
+		t.Fatal(err)
+	}
+	events := []gnosispay.CardEvent{withReceipt, withNote, small, declined, dollars, fewDollars, refund}
+
+	rates := fx.NewRates(gnosispay.EUR)
+	rates.Set(time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC), gnosispay.USD, big.NewRat(104, 100))
+
+	tests := []struct {
+		name string
+		opts *MissingOptions
+		want []string
+	}{
+		{"no threshold", nil, []string{"Taxi", "Coffee", "Diner", "Kiosk"}},
+		{"threshold", &MissingOptions{Min: gnosispay.NewMoney(1000, *eventtest.EUR)}, []string{"Taxi", "Diner", "Kiosk"}},
+		{"threshold with rates", &MissingOptions{Min: gnosispay.NewMoney(1000, *eventtest.EUR), Rates: rates}, []string{"Taxi", "Diner"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Missing(ctx, s, events, tt.opts)
+			if err != nil {
+				t.Fatalf("Missing() error = %v", err)
+			}
+			var names []string
+			for _, e := range got {
+				names = append(names, e.Merchant.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Missing() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissing_attachedWhilePending(t *testing.T) {
+	ctx := context.Background()
+	s := newDirStore(t)
+	pending := eventtest.Payment(day(3), "2500", eventtest.EUR, eventtest.Merchant("Taxi"), eventtest.Thread("thread-taxi"), eventtest.Pending())
+	if _, err := s.Add(ctx, pending.ID(), "taxi.pdf", strings.NewReader("receipt")); err != nil {
+		t.Fatal(err)
+	}
+
+	cleared := eventtest.Payment(day(3), "2650", eventtest.EUR, eventtest.Merchant("Taxi"), eventtest.Thread("thread-taxi"), eventtest.Hash("0xAB"))
+	got, err := Missing(ctx, s, []gnosispay.CardEvent{cleared}, nil)
+	if err != nil || len(got) != 0 {
+		t.Errorf("Missing() after clearing = %v, %v; want none", got, err)
+	}
+}
+
+func TestWriteBundle(t *testing.T) {
+	ctx := context.Background()
+	s := newDirStore(t)
+	hotel := eventtest.Payment(day(3), "20000", eventtest.EUR, eventtest.Merchant("Hotel"), eventtest.Thread("thread-0x1"), eventtest.Hash("0x1"))
+	taxi := eventtest.Payment(day(4), "2500", eventtest.EUR, eventtest.Merchant("Taxi"), eventtest.Thread("thread-0x2"), eventtest.Hash("0x2"))
+
+	for _, name := range []string{"folio.pdf", "minibar.pdf"} {
+		if _, err := s.Add(ctx, hotel.ID(), name, strings.NewReader(name+" content")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.SetNote(ctx, taxi.ID(), "Airport, no receipt"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBundle(ctx, &buf, s, []gnosispay.CardEvent{hotel, taxi}); err != nil {
+		t.Fatalf("WriteBundle() error = %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("reading bundle: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	if got := files["receipts/2025-01-03_002_minibar.pdf"]; got != "minibar.pdf content" {
+		t.Errorf("bundle minibar.pdf = %q", got)
+	}
+	rows, err := csv.NewReader(strings.NewReader(files["index.csv"])).ReadAll()
+	if err != nil {
+		t.Fatalf("reading index: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("index has %d rows, want 4: %v", len(rows), rows)
+	}
+	want := [][]string{
+		{"Hotel", "200.00", "EUR", "", "receipts/2025-01-03_001_folio.pdf", "folio.pdf"},
+		{"Hotel", "200.00", "EUR", "", "receipts/2025-01-03_002_minibar.pdf", "minibar.pdf"},
+		{"Taxi", "25.00", "EUR", "Airport, no receipt", "", ""},
+	}
+	for i, w := range want {
+		row := rows[i+1]
+		got := []string{row[4], row[5], row[6], row[7], row[8], row[9]}
+		if strings.Join(got, "|") != strings.Join(w, "|") {
+			t.Errorf("index row %d = %q, want %q", i+1, got, w)
+		}
+		if row[0] == "" || row[0] != []string{hotel.ID(), hotel.ID(), taxi.ID()}[i] {
+			t.Errorf("index row %d id = %q", i+1, row[0])
+		}
+	}
+}
@@ -0,0 +1,319 @@
+// Package recurring detects subscriptions and other recurring charges in
+// card event history.
+//
+// Charges are grouped by merchant and the currency the merchant charged in.
+// A group is recurring when its charges follow a regular cadence (weekly,
+// every two weeks, monthly, quarterly or yearly) at a mostly stable amount.
+// A merchant charging several subscriptions is split by amount when the
+// group as a whole is irregular.
+package recurring
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/analytics"
+)
+
+// Cadence is the interval between recurring charges.
+type Cadence string
+
+const (
+	Weekly    Cadence = "weekly"
+	Biweekly  Cadence = "biweekly"
+	Monthly   Cadence = "monthly"
+	Quarterly Cadence = "quarterly"
+	Yearly    Cadence = "yearly"
+)
+
+// cadences lists the accepted interval windows in days and the grace period
+// after which an expected charge counts as missed.
+var cadences = []struct {
+	cadence  Cadence
+	min, max float64
+	grace    time.Duration
+}{
+	{Weekly, 6, 8, 2 * 24 * time.Hour},
+	{Biweekly, 13, 15, 3 * 24 * time.Hour},
+	{Monthly, 27, 33, 5 * 24 * time.Hour},
+	{Quarterly, 85, 97, 10 * 24 * time.Hour},
+	{Yearly, 355, 375, 14 * 24 * time.Hour},
+}
+
+// next returns the expected time of the charge after t. Month-based
+// cadences bill on day, or on the last day of shorter months.
+func (c Cadence) next(t time.Time, day int) time.Time {
+	switch c {
+	case Weekly:
+		return t.AddDate(0, 0, 7)
+	case Biweekly:
+		return t.AddDate(0, 0, 14)
+	case Quarterly:
+		return addMonths(t, 3, day)
+	case Yearly:
+		return addMonths(t, 12, day)
+	default:
+		return addMonths(t, 1, day)
+	}
+}
+
+// addMonths moves t by n months to the given day, clamped to the month's
+// length, instead of overflowing into the next month like time.AddDate.
+func addMonths(t time.Time, n, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// Options tune Detect. The zero value uses the defaults.
+type Options struct {
+	// MinCharges is the number of charges needed to call a series
+	// recurring. Defaults to 3.
+	MinCharges int
+
+	// Tolerance is the relative amount difference still considered the
+	// same charge, e.g. 0.15 for 15%. Defaults to 0.15.
+	Tolerance float64
+
+	// Now is the reference time for missed charges. Defaults to
+	// time.Now().
+	Now time.Time
+}
+
+func (o *Options) withDefaults() Options {
+	var out Options
+	if o != nil {
+		out = *o
+	}
+	if out.MinCharges < 2 {
+		out.MinCharges = 3
+	}
+	if out.Tolerance <= 0 {
+		out.Tolerance = 0.15
+	}
+	if out.Now.IsZero() {
+		out.Now = time.Now()
+	}
+	return out
+}
+
+// Charge is one payment of a series.
+type Charge struct {
+	At     time.Time           `json:"at"`
+	Amount gnosispay.Money     `json:"amount"` // In the merchant's currency.
+	Event  gnosispay.CardEvent `json:"event"`
+}
+
+// PriceChange is a change of the charged amount between two consecutive
+// charges.
+type PriceChange struct {
+	At   time.Time       `json:"at"`
+	From gnosispay.Money `json:"from"`
+	To   gnosispay.Money `json:"to"`
+}
+
+// Increase reports whether the price went up.
+func (p PriceChange) Increase() bool {
+	c, err := p.To.Cmp(p.From)
+	return err == nil && c > 0
+}
+
+// Series is a detected recurring charge.
+type Series struct {
+	Merchant string   `json:"merchant"` // Normalized as analytics.ByMerchant.
+	Cadence  Cadence  `json:"cadence"`
+	Charges  []Charge `json:"charges"` // Oldest first.
+
+	// NextAt and NextAmount estimate the next charge from the last one.
+	NextAt     time.Time       `json:"nextAt"`
+	NextAmount gnosispay.Money `json:"nextAmount"`
+
+	// PriceChanges lists every change of the exact charged amount.
+	PriceChanges []PriceChange `json:"priceChanges,omitempty"`
+
+	// Missed counts expected charges that are overdue by more than the
+	// cadence's grace period.
+	Missed int `json:"missed"`
+}
+
+// Last returns the most recent charge.
+func (s Series) Last() Charge {
+	return s.Charges[len(s.Charges)-1]
+}
+
+// PriceIncreased reports whether the latest price change was an increase.
+func (s Series) PriceIncreased() bool {
+	return len(s.PriceChanges) > 0 && s.PriceChanges[len(s.PriceChanges)-1].Increase()
+}
+
+// Detect finds recurring charges among events. Refunds, declined and
+// reversed payments are ignored, as are payments undone by a reversal event
+// among events. Series are ordered by merchant, then by amount.
+func Detect(events []gnosispay.CardEvent, opts *Options) []Series {
+	o := opts.withDefaults()
+
+	undone := map[string]bool{}
+	for _, e := range events {
+		if e.Kind != gnosispay.CardEventReversal {
+			continue
+		}
+		if orig, ok := e.Original(events); ok {
+			undone[chargeKey(orig)] = true
+		}
+	}
+
+	groups := map[[2]string][]Charge{}
+	var keys [][2]string
+	for _, e := range events {
+		if m, ok := analytics.Spending(e); !ok || m.Sign() <= 0 || undone[chargeKey(e)] {
+			continue
+		}
+		amount, err := e.TransactionMoney()
+		if err != nil {
+			if amount, err = e.BillingMoney(); err != nil {
+				continue
+			}
+		}
+		k := [2]string{analytics.ByMerchant(e), amount.Currency.Code}
+		if k[0] == analytics.Unknown {
+			continue
+		}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], Charge{At: e.CreatedAt, Amount: amount.Abs(), Event: e})
+	}
+
+	var out []Series
+	for _, k := range keys {
+		charges := groups[k]
+		sort.SliceStable(charges, func(i, j int) bool { return charges[i].At.Before(charges[j].At) })
+		if s, ok := detect(k[0], charges, o); ok {
+			out = append(out, s)
+			continue
+		}
+		for _, c := range cluster(charges, o.Tolerance) {
+			if s, ok := detect(k[0], c, o); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Merchant != out[j].Merchant {
+			return out[i].Merchant < out[j].Merchant
+		}
+		c, _ := out[i].Last().Amount.Cmp(out[j].Last().Amount)
+		return c < 0
+	})
+	return out
+}
+
+// chargeKey identifies a payment. Key alone is ambiguous for events without
+// transaction hashes, so the merchant and amount are added.
+func chargeKey(e gnosispay.CardEvent) string {
+	return e.Key() + "|" + analytics.ByMerchant(e) + "|" + e.BillingAmount
+}
+
+// detect checks whether charges, sorted by time, form a recurring series.
+func detect(merchant string, charges []Charge, o Options) (Series, bool) {
+	if len(charges) < o.MinCharges {
+		return Series{}, false
+	}
+	days := make([]float64, 0, len(charges)-1)
+	for i := 1; i < len(charges); i++ {
+		days = append(days, charges[i].At.Sub(charges[i-1].At).Hours()/24)
+	}
+	sorted := append([]float64(nil), days...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	idx := -1
+	for i, c := range cadences {
+		if median >= c.min && median <= c.max {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return Series{}, false
+	}
+	c := cadences[idx]
+	var regular, stable int
+	for i, d := range days {
+		if d >= c.min && d <= c.max {
+			regular++
+		}
+		if similar(charges[i].Amount, charges[i+1].Amount, o.Tolerance) {
+			stable++
+		}
+	}
+	// Allow the odd skipped or extra charge and the odd price change.
+	if regular*4 < len(days)*3 || stable*5 < len(days)*3 {
+		return Series{}, false
+	}
+
+	s := Series{Merchant: merchant, Cadence: c.cadence, Charges: charges}
+	for i := 1; i < len(charges); i++ {
+		if prev, cur := charges[i-1].Amount, charges[i].Amount; !prev.Equal(cur) {
+			s.PriceChanges = append(s.PriceChanges, PriceChange{At: charges[i].At, From: prev, To: cur})
+		}
+	}
+	// A charge on the last day of a month bills at the end of each month.
+	last := s.Last()
+	day := last.At.Day()
+	if last.At.AddDate(0, 0, 1).Month() != last.At.Month() {
+		day = 31
+	}
+	s.NextAt = c.cadence.next(last.At, day)
+	s.NextAmount = last.Amount
+	for due := s.NextAt; o.Now.After(due.Add(c.grace)); due = c.cadence.next(due, day) {
+		s.Missed++
+	}
+	return s, true
+}
+
+// cluster splits charges into groups of similar amounts, keeping time
+// order within each group.
+func cluster(charges []Charge, tolerance float64) [][]Charge {
+	var groups [][]Charge
+	for _, ch := range charges {
+		placed := false
+		for i, g := range groups {
+			if similar(g[0].Amount, ch.Amount, tolerance) {
+				groups[i] = append(g, ch)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			groups = append(groups, []Charge{ch})
+		}
+	}
+	return groups
+}
+
+// similar reports whether a and b differ by at most tolerance relative to
+// the larger amount.
+func similar(a, b gnosispay.Money, tolerance float64) bool {
+	if !strings.EqualFold(a.Currency.Code, b.Currency.Code) {
+		return false
+	}
+	x := new(big.Float).SetInt(a.MinorUnits())
+	y := new(big.Float).SetInt(b.MinorUnits())
+	larger := x
+	if y.Cmp(x) > 0 {
+		larger = y
+	}
+	if larger.Sign() == 0 {
+		return true
+	}
+	diff := new(big.Float).Sub(x, y)
+	diff.Abs(diff)
+	limit := new(big.Float).Mul(larger, big.NewFloat(tolerance))
+	return diff.Cmp(limit) <= 0
+}
@@ -0,0 +1,113 @@
+package recurring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	"github.com/guarilha/go-gnosispay/internal/eventtest"
+)
+
+// charge returns a payment of amount in cur, billed as the same number of
+// euro cents.
+func charge(at time.Time, merchant, amount string, cur *gnosispay.Currency) gnosispay.CardEvent {
+	return eventtest.Payment(at, amount, eventtest.EUR, eventtest.Merchant(merchant), eventtest.Transaction(amount, cur))
+}
+
+func monthly(merchant string, from time.Time, amounts ...string) []gnosispay.CardEvent {
+	var out []gnosispay.CardEvent
+	for i, a := range amounts {
+		out = append(out, charge(addMonths(from, i, from.Day()), merchant, a, eventtest.EUR))
+	}
+	return out
+}
+
+func TestDetect(t *testing.T) {
+	start := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	var events []gnosispay.CardEvent
+	// Price increase in April.
+	events = append(events, monthly("Netflix", start, "1299", "1299", "1299", "1499", "1499")...)
+	// Two plans at one merchant.
+	events = append(events, monthly("GitHub", start, "400", "400", "400", "400", "400")...)
+	events = append(events, monthly("github ", start.AddDate(0, 0, 3), "2100", "2100", "2100", "2100", "2100")...)
+	// Yearly, in dollars.
+	for i := 0; i < 3; i++ {
+		events = append(events, charge(start.AddDate(i-2, 0, 0), "JetBrains", "24900", eventtest.USD))
+	}
+	// Weekly with a refund in between.
+	for i := 0; i < 6; i++ {
+		events = append(events, charge(start.AddDate(0, 0, 7*i), "Gym", "1000", eventtest.EUR))
+	}
+	events = append(events, eventtest.Refund(start.AddDate(0, 0, 8), "1000", eventtest.EUR,
+		eventtest.Merchant("Gym"), eventtest.Transaction("1000", eventtest.EUR)))
+	// Irregular spending and too few charges are not recurring.
+	for _, d := range []int{0, 1, 3, 4, 9, 20, 21, 40} {
+		events = append(events, charge(start.AddDate(0, 0, d), "Cafe", "450", eventtest.EUR))
+	}
+	for _, d := range []int{0, 7, 14, 21} {
+		events = append(events, charge(start.AddDate(0, 0, d), "Shell", []string{"4000", "1500", "6200", "2500"}[d/7], eventtest.EUR))
+	}
+	events = append(events, monthly("Spotify", start, "1099", "1099")...)
+	// A third charge that was reversed does not make it recurring.
+	reversed := charge(start.AddDate(0, 2, 0), "Spotify", "1099", eventtest.EUR)
+	reversal := eventtest.Reversal(start.AddDate(0, 2, 1), "1099", eventtest.EUR,
+		eventtest.Merchant("Spotify"), eventtest.Transaction("1099", eventtest.EUR))
+	events = append(events, reversed, reversal)
+
+	now := time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC)
+	got := Detect(events, &Options{Now: now})
+
+	type want struct {
+		merchant, cadence, next, nextAmount string
+		charges, changes, missed            int
+		increased                           bool
+	}
+	wants := []want{
+		{"GITHUB", "monthly", "2025-06-15", "4.00 EUR", 5, 0, 0, false},
+		{"GITHUB", "monthly", "2025-06-18", "21.00 EUR", 5, 0, 0, false},
+		{"GYM", "weekly", "2025-02-26", "10.00 EUR", 6, 0, 16, false},
+		{"JETBRAINS", "yearly", "2026-01-15", "249.00 USD", 3, 0, 0, false},
+		{"NETFLIX", "monthly", "2025-06-15", "14.99 EUR", 5, 1, 0, true},
+	}
+	if len(got) != len(wants) {
+		for _, s := range got {
+			t.Logf("%s %s %d", s.Merchant, s.Cadence, len(s.Charges))
+		}
+		t.Fatalf("got %d series, want %d", len(got), len(wants))
+	}
+	for i, w := range wants {
+		s := got[i]
+		if s.Merchant != w.merchant || string(s.Cadence) != w.cadence || len(s.Charges) != w.charges {
+			t.Errorf("series %d = %s %s with %d charges, want %s %s with %d", i, s.Merchant, s.Cadence, len(s.Charges), w.merchant, w.cadence, w.charges)
+		}
+		if next := s.NextAt.Format(time.DateOnly); next != w.next || s.NextAmount.String() != w.nextAmount {
+			t.Errorf("%s next = %s %s, want %s %s", s.Merchant, next, s.NextAmount, w.next, w.nextAmount)
+		}
+		if len(s.PriceChanges) != w.changes || s.PriceIncreased() != w.increased || s.Missed != w.missed {
+			t.Errorf("%s changes = %d, increased = %v, missed = %d; want %d, %v, %d",
+				s.Merchant, len(s.PriceChanges), s.PriceIncreased(), s.Missed, w.changes, w.increased, w.missed)
+		}
+	}
+	if c := got[4].PriceChanges[0]; c.From.String() != "12.99 EUR" || c.To.String() != "14.99 EUR" || c.At.Month() != time.April {
+		t.Errorf("netflix price change = %+v", c)
+	}
+}
+
+func TestDetect_missedMonthly(t *testing.T) {
+	start := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	events := monthly("Notion", start, "800", "800", "800")
+	tests := []struct {
+		now    time.Time
+		missed int
+	}{
+		{time.Date(2025, 5, 4, 0, 0, 0, 0, time.UTC), 0}, // Due 2025-04-30, with 5 days grace.
+		{time.Date(2025, 5, 6, 0, 0, 0, 0, time.UTC), 1},
+		{time.Date(2025, 7, 6, 0, 0, 0, 0, time.UTC), 3},
+	}
+	for _, tt := range tests {
+		s := Detect(events, &Options{Now: tt.now})
+		if len(s) != 1 || s[0].Missed != tt.missed || !s[0].NextAt.Equal(time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("at %s: series = %+v, want %d missed", tt.now.Format(time.DateOnly), s, tt.missed)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package gnosispay
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// secureSessionInfo is the HKDF info string binding derived keys to this
+// protocol.
+const secureSessionInfo = "gnosispay secure card session v1"
+
+// Purposes bound into the additional data of each sealed payload, so a
+// payload cannot be replayed to a different endpoint.
+const (
+	securePurposeDetails   = "details"
+	securePurposePIN       = "pin"
+	securePurposeChangePIN = "change-pin"
+)
+
+// SecureSession is an end-to-end encrypted channel for reading and
+// changing a card's sensitive data. Both sides contribute an ephemeral
+// X25519 key; payloads are sealed with AES-256-GCM under a key derived
+// from the shared secret, so neither the transport nor the client's debug
+// logging ever sees them in plaintext.
+//
+// A session belongs to a single card. Close it when done to wipe the key.
+type SecureSession struct {
+	cards     *CardService
+	cardID    string
+	id        string
+	key       []byte
+	ExpiresAt time.Time
+}
+
+// SealedPayload is a payload encrypted with a SecureSession key.
+type SealedPayload struct {
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// NewSecureSession establishes an encrypted session for the card.
+func (s *CardService) NewSecureSession(ctx context.Context, cardID string) (*SecureSession, error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session key: %w", err)
+	}
+
+	path := fmt.Sprintf("/api/v1/cards/%s/secure-session", cardID)
+	body := struct {
+		PublicKey []byte `json:"publicKey"`
+	}{priv.PublicKey().Bytes()}
+	req, err := s.client.NewRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		SessionID       string    `json:"sessionId"`
+		ServerPublicKey []byte    `json:"serverPublicKey"`
+		ExpiresAt       time.Time `json:"expiresAt"`
+	}
+	if err := s.client.Do(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.SessionID == "" {
+		return nil, fmt.Errorf("secure session response has no session ID")
+	}
+
+	peer, err := ecdh.X25519().NewPublicKey(resp.ServerPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server public key: %w", err)
+	}
+	shared, err := priv.ECDH(peer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive session key: %w", err)
+	}
+	defer zero(shared)
+
+	return &SecureSession{
+		cards:     s,
+		cardID:    cardID,
+		id:        resp.SessionID,
+		key:       DeriveSessionKey(shared, resp.SessionID),
+		ExpiresAt: resp.ExpiresAt,
+	}, nil
+}
+
+// DeriveSessionKey derives the AES-256 key of a session from the X25519
+// shared secret with HKDF-SHA256, salted with the session ID. It is
+// exported for servers and test doubles implementing the other side.
+func DeriveSessionKey(shared []byte, sessionID string) []byte {
+	extract := hmac.New(sha256.New, []byte(sessionID))
+	extract.Write(shared)
+	prk := extract.Sum(nil)
+	defer zero(prk)
+
+	// A single expand block yields the 32 bytes needed.
+	expand := hmac.New(sha256.New, prk)
+	expand.Write([]byte(secureSessionInfo))
+	expand.Write([]byte{1})
+	return expand.Sum(nil)
+}
+
+// SealPayload encrypts plaintext under key for the given session, card and
+// purpose. It is exported for servers and test doubles.
+func SealPayload(key []byte, sessionID, cardID, purpose string, plaintext []byte) (*SealedPayload, error) {
+	aead, err := newSessionAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ad := sessionAD(sessionID, cardID, purpose)
+	return &SealedPayload{Nonce: nonce, Ciphertext: aead.Seal(nil, nonce, plaintext, ad)}, nil
+}
+
+// OpenPayload decrypts a payload sealed with SealPayload. The caller must
+// zero the returned plaintext after use.
+func OpenPayload(key []byte, sessionID, cardID, purpose string, p *SealedPayload) ([]byte, error) {
+	aead, err := newSessionAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid payload nonce length %d", len(p.Nonce))
+	}
+	plaintext, err := aead.Open(nil, p.Nonce, p.Ciphertext, sessionAD(sessionID, cardID, purpose))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s payload: %w", purpose, err)
+	}
+	return plaintext, nil
+}
+
+func newSessionAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secure session is closed")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sessionAD(sessionID, cardID, purpose string) []byte {
+	return []byte(sessionID + "|" + cardID + "|" + purpose)
+}
+
+// Close wipes the session key. The session cannot be used afterwards.
+func (ss *SecureSession) Close() {
+	zero(ss.key)
+	ss.key = nil
+}
+
+// fetch posts the session ID to path and opens the sealed response.
+func (ss *SecureSession) fetch(ctx context.Context, path, purpose string) ([]byte, error) {
+	if ss.key == nil {
+		return nil, fmt.Errorf("secure session is closed")
+	}
+	body := struct {
+		SessionID string `json:"sessionId"`
+	}{ss.id}
+	req, err := ss.cards.client.NewRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var sealed SealedPayload
+	if err := ss.cards.client.Do(ctx, req, &sealed); err != nil {
+		return nil, err
+	}
+	return OpenPayload(ss.key, ss.id, ss.cardID, purpose, &sealed)
+}
+
+// Details retrieves the card's full number, CVV and expiry date. Call Zero
+// on the result when done with it.
+func (ss *SecureSession) Details(ctx context.Context) (*CardDetails, error) {
+	plaintext, err := ss.fetch(ctx, fmt.Sprintf("/api/v1/cards/%s/sensitive-details", ss.cardID), securePurposeDetails)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(plaintext)
+
+	var d CardDetails
+	if err := json.Unmarshal(plaintext, &d); err != nil {
+		d.Zero()
+		// The error may quote the payload, so it is not wrapped.
+		return nil, fmt.Errorf("failed to decode card details")
+	}
+	return &d, nil
+}
+
+// PIN retrieves the card's PIN. Call Zero on the result when done with it.
+func (ss *SecureSession) PIN(ctx context.Context) (SecretDigits, error) {
+	plaintext, err := ss.fetch(ctx, fmt.Sprintf("/api/v1/cards/%s/pin", ss.cardID), securePurposePIN)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(plaintext)
+
+	var p struct {
+		PIN SecretDigits `json:"pin"`
+	}
+	if err := json.Unmarshal(plaintext, &p); err != nil {
+		p.PIN.Zero()
+		return nil, fmt.Errorf("failed to decode PIN")
+	}
+	return p.PIN, nil
+}
+
+// ChangePIN sets a new PIN for the card. The PIN must be 4 digits. pin is
+// left untouched; the caller remains responsible for zeroing it.
+func (ss *SecureSession) ChangePIN(ctx context.Context, pin SecretDigits) error {
+	if ss.key == nil {
+		return fmt.Errorf("secure session is closed")
+	}
+	if err := pin.validatePIN(); err != nil {
+		return err
+	}
+
+	// Built by hand: encoding/json would leave copies in its buffer pool.
+	plaintext := make([]byte, 0, len(pin)+10)
+	plaintext = append(plaintext, `{"pin":"`...)
+	plaintext = append(plaintext, pin...)
+	plaintext = append(plaintext, `"}`...)
+	sealed, err := SealPayload(ss.key, ss.id, ss.cardID, securePurposeChangePIN, plaintext)
+	zero(plaintext)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/api/v1/cards/%s/pin", ss.cardID)
+	body := struct {
+		SessionID string `json:"sessionId"`
+		*SealedPayload
+	}{ss.id, sealed}
+	req, err := ss.cards.client.NewRequest(ctx, http.MethodPut, path, body)
+	if err != nil {
+		return err
+	}
+
+	return ss.cards.client.Do(ctx, req, nil)
+}
+
+// GetSensitiveDetails retrieves a card's full number, CVV and expiry date
+// over a one-off SecureSession. Call Zero on the result when done with it.
+func (s *CardService) GetSensitiveDetails(ctx context.Context, cardID string) (*CardDetails, error) {
+	ss, err := s.NewSecureSession(ctx, cardID)
+	if err != nil {
+		return nil, err
+	}
+	defer ss.Close()
+	return ss.Details(ctx)
+}
+
+// GetPIN retrieves a card's PIN over a one-off SecureSession. Call Zero on
+// the result when done with it.
+func (s *CardService) GetPIN(ctx context.Context, cardID string) (SecretDigits, error) {
+	ss, err := s.NewSecureSession(ctx, cardID)
+	if err != nil {
+		return nil, err
+	}
+	defer ss.Close()
+	return ss.PIN(ctx)
+}
+
+// ChangePIN sets a card's PIN over a one-off SecureSession.
+func (s *CardService) ChangePIN(ctx context.Context, cardID string, pin SecretDigits) error {
+	ss, err := s.NewSecureSession(ctx, cardID)
+	if err != nil {
+		return err
+	}
+	defer ss.Close()
+	return ss.ChangePIN(ctx, pin)
+}
+
+// CardDetails holds a card's sensitive data. Its String and LogValue
+// methods mask the number and omit the CVV, so printing or logging it is
+// safe.
+type CardDetails struct {
+	PAN         SecretDigits `json:"pan"`
+	CVV         SecretDigits `json:"cvv"`
+	ExpiryMonth int          `json:"expiryMonth"`
+	ExpiryYear  int          `json:"expiryYear"`
+}
+
+// Zero wipes the number and CVV.
+func (d *CardDetails) Zero() {
+	d.PAN.Zero()
+	d.CVV.Zero()
+}
+
+func (d *CardDetails) String() string {
+	return fmt.Sprintf("%s %02d/%d", d.PAN.Masked(4), d.ExpiryMonth, d.ExpiryYear)
+}
+
+func (d *CardDetails) LogValue() slog.Value {
+	return slog.StringValue(d.String())
+}
+
+// SecretDigits is a sensitive digit string, such as a card number or PIN,
+// kept as bytes so it can be wiped with Zero. It formats, logs and encodes
+// to JSON as "[redacted]".
+type SecretDigits []byte
+
+// Zero overwrites the digits in place.
+func (s SecretDigits) Zero() {
+	zero(s)
+}
+
+// Masked returns the digits with all but the last n replaced by '*'.
+func (s SecretDigits) Masked(n int) string {
+	b := bytes.Repeat([]byte{'*'}, len(s))
+	if n > 0 && n < len(s) {
+		copy(b[len(b)-n:], s[len(s)-n:])
+	}
+	return string(b)
+}
+
+func (s SecretDigits) String() string { return "[redacted]" }
+
+func (s SecretDigits) GoString() string { return "[redacted]" }
+
+func (s SecretDigits) LogValue() slog.Value { return slog.StringValue("[redacted]") }
+
+// MarshalJSON encodes the digits as "[redacted]", so they cannot leak
+// through a JSON encoding either.
+func (s SecretDigits) MarshalJSON() ([]byte, error) {
+	return []byte(`"[redacted]"`), nil
+}
+
+// UnmarshalJSON decodes a JSON string of digits. The digits are copied
+// straight from data, without intermediate strings.
+func (s *SecretDigits) UnmarshalJSON(data []byte) error {
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("secret digits must be a JSON string")
+	}
+	digits := data[1 : len(data)-1]
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("secret digits must only contain digits")
+		}
+	}
+	*s = append(SecretDigits(nil), digits...)
+	return nil
+}
+
+func (s SecretDigits) validatePIN() error {
+	if len(s) != 4 {
+		return fmt.Errorf("PIN must be 4 digits")
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("PIN must only contain digits")
+		}
+	}
+	return nil
+}
+
+// zero overwrites b with zeros.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,213 @@
+package gnosispay
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// secureCardServer implements the server side of a SecureSession for
+// card-1.
+type secureCardServer struct {
+	t       *testing.T
+	details string
+	pin     string
+	keys    map[string][]byte // by session ID
+	tamper  bool
+}
+
+func (s *secureCardServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		PublicKey []byte `json:"publicKey"`
+		SessionID string `json:"sessionId"`
+		SealedPayload
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	seal := func(purpose, plaintext string) {
+		sealed, err := SealPayload(s.keys[body.SessionID], body.SessionID, "card-1", purpose, []byte(plaintext))
+		if err != nil {
+			s.t.Errorf("SealPayload() error = %v", err)
+		}
+		if s.tamper {
+			sealed.Ciphertext[0] ^= 1
+		}
+		json.NewEncoder(w).Encode(sealed)
+	}
+
+	switch r.Method + " " + r.URL.Path {
+	case "POST /api/v1/cards/card-1/secure-session":
+		peer, err := ecdh.X25519().NewPublicKey(body.PublicKey)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		priv, _ := ecdh.X25519().GenerateKey(rand.Reader)
+		shared, _ := priv.ECDH(peer)
+		id := fmt.Sprintf("session-%d", len(s.keys)+1)
+		s.keys[id] = DeriveSessionKey(shared, id)
+		json.NewEncoder(w).Encode(map[string]any{
+			"sessionId":       id,
+			"serverPublicKey": priv.PublicKey().Bytes(),
+			"expiresAt":       time.Date(2025, 1, 1, 0, 5, 0, 0, time.UTC),
+		})
+	case "POST /api/v1/cards/card-1/sensitive-details":
+		seal(securePurposeDetails, s.details)
+	case "POST /api/v1/cards/card-1/pin":
+		seal(securePurposePIN, `{"pin":"`+s.pin+`"}`)
+	case "PUT /api/v1/cards/card-1/pin":
+		plaintext, err := OpenPayload(s.keys[body.SessionID], body.SessionID, "card-1", securePurposeChangePIN, &body.SealedPayload)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ApiError{Message: err.Error()})
+			return
+		}
+		var p struct {
+			PIN string `json:"pin"`
+		}
+		json.Unmarshal(plaintext, &p)
+		s.pin = p.PIN
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newSecureCardClient(t *testing.T) (*secureCardServer, *Client) {
+	t.Helper()
+	s := &secureCardServer{
+		t:       t,
+		details: `{"pan":"4111111111111111","cvv":"123","expiryMonth":7,"expiryYear":2029}`,
+		pin:     "2580",
+		keys:    map[string][]byte{},
+	}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	client, _ := New(nil, SetBaseURL(server.URL))
+	return s, client
+}
+
+func TestCardService_GetSensitiveDetails(t *testing.T) {
+	_, client := newSecureCardClient(t)
+
+	d, err := client.Cards.GetSensitiveDetails(context.Background(), "card-1")
+	if err != nil {
+		t.Fatalf("GetSensitiveDetails() error = %v", err)
+	}
+	if string(d.PAN) != "4111111111111111" || string(d.CVV) != "123" || d.ExpiryMonth != 7 || d.ExpiryYear != 2029 {
+		t.Errorf("GetSensitiveDetails() = PAN %s, CVV %s, expiry %d/%d", d.PAN.Masked(0), d.CVV.Masked(0), d.ExpiryMonth, d.ExpiryYear)
+	}
+
+	d.Zero()
+	if !bytes.Equal(d.PAN, make([]byte, 16)) || !bytes.Equal(d.CVV, make([]byte, 3)) {
+		t.Errorf("Zero() left %v %v", []byte(d.PAN), []byte(d.CVV))
+	}
+}
+
+func TestSecureSession(t *testing.T) {
+	s, client := newSecureCardClient(t)
+	ctx := context.Background()
+
+	ss, err := client.Cards.NewSecureSession(ctx, "card-1")
+	if err != nil {
+		t.Fatalf("NewSecureSession() error = %v", err)
+	}
+	if !ss.ExpiresAt.Equal(time.Date(2025, 1, 1, 0, 5, 0, 0, time.UTC)) {
+		t.Errorf("ExpiresAt = %v", ss.ExpiresAt)
+	}
+
+	pin, err := ss.PIN(ctx)
+	if err != nil || string(pin) != "2580" {
+		t.Fatalf("PIN() = %q, %v", pin.Masked(0), err)
+	}
+
+	if err := ss.ChangePIN(ctx, SecretDigits("12a4")); err == nil {
+		t.Error("ChangePIN() with a letter expected error")
+	}
+	if err := ss.ChangePIN(ctx, SecretDigits("12345")); err == nil {
+		t.Error("ChangePIN() with 5 digits expected error")
+	}
+	if err := ss.ChangePIN(ctx, SecretDigits("9713")); err != nil {
+		t.Fatalf("ChangePIN() error = %v", err)
+	}
+	if s.pin != "9713" {
+		t.Errorf("server PIN = %q, want 9713", s.pin)
+	}
+
+	key := ss.key
+	ss.Close()
+	if !bytes.Equal(key, make([]byte, 32)) {
+		t.Error("Close() did not wipe the session key")
+	}
+	if _, err := ss.Details(ctx); err == nil {
+		t.Error("Details() on a closed session expected error")
+	}
+	if err := ss.ChangePIN(ctx, SecretDigits("1111")); err == nil {
+		t.Error("ChangePIN() on a closed session expected error")
+	}
+}
+
+func TestSecureSession_rejectsTamperedPayloads(t *testing.T) {
+	s, client := newSecureCardClient(t)
+	s.tamper = true
+
+	if _, err := client.Cards.GetSensitiveDetails(context.Background(), "card-1"); err == nil {
+		t.Error("GetSensitiveDetails() with tampered payload expected error")
+	}
+}
+
+func TestSecureSession_rejectsPayloadForOtherPurpose(t *testing.T) {
+	key := make([]byte, 32)
+	sealed, _ := SealPayload(key, "s", "card-1", securePurposePIN, []byte(`{"pin":"1234"}`))
+	if _, err := OpenPayload(key, "s", "card-1", securePurposeDetails, sealed); err == nil {
+		t.Error("OpenPayload() for another purpose expected error")
+	}
+	if _, err := OpenPayload(key, "s", "card-2", securePurposePIN, sealed); err == nil {
+		t.Error("OpenPayload() for another card expected error")
+	}
+}
+
+func TestSecureSession_neverLogsPlaintext(t *testing.T) {
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(prev)
+
+	_, client := newSecureCardClient(t)
+	ctx := context.Background()
+	d, err := client.Cards.GetSensitiveDetails(ctx, "card-1")
+	if err != nil {
+		t.Fatalf("GetSensitiveDetails() error = %v", err)
+	}
+	pin, err := client.Cards.GetPIN(ctx, "card-1")
+	if err != nil {
+		t.Fatalf("GetPIN() error = %v", err)
+	}
+	if err := client.Cards.ChangePIN(ctx, "card-1", SecretDigits("9713")); err != nil {
+		t.Fatalf("ChangePIN() error = %v", err)
+	}
+
+	slog.Info("revealed", "details", d, "pin", pin)
+	fmt.Fprintf(&logs, "%v %+v %#v %s\n", d, d, pin, pin)
+	b, _ := json.Marshal(d)
+	logs.Write(b)
+
+	// Base64 ciphertext may contain short digit runs, so look for the
+	// plaintext payloads and for the digits only as whole words.
+	for _, secret := range []string{"4111111111111111", `"cvv":"123"`, `"pin":"2580"`, `"pin":"9713"`, " 2580", "=2580"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("output contains %q:\n%s", secret, logs.String())
+		}
+	}
+	if !strings.Contains(logs.String(), "************1111 07/2029") {
+		t.Errorf("output lacks the masked card:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/guarilha/go-gnosispay/receipts"
+)
+
+// Receipts returns a receipts.Store that keeps attachments and notes in
+// the database.
+func (s *Store) Receipts() receipts.Store {
+	return receiptStore{s}
+}
+
+type receiptStore struct {
+	s *Store
+}
+
+func (r receiptStore) Add(ctx context.Context, event, name string, rd io.Reader) (receipts.Attachment, error) {
+	a, data, err := receipts.Read(name, rd)
+	if err != nil {
+		return receipts.Attachment{}, err
+	}
+	a.AddedAt = r.s.now().UTC()
+	_, err = r.s.db.ExecContext(ctx, `INSERT INTO attachments
+		(event_id, id, name, content_type, size, sha256, added_at, content)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (event_id, id) DO NOTHING`,
+		event, a.ID, a.Name, a.ContentType, a.Size, a.SHA256, formatTime(a.AddedAt), data)
+	if err != nil {
+		return receipts.Attachment{}, err
+	}
+	atts, err := r.list(ctx, "event_id = ? AND id = ?", event, a.ID)
+	if err != nil {
+		return receipts.Attachment{}, err
+	}
+	return atts[0], nil
+}
+
+func (r receiptStore) List(ctx context.Context, event string) ([]receipts.Attachment, error) {
+	return r.list(ctx, "event_id = ?", event)
+}
+
+func (r receiptStore) list(ctx context.Context, where string, args ...any) ([]receipts.Attachment, error) {
+	rows, err := r.s.db.QueryContext(ctx, `SELECT id, name, content_type, size, sha256, added_at
+		FROM attachments WHERE `+where+` ORDER BY added_at, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []receipts.Attachment
+	for rows.Next() {
+		var a receipts.Attachment
+		var addedAt string
+		if err := rows.Scan(&a.ID, &a.Name, &a.ContentType, &a.Size, &a.SHA256, &addedAt); err != nil {
+			return nil, err
+		}
+		if a.AddedAt, err = parseTime(addedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (r receiptStore) Open(ctx context.Context, event, id string) (io.ReadCloser, error) {
+	var data []byte
+	err := r.s.db.QueryRowContext(ctx, "SELECT content FROM attachments WHERE event_id = ? AND id = ?", event, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, receipts.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (r receiptStore) Delete(ctx context.Context, event, id string) error {
+	res, err := r.s.db.ExecContext(ctx, "DELETE FROM attachments WHERE event_id = ? AND id = ?", event, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return receipts.ErrNotFound
+	}
+	return nil
+}
+
+func (r receiptStore) Note(ctx context.Context, event string) (string, error) {
+	var note string
+	err := r.s.db.QueryRowContext(ctx, "SELECT note FROM event_notes WHERE event_id = ?", event).Scan(&note)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return note, err
+}
+
+func (r receiptStore) SetNote(ctx context.Context, event, note string) error {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		_, err := r.s.db.ExecContext(ctx, "DELETE FROM event_notes WHERE event_id = ?", event)
+		return err
+	}
+	_, err := r.s.db.ExecContext(ctx, `INSERT INTO event_notes (event_id, note, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (event_id) DO UPDATE SET note = excluded.note, updated_at = excluded.updated_at`,
+		event, note, formatTime(r.s.now()))
+	return err
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay/receipts"
+)
+
+func TestReceipts(t *testing.T) {
+	ctx := context.Background()
+	s, path := openTest(t)
+	s.now = func() time.Time { return time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC) }
+	r := s.Receipts()
+	id := "f1790fc3ee7b239c4a71a0d6cb17fe60"
+
+	a, err := r.Add(ctx, id, "hotel.pdf", strings.NewReader("folio"))
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if a.ContentType != "application/pdf" || a.Size != 5 || !a.AddedAt.Equal(s.now()) {
+		t.Errorf("Add() = %+v", a)
+	}
+	if again, err := r.Add(ctx, id, "copy.pdf", strings.NewReader("folio")); err != nil || again != a {
+		t.Errorf("Add() of the same content = %+v, %v, want %+v", again, err, a)
+	}
+	if err := r.SetNote(ctx, id, "Team offsite"); err != nil {
+		t.Fatalf("SetNote() error = %v", err)
+	}
+	if err := r.SetNote(ctx, id, "Team offsite, 3 nights"); err != nil {
+		t.Fatalf("SetNote() error = %v", err)
+	}
+	s.Close()
+
+	s, err = Open(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	r = s.Receipts()
+
+	atts, err := r.List(ctx, id)
+	if err != nil || len(atts) != 1 || atts[0] != a {
+		t.Fatalf("List() = %+v, %v, want [%+v]", atts, err, a)
+	}
+	rc, err := r.Open(ctx, id, a.ID)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if data, _ := io.ReadAll(rc); string(data) != "folio" {
+		t.Errorf("Open() content = %q, want %q", data, "folio")
+	}
+	if note, err := r.Note(ctx, id); err != nil || note != "Team offsite, 3 nights" {
+		t.Errorf("Note() = %q, %v", note, err)
+	}
+
+	if err := r.Delete(ctx, id, a.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := r.Delete(ctx, id, a.ID); !errors.Is(err, receipts.ErrNotFound) {
+		t.Errorf("Delete() again error = %v, want ErrNotFound", err)
+	}
+	if _, err := r.Open(ctx, id, a.ID); !errors.Is(err, receipts.ErrNotFound) {
+		t.Errorf("Open() deleted error = %v, want ErrNotFound", err)
+	}
+	if err := r.SetNote(ctx, id, " "); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(t, s, "event_notes"); got != 0 {
+		t.Errorf("event_notes has %d rows after clearing the note, want 0", got)
+	}
+}
@@ -0,0 +1,244 @@
+// Package store keeps a local SQLite copy of Gnosis Pay account data for
+// offline queries. Sync pulls cards, card events, IBAN orders, delayed
+// transactions and a balance snapshot, upserting each record by a stable
+// identifier; card events are fetched incrementally from a checkpoint
+// saved in the database. Receipts keeps receipts and notes for card events
+// in the same database.
+//
+// The database uses a pure-Go SQLite driver, so no cgo is required. Use DB
+// to run ad-hoc queries against the tables described in schema.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+	_ "modernc.org/sqlite"
+)
+
+// timeLayout is fixed-width so stored timestamps sort as text.
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// migrations upgrade the schema one step each; the database's user_version
+// records how many have been applied. Append new steps, never edit old
+// ones.
+var migrations = []string{
+	`CREATE TABLE cards (
+		id             TEXT PRIMARY KEY,
+		last_four      TEXT NOT NULL,
+		activated_at   TEXT,
+		status_code    REAL,
+		is_frozen      INTEGER NOT NULL DEFAULT 0,
+		is_lost        INTEGER NOT NULL DEFAULT 0,
+		is_stolen      INTEGER NOT NULL DEFAULT 0,
+		is_blocked     INTEGER NOT NULL DEFAULT 0,
+		is_void        INTEGER NOT NULL DEFAULT 0,
+		synced_at      TEXT NOT NULL
+	);
+	CREATE TABLE card_events (
+		key                  TEXT PRIMARY KEY,
+		created_at           TEXT NOT NULL,
+		cleared_at           TEXT,
+		kind                 TEXT NOT NULL,
+		status               TEXT NOT NULL,
+		is_pending           INTEGER NOT NULL,
+		mcc                  TEXT NOT NULL,
+		merchant_name        TEXT NOT NULL,
+		merchant_city        TEXT NOT NULL,
+		merchant_country     TEXT NOT NULL,
+		billing_amount       TEXT NOT NULL,
+		billing_currency     TEXT NOT NULL,
+		transaction_amount   TEXT NOT NULL,
+		transaction_currency TEXT NOT NULL,
+		tx_hashes            TEXT NOT NULL,
+		raw                  TEXT NOT NULL,
+		synced_at            TEXT NOT NULL
+	);
+	CREATE INDEX card_events_created_at ON card_events (created_at);
+	CREATE INDEX card_events_mcc ON card_events (mcc);
+	CREATE TABLE iban_orders (
+		id               TEXT PRIMARY KEY,
+		kind             TEXT NOT NULL,
+		state            TEXT NOT NULL,
+		amount           TEXT NOT NULL,
+		currency         TEXT NOT NULL,
+		address          TEXT NOT NULL,
+		memo             TEXT NOT NULL,
+		counterpart_name TEXT NOT NULL,
+		counterpart_iban TEXT NOT NULL,
+		placed_at        TEXT,
+		raw              TEXT NOT NULL,
+		synced_at        TEXT NOT NULL
+	);
+	CREATE TABLE delayed_transactions (
+		id             TEXT PRIMARY KEY,
+		safe_address   TEXT NOT NULL,
+		operation_type TEXT NOT NULL,
+		status         TEXT NOT NULL,
+		ready_at       TEXT,
+		created_at     TEXT,
+		raw            TEXT NOT NULL,
+		synced_at      TEXT NOT NULL
+	);
+	CREATE TABLE balance_snapshots (
+		id        INTEGER PRIMARY KEY AUTOINCREMENT,
+		taken_at  TEXT NOT NULL,
+		total     TEXT NOT NULL,
+		spendable TEXT NOT NULL,
+		pending   TEXT NOT NULL
+	);
+	CREATE TABLE sync_state (
+		name  TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);`,
+	// Receipts are keyed by card event but not tied to card_events,
+	// so they can be attached before the event is synced.
+	`CREATE TABLE attachments (
+		event_key    TEXT NOT NULL,
+		id           TEXT NOT NULL,
+		name         TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		size         INTEGER NOT NULL,
+		sha256       TEXT NOT NULL,
+		added_at     TEXT NOT NULL,
+		content      BLOB NOT NULL,
+		PRIMARY KEY (event_key, id)
+	);
+	CREATE TABLE event_notes (
+		event_key  TEXT PRIMARY KEY,
+		note       TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);`,
+	// Receipts are keyed by gnosispay.CardEvent.ID, which survives
+	// clearing, instead of the event key.
+	`ALTER TABLE attachments RENAME COLUMN event_key TO event_id;
+	ALTER TABLE event_notes RENAME COLUMN event_key TO event_id;`,
+}
+
+// Store is a local SQLite database of account data.
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// Open opens or creates the database at path and applies any pending
+// migrations.
+func Open(ctx context.Context, path string) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer; a single connection avoids SQLITE_BUSY
+	// between our own goroutines.
+	db.SetMaxOpenConns(1)
+	s := &Store{db: db, now: time.Now}
+	if err := s.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// DB returns the underlying database for ad-hoc queries.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// SchemaVersion returns the number of migrations applied.
+func (s *Store) SchemaVersion(ctx context.Context) (int, error) {
+	var v int
+	err := s.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&v)
+	return v, err
+}
+
+func (s *Store) migrate(ctx context.Context) error {
+	version, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("store: database schema version %d is newer than supported version %d", version, len(migrations))
+	}
+	for i := version; i < len(migrations); i++ {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("store: migration %d: %w", i+1, err)
+		}
+		// PRAGMA does not accept bound parameters.
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CardEvents returns the stored card events created in [from, to), oldest
+// first. Zero bounds are open.
+func (s *Store) CardEvents(ctx context.Context, from, to time.Time) ([]gnosispay.CardEvent, error) {
+	query := "SELECT raw FROM card_events WHERE 1=1"
+	var args []any
+	if !from.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, formatTime(from))
+	}
+	if !to.IsZero() {
+		query += " AND created_at < ?"
+		args = append(args, formatTime(to))
+	}
+	query += " ORDER BY created_at, key"
+	return queryRaw[gnosispay.CardEvent](ctx, s.db, query, args...)
+}
+
+// IbanOrders returns the stored IBAN orders, most recently placed first.
+func (s *Store) IbanOrders(ctx context.Context) ([]gnosispay.IbanOrder, error) {
+	return queryRaw[gnosispay.IbanOrder](ctx, s.db, "SELECT raw FROM iban_orders ORDER BY placed_at DESC, id")
+}
+
+func queryRaw[T any](ctx context.Context, db *sql.DB, query string, args ...any) ([]T, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []T
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		var v T
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// formatTime returns the stored form of t, or NULL for the zero time.
+func formatTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(timeLayout)
+}
+
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(timeLayout, s)
+}
@@ -0,0 +1,211 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+// fakeAPI serves the endpoints used by Sync from mutable data.
+type fakeAPI struct {
+	mu     sync.Mutex
+	events []gnosispay.CardEvent
+	afters []string
+}
+
+func (f *fakeAPI) setEvents(events ...gnosispay.CardEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = events
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var v any
+	switch r.URL.Path {
+	case "/api/v1/cards":
+		v = []gnosispay.Card{{Id: "card-1", LastFourDigits: "4242"}}
+	case "/api/v1/cards/card-1/status":
+		v = gnosispay.CardStatus{ActivatedAt: "2025-01-01T00:00:00Z", IsFrozen: true}
+	case "/transactions":
+		after := r.URL.Query().Get("after")
+		f.afters = append(f.afters, after)
+		var out []gnosispay.CardEvent
+		for _, e := range f.events {
+			if a, err := time.Parse(time.RFC3339, after); err == nil && e.CreatedAt.Before(a) {
+				continue
+			}
+			out = append(out, e)
+		}
+		v = out
+	case "/api/v1/ibans/orders":
+		v = map[string][]gnosispay.IbanOrder{"data": {{Id: "order-1", Kind: "issue", Currency: "eur", Amount: "100", State: "processed",
+			Meta: &gnosispay.IbanOrderMetadata{PlacedAt: "2025-01-03T10:00:00.000Z"}}}}
+	case "/api/v1/delay-relay":
+		v = []gnosispay.DelayTransaction{{Id: "delay-1", OperationType: "transfer", Status: "queued"}}
+	case "/api/v1/account-balances":
+		v = gnosispay.AccountBalances{Total: "100", Spendable: "90", Pending: "10"}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(v)
+}
+
+func openTest(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "gnosispay.db")
+	s, err := Open(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+func count(t *testing.T, s *Store, table string) int {
+	t.Helper()
+	var n int
+	if err := s.DB().QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestOpen_migrations(t *testing.T) {
+	s, path := openTest(t)
+	ctx := context.Background()
+	if v, err := s.SchemaVersion(ctx); err != nil || v != len(migrations) {
+		t.Errorf("SchemaVersion() = %d, %v, want %d", v, err, len(migrations))
+	}
+	s.Close()
+
+	// Reopening applies nothing twice.
+	s, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer s.Close()
+
+	if _, err := s.DB().Exec("PRAGMA user_version = 99"); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if _, err := Open(ctx, path); err == nil {
+		t.Error("Open() of a newer schema expected error")
+	}
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	api := &fakeAPI{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	client, _ := gnosispay.New(nil, gnosispay.SetBaseURL(server.URL))
+
+	s, _ := openTest(t)
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	eur := &gnosispay.Currency{Code: "EUR", Decimals: 2}
+	old := gnosispay.CardEvent{CreatedAt: start.Add(-time.Hour), Kind: "Payment", BillingAmount: "100", BillingCurrency: eur}
+	pending := gnosispay.CardEvent{CreatedAt: start.Add(time.Minute), Kind: "Payment", IsPending: true, BillingAmount: "250", BillingCurrency: eur}
+	cleared := pending
+	cleared.IsPending = false
+	cleared.Transactions = []gnosispay.Transaction{{Hash: "0xabc"}}
+	later := gnosispay.CardEvent{CreatedAt: start.Add(2 * time.Minute), Kind: "Refund", BillingAmount: "50", BillingCurrency: eur,
+		Merchant: &gnosispay.Merchant{Name: "REWE", Country: &gnosispay.Country{Alpha2: "DE"}}}
+
+	api.setEvents(old, pending, later)
+	res, err := s.Sync(ctx, client, &SyncOptions{Since: start})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	want := SyncResult{Cards: 1, CardEvents: 2, IbanOrders: 1, DelayedTransactions: 1, BalanceSnapshots: 1}
+	if *res != want {
+		t.Errorf("Sync() = %+v, want %+v", *res, want)
+	}
+
+	// The pending event clears with a new key; the second sync resumes at
+	// the pending event and replaces it.
+	api.setEvents(old, cleared, later)
+	if _, err := s.Sync(ctx, client, nil); err != nil {
+		t.Fatalf("second Sync() error = %v", err)
+	}
+	events, err := s.CardEvents(ctx, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].IsPending || events[0].Key() != cleared.Key() || events[1].Kind != "Refund" {
+		t.Errorf("CardEvents() = %+v", events)
+	}
+	if api.afters[0] != "2025-01-01T11:59:59Z" || api.afters[1] != "2025-01-01T12:00:59Z" {
+		t.Errorf("after filters = %v", api.afters)
+	}
+
+	// A third sync starts after the newest event and upserts nothing new.
+	if _, err := s.Sync(ctx, client, nil); err != nil {
+		t.Fatal(err)
+	}
+	if api.afters[2] != "2025-01-01T12:01:59Z" {
+		t.Errorf("third after filter = %s", api.afters[2])
+	}
+
+	for table, want := range map[string]int{
+		"cards": 1, "card_events": 2, "iban_orders": 1, "delayed_transactions": 1, "balance_snapshots": 3,
+	} {
+		if got := count(t, s, table); got != want {
+			t.Errorf("%s has %d rows, want %d", table, got, want)
+		}
+	}
+	var frozen bool
+	var country string
+	s.DB().QueryRow("SELECT is_frozen FROM cards WHERE id = 'card-1'").Scan(&frozen)
+	s.DB().QueryRow("SELECT merchant_country FROM card_events WHERE kind = 'Refund'").Scan(&country)
+	if !frozen || country != "DE" {
+		t.Errorf("stored columns: frozen = %v, merchant_country = %q", frozen, country)
+	}
+	orders, err := s.IbanOrders(ctx)
+	if err != nil || len(orders) != 1 || orders[0].Id != "order-1" {
+		t.Errorf("IbanOrders() = %+v, %v", orders, err)
+	}
+	if last, err := s.LastSync(ctx); err != nil || last.IsZero() {
+		t.Errorf("LastSync() = %v, %v", last, err)
+	}
+}
+
+func TestSyncCardEvents_fullHistory(t *testing.T) {
+	ctx := context.Background()
+	api := &fakeAPI{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	client, _ := gnosispay.New(nil, gnosispay.SetBaseURL(server.URL))
+
+	s, _ := openTest(t)
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	eur := &gnosispay.Currency{Code: "EUR", Decimals: 2}
+	old := gnosispay.CardEvent{CreatedAt: start.AddDate(-1, 0, 0), Kind: "Payment", BillingAmount: "100", BillingCurrency: eur}
+	pending := gnosispay.CardEvent{CreatedAt: start, Kind: "Payment", IsPending: true, BillingAmount: "250", BillingCurrency: eur}
+
+	api.setEvents(old, pending)
+	if n, err := s.SyncCardEvents(ctx, client.Cards, time.Time{}); err != nil || n != 2 {
+		t.Fatalf("SyncCardEvents() = %d, %v; want 2 events", n, err)
+	}
+	// The pending event was declined and is no longer returned.
+	api.setEvents(old)
+	if n, err := s.SyncCardEvents(ctx, client.Cards, time.Time{}); err != nil || n != 0 {
+		t.Fatalf("second SyncCardEvents() = %d, %v; want no changes", n, err)
+	}
+	if api.afters[0] != "" || api.afters[1] != "2025-01-01T11:59:59Z" {
+		t.Errorf("after filters = %v", api.afters)
+	}
+	if got := count(t, s, "card_events"); got != 1 {
+		t.Errorf("card_events has %d rows, want the declined pending event deleted", got)
+	}
+}
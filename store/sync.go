@@ -0,0 +1,387 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+const (
+	stateEventsCursor = "card_events.after"
+	stateLastSync     = "last_sync"
+
+	// cursorOverlap matches the overlap a gnosispay.Watcher subtracts from
+	// its cursor, so the events it may return again are loaded as seen.
+	cursorOverlap = time.Second
+)
+
+// SyncOptions tunes Sync.
+type SyncOptions struct {
+	// Since bounds the first card event sync. Later syncs resume from the
+	// saved checkpoint. Zero fetches the full history.
+	Since time.Time
+}
+
+// SyncResult counts the records fetched by Sync.
+type SyncResult struct {
+	Cards               int `json:"cards"`
+	CardEvents          int `json:"cardEvents"`
+	IbanOrders          int `json:"ibanOrders"`
+	DelayedTransactions int `json:"delayedTransactions"`
+	BalanceSnapshots    int `json:"balanceSnapshots"`
+}
+
+// Sync fetches everything the store keeps. Each part is committed on its
+// own, so an interrupted sync keeps what it finished and the next run
+// resumes from the saved checkpoint.
+func (s *Store) Sync(ctx context.Context, c *gnosispay.Client, opts *SyncOptions) (*SyncResult, error) {
+	if opts == nil {
+		opts = &SyncOptions{}
+	}
+	var res SyncResult
+	var err error
+	if res.Cards, err = s.SyncCards(ctx, c.Cards); err != nil {
+		return &res, err
+	}
+	if res.CardEvents, err = s.SyncCardEvents(ctx, c.Cards, opts.Since); err != nil {
+		return &res, err
+	}
+	if res.IbanOrders, err = s.SyncIbanOrders(ctx, c.IBAN); err != nil {
+		return &res, err
+	}
+	if res.DelayedTransactions, err = s.SyncDelayedTransactions(ctx, c.Account); err != nil {
+		return &res, err
+	}
+	if err = s.SnapshotBalances(ctx, c.Account); err != nil {
+		return &res, err
+	}
+	res.BalanceSnapshots = 1
+	_, err = s.db.ExecContext(ctx, upsertState, stateLastSync, s.now().UTC().Format(timeLayout))
+	return &res, err
+}
+
+// LastSync returns the time the last complete Sync finished.
+func (s *Store) LastSync(ctx context.Context) (time.Time, error) {
+	v, err := s.state(ctx, s.db, stateLastSync)
+	if err != nil || v == "" {
+		return time.Time{}, err
+	}
+	return parseTime(v)
+}
+
+const upsertState = `INSERT INTO sync_state (name, value) VALUES (?, ?)
+	ON CONFLICT (name) DO UPDATE SET value = excluded.value`
+
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func (s *Store) state(ctx context.Context, q querier, name string) (string, error) {
+	var v string
+	err := q.QueryRowContext(ctx, "SELECT value FROM sync_state WHERE name = ?", name).Scan(&v)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return v, err
+}
+
+// SyncCards upserts all cards with their current status.
+func (s *Store) SyncCards(ctx context.Context, cards *gnosispay.CardService) (int, error) {
+	list, err := cards.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	statuses := make([]*gnosispay.CardStatus, len(list))
+	for i, card := range list {
+		if statuses[i], err = cards.GetStatus(ctx, card.Id); err != nil {
+			return 0, err
+		}
+	}
+
+	now := s.now().UTC().Format(timeLayout)
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		for i, card := range list {
+			st := statuses[i]
+			_, err := tx.ExecContext(ctx, `INSERT INTO cards
+				(id, last_four, activated_at, status_code, is_frozen, is_lost, is_stolen, is_blocked, is_void, synced_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (id) DO UPDATE SET
+					last_four = excluded.last_four, activated_at = excluded.activated_at,
+					status_code = excluded.status_code, is_frozen = excluded.is_frozen,
+					is_lost = excluded.is_lost, is_stolen = excluded.is_stolen,
+					is_blocked = excluded.is_blocked, is_void = excluded.is_void,
+					synced_at = excluded.synced_at`,
+				card.Id, card.LastFourDigits, formatTime(card.ActivatedAt), st.StatusCode,
+				st.IsFrozen, st.IsLost, st.IsStolen, st.IsBlocked, st.IsVoid, now)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(list), err
+}
+
+// SyncCardEvents fetches the card events that changed since the last sync
+// and upserts them by CardEvent.Key. since is used when there is no
+// checkpoint yet.
+//
+// The sync runs one gnosispay.Watcher poll with the store as its
+// checkpoint, so it resumes, overlaps and handles clearing the same way a
+// Watcher does. Pending rows the Watcher drops, because the event was
+// reversed or cleared under a new key, are deleted.
+func (s *Store) SyncCardEvents(ctx context.Context, cards *gnosispay.CardService, since time.Time) (int, error) {
+	cp := &checkpoint{s: s}
+	saved, err := cp.Load(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if saved == nil {
+		// A zero since asks for the full history, which a Watcher only
+		// polls from a saved checkpoint.
+		cp.initial = &gnosispay.Checkpoint{After: since}
+	}
+
+	w := gnosispay.NewWatcher(cards, nil)
+	w.Store = cp
+	n := 0
+	err = w.Poll(ctx, func(c gnosispay.WatchEvent) error {
+		n++
+		return upsertCardEvent(ctx, s.db, c.Event.Key(), c.Event, s.now().UTC().Format(timeLayout))
+	})
+	return n, err
+}
+
+// checkpoint is a gnosispay.CheckpointStore backed by the database: the
+// cursor lives in sync_state and the events a Watcher has seen are the
+// card_events rows at or after the cursor.
+type checkpoint struct {
+	s       *Store
+	initial *gnosispay.Checkpoint // Returned by Load when nothing is saved.
+	from    time.Time             // Start of the window last loaded.
+}
+
+func (c *checkpoint) Load(ctx context.Context) (*gnosispay.Checkpoint, error) {
+	cursor, err := c.s.state(ctx, c.s.db, stateEventsCursor)
+	if err != nil || cursor == "" {
+		return c.initial, err
+	}
+	after, err := parseTime(cursor)
+	if err != nil {
+		return nil, err
+	}
+	c.from = after.Add(-cursorOverlap)
+	cp := &gnosispay.Checkpoint{After: after, Seen: map[string]gnosispay.SeenEvent{}}
+	rows, err := c.s.db.QueryContext(ctx, "SELECT key, created_at, is_pending FROM card_events WHERE created_at >= ?",
+		c.from.UTC().Format(timeLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, created string
+		var pending bool
+		if err := rows.Scan(&key, &created, &pending); err != nil {
+			return nil, err
+		}
+		t, err := parseTime(created)
+		if err != nil {
+			return nil, err
+		}
+		cp.Seen[key] = gnosispay.SeenEvent{CreatedAt: t, Pending: pending}
+	}
+	return cp, rows.Err()
+}
+
+// Save stores the cursor and deletes the pending rows of the loaded window
+// that cp no longer holds.
+func (c *checkpoint) Save(ctx context.Context, cp *gnosispay.Checkpoint) error {
+	return c.s.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SELECT key FROM card_events WHERE is_pending = 1 AND created_at >= ?",
+			c.from.UTC().Format(timeLayout))
+		if err != nil {
+			return err
+		}
+		var stale []string
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return err
+			}
+			if _, ok := cp.Seen[key]; !ok {
+				stale = append(stale, key)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, key := range stale {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM card_events WHERE key = ?", key); err != nil {
+				return err
+			}
+		}
+		if cp.After.IsZero() {
+			return nil
+		}
+		_, err = tx.ExecContext(ctx, upsertState, stateEventsCursor, formatTime(cp.After))
+		return err
+	})
+}
+
+func upsertCardEvent(ctx context.Context, tx execer, key string, e gnosispay.CardEvent, now string) error {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	var merchantName, merchantCity, merchantCountry string
+	if e.Merchant != nil {
+		merchantName, merchantCity = e.Merchant.Name, e.Merchant.City
+		if e.Merchant.Country != nil {
+			merchantCountry = e.Merchant.Country.Alpha2
+		}
+	}
+	var clearedAt any
+	if e.ClearedAt != nil {
+		clearedAt = formatTime(*e.ClearedAt)
+	}
+	var hashes []string
+	for _, t := range e.Transactions {
+		if t.Hash != "" {
+			hashes = append(hashes, t.Hash)
+		}
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO card_events
+		(key, created_at, cleared_at, kind, status, is_pending, mcc, merchant_name, merchant_city,
+		 merchant_country, billing_amount, billing_currency, transaction_amount, transaction_currency,
+		 tx_hashes, raw, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			cleared_at = excluded.cleared_at, kind = excluded.kind, status = excluded.status,
+			is_pending = excluded.is_pending, mcc = excluded.mcc, merchant_name = excluded.merchant_name,
+			merchant_city = excluded.merchant_city, merchant_country = excluded.merchant_country,
+			billing_amount = excluded.billing_amount, billing_currency = excluded.billing_currency,
+			transaction_amount = excluded.transaction_amount,
+			transaction_currency = excluded.transaction_currency, tx_hashes = excluded.tx_hashes,
+			raw = excluded.raw, synced_at = excluded.synced_at`,
+		key, formatTime(e.CreatedAt), clearedAt, string(e.Kind), string(e.Status), e.IsPending, e.Mcc,
+		merchantName, merchantCity, merchantCountry,
+		e.BillingAmount, currencyCode(e.BillingCurrency), e.TransactionAmount, currencyCode(e.TransactionCurrency),
+		strings.Join(hashes, ","), string(raw), now)
+	return err
+}
+
+func currencyCode(c *gnosispay.Currency) string {
+	if c == nil {
+		return ""
+	}
+	return c.Code
+}
+
+// SyncIbanOrders upserts all IBAN orders by ID.
+func (s *Store) SyncIbanOrders(ctx context.Context, iban *gnosispay.IBANService) (int, error) {
+	orders, err := iban.ListOrders(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := s.now().UTC().Format(timeLayout)
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, o := range orders {
+			raw, err := json.Marshal(o)
+			if err != nil {
+				return err
+			}
+			var name, iban string
+			if o.Counterpart != nil {
+				name, iban = o.Counterpart.Details.Name, o.Counterpart.Identifier.Iban
+			}
+			var placedAt any
+			if o.Meta != nil && o.Meta.PlacedAt != "" {
+				if t, err := time.Parse(time.RFC3339, o.Meta.PlacedAt); err == nil {
+					placedAt = formatTime(t)
+				}
+			}
+			_, err = tx.ExecContext(ctx, `INSERT INTO iban_orders
+				(id, kind, state, amount, currency, address, memo, counterpart_name, counterpart_iban, placed_at, raw, synced_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (id) DO UPDATE SET
+					kind = excluded.kind, state = excluded.state, amount = excluded.amount,
+					currency = excluded.currency, address = excluded.address, memo = excluded.memo,
+					counterpart_name = excluded.counterpart_name, counterpart_iban = excluded.counterpart_iban,
+					placed_at = excluded.placed_at, raw = excluded.raw, synced_at = excluded.synced_at`,
+				o.Id, o.Kind, o.State, o.Amount, strings.ToUpper(o.Currency), o.Address, o.Memo,
+				name, iban, placedAt, string(raw), now)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(orders), err
+}
+
+// SyncDelayedTransactions upserts the Safe's delayed transactions by ID.
+func (s *Store) SyncDelayedTransactions(ctx context.Context, account *gnosispay.AccountManagementService) (int, error) {
+	txs, err := account.ListDelayedTransactions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := s.now().UTC().Format(timeLayout)
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, d := range txs {
+			raw, err := json.Marshal(d)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, `INSERT INTO delayed_transactions
+				(id, safe_address, operation_type, status, ready_at, created_at, raw, synced_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (id) DO UPDATE SET
+					safe_address = excluded.safe_address, operation_type = excluded.operation_type,
+					status = excluded.status, ready_at = excluded.ready_at,
+					created_at = excluded.created_at, raw = excluded.raw, synced_at = excluded.synced_at`,
+				d.Id, d.SafeAddress, d.OperationType, d.Status, formatTime(d.ReadyAt), formatTime(d.CreatedAt),
+				string(raw), now)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(txs), err
+}
+
+// SnapshotBalances records the current account balances. Snapshots are
+// append-only so balances can be charted over time.
+func (s *Store) SnapshotBalances(ctx context.Context, account *gnosispay.AccountManagementService) error {
+	b, err := account.GetBalances(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		"INSERT INTO balance_snapshots (taken_at, total, spendable, pending) VALUES (?, ?, ?, ?)",
+		s.now().UTC().Format(timeLayout), b.Total, b.Spendable, b.Pending)
+	return err
+}
+
+func (s *Store) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -2,10 +2,12 @@ package wallet
 
 import (
 	"crypto/ecdsa"
+	"encoding/json"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
 // signHash creates an Ethereum-specific hash of the given data by prefixing it with
@@ -59,3 +61,20 @@ func SignRawBytes(message []byte, privateKey *ecdsa.PrivateKey) ([]byte, error)
 	signature[64] += 27
 	return signature, nil
 }
+
+// SignTypedData signs EIP-712 typed data, given as JSON, using the
+// provided private key. It hashes the data as defined by EIP-712 and signs
+// the digest without a message prefix. The signature's V value is adjusted
+// by adding 27.
+func SignTypedData(data []byte, privateKey *ecdsa.PrivateKey) ([]byte, error) {
+	var typed apitypes.TypedData
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return nil, fmt.Errorf("failed to decode typed data: %w", err)
+	}
+	hash, _, err := apitypes.TypedDataAndHash(typed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+
+	return SignRawBytes(hash, privateKey)
+}
@@ -0,0 +1,398 @@
+package gnosispay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWatchInterval   = time.Minute
+	defaultWatchMaxBackoff = 15 * time.Minute
+
+	// watchOverlap is subtracted from the cursor on every poll. The API
+	// filters with one-second precision, so events sharing the cursor's
+	// second would otherwise be missed; duplicates are dropped by key.
+	watchOverlap = time.Second
+)
+
+// Key identifies a card event across polls. It combines the creation time
+// with the on-chain transaction hashes.
+func (e CardEvent) Key() string {
+	var hashes []string
+	for _, tx := range e.Transactions {
+		if tx.Hash != "" {
+			hashes = append(hashes, strings.ToLower(tx.Hash))
+		}
+	}
+	sort.Strings(hashes)
+	return e.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + strings.Join(hashes, ",")
+}
+
+// WatchEventType is the kind of change reported by a Watcher.
+type WatchEventType string
+
+const (
+	// WatchEventNew reports an event seen for the first time.
+	WatchEventNew WatchEventType = "new"
+	// WatchEventCleared reports a pending event that has since cleared.
+	WatchEventCleared WatchEventType = "cleared"
+)
+
+// WatchEvent is a change emitted by a Watcher.
+type WatchEvent struct {
+	Type  WatchEventType
+	Event CardEvent
+}
+
+// SeenEvent is the state a Watcher keeps for an event it has emitted.
+type SeenEvent struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Pending   bool      `json:"pending"`
+}
+
+// Checkpoint is the persistent state of a Watcher.
+type Checkpoint struct {
+	// After is the cursor for the next poll. It never moves past an event
+	// that is still pending, so its clearing is observed. A zero After
+	// polls the full history.
+	After time.Time `json:"after"`
+
+	// Seen holds the events at or after the cursor, keyed by CardEvent.Key.
+	Seen map[string]SeenEvent `json:"seen"`
+}
+
+// CheckpointStore persists a Watcher's checkpoint between runs.
+type CheckpointStore interface {
+	// Load returns the saved checkpoint, or nil if there is none.
+	Load(ctx context.Context) (*Checkpoint, error)
+	Save(ctx context.Context, cp *Checkpoint) error
+}
+
+// MemoryCheckpointStore keeps the checkpoint in memory. It is the default
+// store of a Watcher.
+type MemoryCheckpointStore struct {
+	mu sync.Mutex
+	cp *Checkpoint
+}
+
+func (s *MemoryCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cp.clone(), nil
+}
+
+func (s *MemoryCheckpointStore) Save(ctx context.Context, cp *Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cp = cp.clone()
+	return nil
+}
+
+// FileCheckpointStore keeps the checkpoint in a JSON file.
+type FileCheckpointStore struct {
+	Path string
+}
+
+func (s *FileCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("reading checkpoint %s: %w", s.Path, err)
+	}
+	return &cp, nil
+}
+
+// Save replaces the file atomically so a crash never leaves a partial
+// checkpoint behind.
+func (s *FileCheckpointStore) Save(ctx context.Context, cp *Checkpoint) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+func (cp *Checkpoint) clone() *Checkpoint {
+	if cp == nil {
+		return nil
+	}
+	c := &Checkpoint{After: cp.After, Seen: make(map[string]SeenEvent, len(cp.Seen))}
+	for k, v := range cp.Seen {
+		c.Seen[k] = v
+	}
+	return c
+}
+
+// Watcher polls CardService.ListTransactions and emits each new event once,
+// plus a second notification when a pending event clears.
+//
+// Set the exported fields before calling Run or Events.
+type Watcher struct {
+	cards *CardService
+	opts  ListTransactionsOptions
+	now   func() time.Time
+
+	// Interval between polls. Defaults to one minute.
+	Interval time.Duration
+
+	// MaxBackoff caps the delay between polls after consecutive errors.
+	// The delay starts at Interval and doubles per failure. Defaults to
+	// 15 minutes.
+	MaxBackoff time.Duration
+
+	// Store persists the checkpoint. Defaults to a MemoryCheckpointStore.
+	Store CheckpointStore
+
+	// OnError, if set, is called with every failed poll or save.
+	OnError func(error)
+}
+
+// NewWatcher returns a Watcher for the card events matching opts. Before
+// is ignored and After is only used when the store holds no checkpoint;
+// a zero After starts watching from now instead of replaying history.
+func NewWatcher(cards *CardService, opts *ListTransactionsOptions) *Watcher {
+	w := &Watcher{cards: cards, now: time.Now}
+	if opts != nil {
+		w.opts = *opts
+	}
+	w.opts.Before = time.Time{}
+	return w
+}
+
+// Run polls until ctx is done, calling handle for every change in creation
+// order. The checkpoint is saved after each poll whose changes were all
+// handled, so delivery is at least once. Run returns ctx.Err() or the
+// first error returned by handle.
+func (w *Watcher) Run(ctx context.Context, handle func(WatchEvent) error) error {
+	cp, err := w.checkpoint(ctx)
+	if err != nil {
+		return err
+	}
+	interval := w.Interval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	maxBackoff := w.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultWatchMaxBackoff
+	}
+
+	delay := interval
+	for {
+		changes, next, err := w.poll(ctx, cp)
+		if err == nil {
+			for _, c := range changes {
+				if err := handle(c); err != nil {
+					return err
+				}
+			}
+			err = w.Store.Save(ctx, next)
+		}
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			if w.OnError != nil {
+				w.OnError(err)
+			}
+		default:
+			cp = next
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		if err != nil {
+			delay = min(delay*2, maxBackoff)
+		} else {
+			delay = interval
+		}
+	}
+}
+
+// Poll polls once, for callers that schedule polls themselves: it calls
+// handle for every change since the saved checkpoint in creation order,
+// then saves the checkpoint. Nothing is saved if handle fails.
+func (w *Watcher) Poll(ctx context.Context, handle func(WatchEvent) error) error {
+	cp, err := w.checkpoint(ctx)
+	if err != nil {
+		return err
+	}
+	changes, next, err := w.poll(ctx, cp)
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		if err := handle(c); err != nil {
+			return err
+		}
+	}
+	return w.Store.Save(ctx, next)
+}
+
+// checkpoint validates the options and loads the saved checkpoint, or
+// starts a new one at the After option.
+func (w *Watcher) checkpoint(ctx context.Context) (*Checkpoint, error) {
+	opts := w.opts
+	opts.After = time.Time{}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if w.Store == nil {
+		w.Store = &MemoryCheckpointStore{}
+	}
+	cp, err := w.Store.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading checkpoint: %w", err)
+	}
+	if cp == nil {
+		cp = &Checkpoint{After: w.opts.After}
+		if cp.After.IsZero() {
+			cp.After = w.now()
+		}
+	}
+	if cp.Seen == nil {
+		cp.Seen = map[string]SeenEvent{}
+	}
+	return cp, nil
+}
+
+// Events runs the watcher in a goroutine and delivers changes on the
+// returned channel, which is closed once ctx is done.
+func (w *Watcher) Events(ctx context.Context) <-chan WatchEvent {
+	ch := make(chan WatchEvent)
+	go func() {
+		defer close(ch)
+		err := w.Run(ctx, func(e WatchEvent) error {
+			select {
+			case ch <- e:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil && w.OnError != nil {
+			w.OnError(err)
+		}
+	}()
+	return ch
+}
+
+// poll fetches the events after the cursor and returns the changes since
+// cp together with the updated checkpoint. cp itself is not modified.
+func (w *Watcher) poll(ctx context.Context, cp *Checkpoint) ([]WatchEvent, *Checkpoint, error) {
+	opts := w.opts
+	opts.After = time.Time{}
+	if !cp.After.IsZero() {
+		opts.After = cp.After.Add(-watchOverlap)
+	}
+	events, err := w.cards.ListTransactions(ctx, &opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+
+	next := cp.clone()
+	returned := make(map[string]bool, len(events))
+	for _, e := range events {
+		returned[e.Key()] = true
+	}
+
+	var changes []WatchEvent
+	for _, e := range events {
+		key := e.Key()
+		prev, seen := next.Seen[key]
+		switch {
+		case !seen:
+			// Transaction hashes may only appear once an event clears,
+			// which changes its key. Pair it with a vanished pending
+			// event created at the same time.
+			if old := pendingMatch(next, e, returned); !e.IsPending && old != "" {
+				delete(next.Seen, old)
+				next.Seen[key] = SeenEvent{CreatedAt: e.CreatedAt, Pending: false}
+				changes = append(changes, WatchEvent{Type: WatchEventCleared, Event: e})
+				continue
+			}
+			next.Seen[key] = SeenEvent{CreatedAt: e.CreatedAt, Pending: e.IsPending}
+			changes = append(changes, WatchEvent{Type: WatchEventNew, Event: e})
+		case prev.Pending && !e.IsPending:
+			next.Seen[key] = SeenEvent{CreatedAt: e.CreatedAt, Pending: false}
+			changes = append(changes, WatchEvent{Type: WatchEventCleared, Event: e})
+		}
+	}
+
+	// Pending events missing from the response were declined or reversed;
+	// stop holding the cursor back for them.
+	for key, s := range next.Seen {
+		if s.Pending && !returned[key] && !s.CreatedAt.Before(opts.After) {
+			delete(next.Seen, key)
+		}
+	}
+
+	// Advance the cursor to the oldest pending event, or else the newest
+	// event seen, and forget what the next poll can no longer return.
+	var oldestPending, newest time.Time
+	for _, s := range next.Seen {
+		if s.Pending && (oldestPending.IsZero() || s.CreatedAt.Before(oldestPending)) {
+			oldestPending = s.CreatedAt
+		}
+		if s.CreatedAt.After(newest) {
+			newest = s.CreatedAt
+		}
+	}
+	switch {
+	case !oldestPending.IsZero():
+		next.After = oldestPending
+	case newest.After(next.After):
+		next.After = newest
+	}
+	for key, s := range next.Seen {
+		if s.CreatedAt.Before(next.After.Add(-watchOverlap)) {
+			delete(next.Seen, key)
+		}
+	}
+	return changes, next, nil
+}
+
+// pendingMatch returns the key of a pending event created at the same time
+// as e that is absent from the current response.
+func pendingMatch(cp *Checkpoint, e CardEvent, returned map[string]bool) string {
+	for key, s := range cp.Seen {
+		if s.Pending && !returned[key] && s.CreatedAt.Equal(e.CreatedAt) {
+			return key
+		}
+	}
+	return ""
+}
@@ -0,0 +1,254 @@
+package gnosispay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFeed serves /transactions from a mutable event list, honouring the
+// after filter with the API's one-second precision.
+type fakeFeed struct {
+	mu     sync.Mutex
+	events []CardEvent
+	fail   int
+	afters []string
+}
+
+func (f *fakeFeed) set(events ...CardEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = events
+}
+
+func (f *fakeFeed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	after := r.URL.Query().Get("after")
+	f.afters = append(f.afters, after)
+	if f.fail > 0 {
+		f.fail--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var out []CardEvent
+	for _, e := range f.events {
+		if a, err := time.Parse(time.RFC3339, after); err == nil && e.CreatedAt.Before(a) {
+			continue
+		}
+		out = append(out, e)
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func newWatcherTest(t *testing.T, opts *ListTransactionsOptions) (*fakeFeed, *Watcher) {
+	t.Helper()
+	feed := &fakeFeed{}
+	server := httptest.NewServer(feed)
+	t.Cleanup(server.Close)
+	client, _ := New(nil, SetBaseURL(server.URL))
+	return feed, NewWatcher(client.Cards, opts)
+}
+
+func TestCardEvent_Key(t *testing.T) {
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	a := CardEvent{CreatedAt: at, Transactions: []Transaction{{Hash: "0xBB"}, {Hash: "0xaa"}}}
+	b := CardEvent{CreatedAt: at.UTC(), Transactions: []Transaction{{Hash: "0xaa"}, {Hash: "0xbb"}}, IsPending: true}
+	if a.Key() != b.Key() {
+		t.Errorf("Key() differs for the same event: %q != %q", a.Key(), b.Key())
+	}
+	if c := (CardEvent{CreatedAt: at}); c.Key() == a.Key() {
+		t.Errorf("Key() ignores transaction hashes")
+	}
+}
+
+func TestWatcher_poll(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(min int) time.Time { return start.Add(time.Duration(min) * time.Minute) }
+	old := CardEvent{CreatedAt: at(-10), Kind: "Payment"}
+	coffee := CardEvent{CreatedAt: at(1), Kind: "Payment", IsPending: true}
+	coffeeCleared := CardEvent{CreatedAt: at(1), Kind: "Payment", Transactions: []Transaction{{Hash: "0x01"}}}
+	books := CardEvent{CreatedAt: at(2), Kind: "Payment", Transactions: []Transaction{{Hash: "0x02"}}}
+	hold := CardEvent{CreatedAt: at(3), Kind: "Payment", IsPending: true}
+
+	feed, w := newWatcherTest(t, &ListTransactionsOptions{After: start})
+	cp := &Checkpoint{After: start, Seen: map[string]SeenEvent{}}
+	poll := func() []WatchEvent {
+		t.Helper()
+		changes, next, err := w.poll(context.Background(), cp)
+		if err != nil {
+			t.Fatalf("poll() error = %v", err)
+		}
+		cp = next
+		return changes
+	}
+	types := func(changes []WatchEvent) []WatchEventType {
+		var out []WatchEventType
+		for _, c := range changes {
+			out = append(out, c.Type)
+		}
+		return out
+	}
+
+	feed.set(old, coffee, books)
+	if got := types(poll()); !reflect.DeepEqual(got, []WatchEventType{WatchEventNew, WatchEventNew}) {
+		t.Fatalf("first poll = %v, want two new events", got)
+	}
+	if !cp.After.Equal(at(1)) {
+		t.Errorf("cursor = %v, want it held at the pending event %v", cp.After, at(1))
+	}
+
+	if got := poll(); len(got) != 0 {
+		t.Errorf("repeated poll = %v, want no duplicates", types(got))
+	}
+
+	// The pending event clears and gains a transaction hash.
+	feed.set(old, coffeeCleared, books, hold)
+	got := poll()
+	if want := []WatchEventType{WatchEventCleared, WatchEventNew}; !reflect.DeepEqual(types(got), want) {
+		t.Fatalf("poll after clearing = %v, want %v", types(got), want)
+	}
+	if got[0].Event.Key() != coffeeCleared.Key() {
+		t.Errorf("cleared event = %+v", got[0].Event)
+	}
+	if !cp.After.Equal(at(3)) {
+		t.Errorf("cursor = %v, want %v", cp.After, at(3))
+	}
+
+	// A pending authorization that disappears no longer holds the cursor.
+	feed.set(old, coffeeCleared, books)
+	if got := poll(); len(got) != 0 {
+		t.Errorf("poll after reversal = %v, want no changes", types(got))
+	}
+	if !cp.After.Equal(at(3)) || len(cp.Seen) != 0 {
+		t.Errorf("checkpoint = %+v, want the cursor kept at %v with nothing pending", cp, at(3))
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	feed, w := newWatcherTest(t, &ListTransactionsOptions{After: start, MCC: []string{"5411"}})
+	store := &FileCheckpointStore{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+	w.Store = store
+	w.Interval = time.Millisecond
+	feed.fail = 2
+	var errs []error
+	w.OnError = func(err error) { errs = append(errs, err) }
+
+	feed.set(
+		CardEvent{CreatedAt: start.Add(time.Minute), Kind: "Payment", IsPending: true},
+		CardEvent{CreatedAt: start.Add(2 * time.Minute), Kind: "Refund"},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []WatchEvent
+	err := w.Run(ctx, func(e WatchEvent) error {
+		got = append(got, e)
+		if len(got) == 2 {
+			// Clear the pending event for the next poll.
+			feed.set(
+				CardEvent{CreatedAt: start.Add(time.Minute), Kind: "Payment"},
+				CardEvent{CreatedAt: start.Add(2 * time.Minute), Kind: "Refund"},
+			)
+		}
+		if len(got) == 3 {
+			return errors.New("stop")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "stop" {
+		t.Fatalf("Run() error = %v, want the handler's error", err)
+	}
+	if len(errs) != 2 {
+		t.Errorf("OnError called %d times, want 2", len(errs))
+	}
+	if got[2].Type != WatchEventCleared || got[2].Event.Kind != "Payment" {
+		t.Errorf("third change = %+v, want the payment clearing", got[2])
+	}
+
+	// The checkpoint saved before the failing handler lets a new watcher
+	// resume without re-emitting the first two events.
+	cp, err := store.Load(ctx)
+	if err != nil || cp == nil {
+		t.Fatalf("Load() = %v, %v", cp, err)
+	}
+	if !cp.After.Equal(start.Add(time.Minute)) || len(cp.Seen) != 2 {
+		t.Errorf("checkpoint = %+v", cp)
+	}
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	if want := "2025-01-01T11:59:59Z"; feed.afters[0] != want {
+		t.Errorf("first poll after = %s, want %s", feed.afters[0], want)
+	}
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	ctx := context.Background()
+	feed, w := newWatcherTest(t, nil)
+	store := &MemoryCheckpointStore{}
+	w.Store = store
+	// A zero cursor replays the full history.
+	store.Save(ctx, &Checkpoint{})
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	feed.set(CardEvent{CreatedAt: start, Kind: "Payment"})
+
+	var got []WatchEvent
+	handle := func(e WatchEvent) error {
+		got = append(got, e)
+		return nil
+	}
+	if err := w.Poll(ctx, handle); err != nil || len(got) != 1 {
+		t.Fatalf("Poll() = %v with %d changes, want 1", err, len(got))
+	}
+	if err := w.Poll(ctx, handle); err != nil || len(got) != 1 {
+		t.Errorf("second Poll() = %v with %d changes, want none new", err, len(got))
+	}
+	if cp, _ := store.Load(ctx); !cp.After.Equal(start) {
+		t.Errorf("checkpoint after = %s, want %s", cp.After, start)
+	}
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	if feed.afters[0] != "" || feed.afters[1] != "2025-01-01T11:59:59Z" {
+		t.Errorf("after filters = %v", feed.afters)
+	}
+}
+
+func TestWatcher_Events(t *testing.T) {
+	feed, w := newWatcherTest(t, nil)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+	w.Interval = time.Millisecond
+	feed.set(
+		CardEvent{CreatedAt: now.Add(-time.Hour), Kind: "Payment"},
+		CardEvent{CreatedAt: now.Add(time.Second), Kind: "Payment"},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := w.Events(ctx)
+	select {
+	case e := <-events:
+		if e.Type != WatchEventNew || !e.Event.CreatedAt.Equal(now.Add(time.Second)) {
+			t.Errorf("event = %+v, want only events after the start time", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatcher_RunRejectsInvalidOptions(t *testing.T) {
+	_, w := newWatcherTest(t, &ListTransactionsOptions{MCC: []string{"food"}})
+	if err := w.Run(context.Background(), func(WatchEvent) error { return nil }); err == nil {
+		t.Error("Run() expected error for invalid filters")
+	}
+}
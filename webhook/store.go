@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrDuplicate is returned by Store.Claim for an event that was
+	// already processed.
+	ErrDuplicate = errors.New("webhook: event already processed")
+
+	// ErrInProgress is returned by Store.Claim while another delivery of
+	// the same event is being processed.
+	ErrInProgress = errors.New("webhook: event is being processed")
+)
+
+// Store records which events have been processed so redeliveries are
+// acknowledged without running the handlers again.
+type Store interface {
+	// Claim marks id as in progress, or returns ErrDuplicate or
+	// ErrInProgress.
+	Claim(ctx context.Context, id string) error
+
+	// Complete marks a claimed id as processed.
+	Complete(ctx context.Context, id string) error
+
+	// Release drops a claim after a failure so a retry can process id.
+	Release(ctx context.Context, id string) error
+}
+
+// MemoryStore is an in-process Store. Processed IDs are remembered for TTL
+// and claims expire after Lease, so a crashed handler does not block
+// retries forever.
+type MemoryStore struct {
+	TTL   time.Duration
+	Lease time.Duration
+
+	mu  sync.Mutex
+	ids map[string]memoryEntry
+	now func() time.Time
+}
+
+type memoryEntry struct {
+	done    bool
+	expires time.Time
+}
+
+// NewMemoryStore returns a MemoryStore remembering processed IDs for ttl.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{TTL: ttl, Lease: 5 * time.Minute}
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock()
+	if s.ids == nil {
+		s.ids = map[string]memoryEntry{}
+	}
+	for k, e := range s.ids {
+		if !now.Before(e.expires) {
+			delete(s.ids, k)
+		}
+	}
+	if e, ok := s.ids[id]; ok {
+		if e.done {
+			return ErrDuplicate
+		}
+		return ErrInProgress
+	}
+	s.ids[id] = memoryEntry{expires: now.Add(s.Lease)}
+	return nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ids == nil {
+		s.ids = map[string]memoryEntry{}
+	}
+	s.ids[id] = memoryEntry{done: true, expires: s.clock().Add(s.TTL)}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.ids[id]; ok && !e.done {
+		delete(s.ids, id)
+	}
+	return nil
+}
+
+func (s *MemoryStore) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
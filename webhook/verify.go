@@ -0,0 +1,171 @@
+package webhook
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSignatureHeader carries the delivery signature.
+	DefaultSignatureHeader = "X-Gnosispay-Signature"
+
+	// DefaultTolerance is how far a delivery's timestamp may be from the
+	// local clock before it is rejected as a replay.
+	DefaultTolerance = 5 * time.Minute
+)
+
+// ErrInvalidSignature is returned when no signature on a delivery matches.
+var ErrInvalidSignature = errors.New("webhook: invalid signature")
+
+// Verifier authenticates a webhook delivery.
+type Verifier interface {
+	Verify(header http.Header, body []byte) error
+}
+
+// The signature header has the form "t=<unix seconds>,v1=<hex signature>".
+// The signature covers "<t>.<body>". Several v1 entries may be present
+// while the sender rotates keys; any one of them matching is enough.
+
+// HMACVerifier checks HMAC-SHA256 signatures made with a shared secret.
+type HMACVerifier struct {
+	Secret []byte
+
+	// Header defaults to DefaultSignatureHeader and Tolerance to
+	// DefaultTolerance.
+	Header    string
+	Tolerance time.Duration
+
+	now func() time.Time
+}
+
+func (v *HMACVerifier) Verify(header http.Header, body []byte) error {
+	if len(v.Secret) == 0 {
+		return errors.New("webhook: HMAC secret is empty")
+	}
+	payload, sigs, err := parseSignature(header, body, v.Header, v.Tolerance, v.now)
+	if err != nil {
+		return err
+	}
+	want := hmacSHA256(v.Secret, payload)
+	for _, sig := range sigs {
+		if hmac.Equal(sig, want) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// PublicKeyVerifier checks signatures made with the sender's private key.
+// Key must be an ed25519.PublicKey or an *ecdsa.PublicKey; ECDSA signatures
+// are over the SHA-256 digest, either ASN.1 encoded or as raw r||s.
+type PublicKeyVerifier struct {
+	Key crypto.PublicKey
+
+	// Header defaults to DefaultSignatureHeader and Tolerance to
+	// DefaultTolerance.
+	Header    string
+	Tolerance time.Duration
+
+	now func() time.Time
+}
+
+func (v *PublicKeyVerifier) Verify(header http.Header, body []byte) error {
+	payload, sigs, err := parseSignature(header, body, v.Header, v.Tolerance, v.now)
+	if err != nil {
+		return err
+	}
+	for _, sig := range sigs {
+		switch key := v.Key.(type) {
+		case ed25519.PublicKey:
+			if ed25519.Verify(key, payload, sig) {
+				return nil
+			}
+		case *ecdsa.PublicKey:
+			digest := sha256.Sum256(payload)
+			if ecdsa.VerifyASN1(key, digest[:], sig) {
+				return nil
+			}
+			if size := (key.Curve.Params().BitSize + 7) / 8; len(sig) == 2*size {
+				r := new(big.Int).SetBytes(sig[:size])
+				s := new(big.Int).SetBytes(sig[size:])
+				if ecdsa.Verify(key, digest[:], r, s) {
+					return nil
+				}
+			}
+		default:
+			return fmt.Errorf("webhook: unsupported public key type %T", v.Key)
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// SignHMAC returns the signature header value for body signed with secret
+// at time t. It is meant for tests and for relaying deliveries.
+func SignHMAC(secret []byte, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	sig := hmacSHA256(secret, []byte(ts+"."+string(body)))
+	return "t=" + ts + ",v1=" + hex.EncodeToString(sig)
+}
+
+func hmacSHA256(secret, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// parseSignature extracts the signed payload and candidate signatures from
+// the signature header, rejecting stale timestamps.
+func parseSignature(header http.Header, body []byte, name string, tolerance time.Duration, now func() time.Time) ([]byte, [][]byte, error) {
+	if name == "" {
+		name = DefaultSignatureHeader
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	if now == nil {
+		now = time.Now
+	}
+
+	value := header.Get(name)
+	if value == "" {
+		return nil, nil, fmt.Errorf("webhook: missing %s header", name)
+	}
+	var ts string
+	var sigs [][]byte
+	for _, part := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			if sig, err := hex.DecodeString(v); err == nil {
+				sigs = append(sigs, sig)
+			}
+		}
+	}
+	if ts == "" || len(sigs) == 0 {
+		return nil, nil, fmt.Errorf("webhook: malformed %s header", name)
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("webhook: invalid signature timestamp %q", ts)
+	}
+	if d := now().Sub(time.Unix(sec, 0)); d > tolerance || d < -tolerance {
+		return nil, nil, fmt.Errorf("webhook: signature timestamp outside tolerance (%s)", d.Round(time.Second))
+	}
+	return []byte(ts + "." + string(body)), sigs, nil
+}
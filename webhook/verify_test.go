@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func signatureHeader(value string) http.Header {
+	h := http.Header{}
+	h.Set(DefaultSignatureHeader, value)
+	return h
+}
+
+func TestHMACVerifier(t *testing.T) {
+	secret := []byte("whsec_test")
+	body := []byte(`{"id":"evt_1"}`)
+	v := &HMACVerifier{Secret: secret, now: func() time.Time { return testNow }}
+	_, current, _ := strings.Cut(SignHMAC(secret, testNow, body), ",")
+
+	tests := []struct {
+		name    string
+		header  string
+		wantErr bool
+	}{
+		{"valid", SignHMAC(secret, testNow, body), false},
+		{"within tolerance", SignHMAC(secret, testNow.Add(-4*time.Minute), body), false},
+		{"stale timestamp", SignHMAC(secret, testNow.Add(-10*time.Minute), body), true},
+		{"future timestamp", SignHMAC(secret, testNow.Add(10*time.Minute), body), true},
+		{"wrong secret", SignHMAC([]byte("other"), testNow, body), true},
+		{"rotated secret", SignHMAC([]byte("old"), testNow, body) + "," + current, false},
+		{"missing header", "", true},
+		{"malformed header", "sha256=abc", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Verify(signatureHeader(tt.header), body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := v.Verify(signatureHeader(SignHMAC(secret, testNow, body)), []byte(`{"id":"evt_2"}`)); err != ErrInvalidSignature {
+		t.Errorf("Verify() with tampered body error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestPublicKeyVerifier(t *testing.T) {
+	body := []byte(`{"id":"evt_1"}`)
+	ts := strconv.FormatInt(testNow.Unix(), 10)
+	payload := []byte(ts + "." + string(body))
+	now := func() time.Time { return testNow }
+
+	edPub, edPriv, _ := ed25519.GenerateKey(rand.Reader)
+	ecPriv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	digest := sha256.Sum256(payload)
+	asn1Sig, _ := ecdsa.SignASN1(rand.Reader, ecPriv, digest[:])
+	r, s, _ := ecdsa.Sign(rand.Reader, ecPriv, digest[:])
+	rawSig := make([]byte, 64)
+	r.FillBytes(rawSig[:32])
+	s.FillBytes(rawSig[32:])
+
+	tests := []struct {
+		name    string
+		key     any
+		sig     []byte
+		wantErr bool
+	}{
+		{"ed25519", edPub, ed25519.Sign(edPriv, payload), false},
+		{"ed25519 wrong payload", edPub, ed25519.Sign(edPriv, body), true},
+		{"ecdsa asn1", &ecPriv.PublicKey, asn1Sig, false},
+		{"ecdsa raw", &ecPriv.PublicKey, rawSig, false},
+		{"ecdsa wrong key", &ecPriv.PublicKey, ed25519.Sign(edPriv, payload), true},
+		{"unsupported key", "not a key", []byte("x"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &PublicKeyVerifier{Key: tt.key, now: now}
+			err := v.Verify(signatureHeader("t="+ts+",v1="+hex.EncodeToString(tt.sig)), body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
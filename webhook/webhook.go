@@ -0,0 +1,231 @@
+// Package webhook receives Gnosis Pay webhook deliveries. Handler verifies
+// each delivery's signature, drops redeliveries of events it has already
+// processed, decodes the payload into typed events and dispatches them to
+// the registered callbacks.
+//
+// Deliveries are acknowledged with 200 only once every callback has
+// succeeded. A failing callback answers 500 and releases the event, so the
+// sender's retry runs the callbacks again; callbacks should therefore be
+// idempotent for partial failures.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+// DefaultMaxBodyBytes limits the size of a delivery.
+const DefaultMaxBodyBytes = 1 << 20
+
+// EventType identifies the payload of a delivery.
+type EventType string
+
+const (
+	EventCardTransaction EventType = "card.transaction"
+	EventKycStatus       EventType = "kyc.status_changed"
+	EventIbanOrder       EventType = "iban.order"
+)
+
+// Event is the envelope shared by all deliveries.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      EventType       `json:"type"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// CardTransactionEvent reports a card authorization or a change to one.
+type CardTransactionEvent struct {
+	Event
+	Transaction gnosispay.CardEvent
+}
+
+// KycStatusEvent reports a change of a user's KYC status.
+type KycStatusEvent struct {
+	Event
+	UserID string
+	Status gnosispay.KycStatus
+}
+
+// IbanOrderEvent reports a new IBAN order or a change to its state.
+type IbanOrderEvent struct {
+	Event
+	Order gnosispay.IbanOrder
+}
+
+// Handler is an http.Handler for webhook deliveries. Register callbacks
+// before serving; registration is not safe during ServeHTTP.
+type Handler struct {
+	verifier Verifier
+	store    Store
+
+	// MaxBodyBytes defaults to DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+
+	card  []func(context.Context, CardTransactionEvent) error
+	kyc   []func(context.Context, KycStatusEvent) error
+	iban  []func(context.Context, IbanOrderEvent) error
+	event []func(context.Context, Event) error
+}
+
+// NewHandler returns a Handler that authenticates deliveries with v and
+// remembers processed events in store. A nil store keeps event IDs in
+// memory for 24 hours.
+func NewHandler(v Verifier, store Store) *Handler {
+	if store == nil {
+		store = NewMemoryStore(24 * time.Hour)
+	}
+	return &Handler{verifier: v, store: store}
+}
+
+// OnCardTransaction registers fn for card transaction events.
+func (h *Handler) OnCardTransaction(fn func(context.Context, CardTransactionEvent) error) {
+	h.card = append(h.card, fn)
+}
+
+// OnKycStatus registers fn for KYC status changes.
+func (h *Handler) OnKycStatus(fn func(context.Context, KycStatusEvent) error) {
+	h.kyc = append(h.kyc, fn)
+}
+
+// OnIbanOrder registers fn for IBAN order updates.
+func (h *Handler) OnIbanOrder(fn func(context.Context, IbanOrderEvent) error) {
+	h.iban = append(h.iban, fn)
+}
+
+// OnEvent registers fn for every event, including types this package does
+// not know. It runs after the typed callbacks.
+func (h *Handler) OnEvent(fn func(context.Context, Event) error) {
+	h.event = append(h.event, fn)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := h.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "error reading body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.verifier.Verify(r.Header, body); err != nil {
+		slog.Debug("webhook signature rejected", "err", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var ev Event
+	if err := json.Unmarshal(body, &ev); err != nil {
+		http.Error(w, "malformed event", http.StatusBadRequest)
+		return
+	}
+	if ev.ID == "" || ev.Type == "" {
+		http.Error(w, "event id and type are required", http.StatusBadRequest)
+		return
+	}
+	// Decode before claiming: a payload that cannot be decoded will not
+	// improve on retry.
+	dispatch, err := h.decode(ev)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	switch err := h.store.Claim(ctx, ev.ID); {
+	case errors.Is(err, ErrDuplicate):
+		w.WriteHeader(http.StatusOK)
+		return
+	case errors.Is(err, ErrInProgress):
+		http.Error(w, "event is being processed", http.StatusConflict)
+		return
+	case err != nil:
+		slog.Error("webhook store claim failed", "id", ev.ID, "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := dispatch(ctx); err != nil {
+		slog.Error("webhook handler failed", "id", ev.ID, "type", ev.Type, "err", err)
+		if err := h.store.Release(context.WithoutCancel(ctx), ev.ID); err != nil {
+			slog.Error("webhook store release failed", "id", ev.ID, "err", err)
+		}
+		http.Error(w, "handler failed", http.StatusInternalServerError)
+		return
+	}
+	if err := h.store.Complete(context.WithoutCancel(ctx), ev.ID); err != nil {
+		// The callbacks ran; a retry would run them again, so still
+		// acknowledge the delivery.
+		slog.Error("webhook store complete failed", "id", ev.ID, "err", err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// decode parses the event payload and returns a function running the
+// callbacks registered for it.
+func (h *Handler) decode(ev Event) (func(context.Context) error, error) {
+	var typed func(context.Context) error
+	switch ev.Type {
+	case EventCardTransaction:
+		e := CardTransactionEvent{Event: ev}
+		if err := json.Unmarshal(ev.Data, &e.Transaction); err != nil {
+			return nil, fmt.Errorf("decoding %s data: %w", ev.Type, err)
+		}
+		typed = func(ctx context.Context) error { return run(ctx, h.card, e) }
+	case EventKycStatus:
+		var data struct {
+			UserID    string              `json:"userId"`
+			KycStatus gnosispay.KycStatus `json:"kycStatus"`
+		}
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			return nil, fmt.Errorf("decoding %s data: %w", ev.Type, err)
+		}
+		e := KycStatusEvent{Event: ev, UserID: data.UserID, Status: data.KycStatus}
+		typed = func(ctx context.Context) error { return run(ctx, h.kyc, e) }
+	case EventIbanOrder:
+		e := IbanOrderEvent{Event: ev}
+		if err := json.Unmarshal(ev.Data, &e.Order); err != nil {
+			return nil, fmt.Errorf("decoding %s data: %w", ev.Type, err)
+		}
+		typed = func(ctx context.Context) error { return run(ctx, h.iban, e) }
+	}
+
+	return func(ctx context.Context) error {
+		if typed != nil {
+			if err := typed(ctx); err != nil {
+				return err
+			}
+		}
+		return run(ctx, h.event, ev)
+	}, nil
+}
+
+func run[E any](ctx context.Context, fns []func(context.Context, E) error, e E) error {
+	for _, fn := range fns {
+		if err := fn(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarilha/go-gnosispay"
+)
+
+var testSecret = []byte("whsec_test")
+
+func newTestHandler() *Handler {
+	store := NewMemoryStore(time.Hour)
+	return NewHandler(&HMACVerifier{Secret: testSecret}, store)
+}
+
+func deliver(t *testing.T, h http.Handler, body string) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/gnosispay", strings.NewReader(body))
+	req.Header.Set(DefaultSignatureHeader, SignHMAC(testSecret, time.Now(), []byte(body)))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+const (
+	cardDelivery = `{"id":"evt_card","type":"card.transaction","createdAt":"2025-01-01T12:00:00Z",
+		"data":{"kind":"Payment","isPending":true,"mcc":"5411","billingAmount":"1250","merchant":{"name":"REWE"}}}`
+	kycDelivery = `{"id":"evt_kyc","type":"kyc.status_changed","createdAt":"2025-01-01T12:00:00Z",
+		"data":{"userId":"user-1","kycStatus":"approved"}}`
+	ibanDelivery = `{"id":"evt_iban","type":"iban.order","createdAt":"2025-01-01T12:00:00Z",
+		"data":{"id":"order-1","kind":"issue","currency":"eur","amount":"100.5","state":"processed"}}`
+)
+
+func TestHandler_dispatch(t *testing.T) {
+	h := newTestHandler()
+	var card CardTransactionEvent
+	var kyc KycStatusEvent
+	var iban IbanOrderEvent
+	var all []EventType
+	h.OnCardTransaction(func(ctx context.Context, e CardTransactionEvent) error { card = e; return nil })
+	h.OnKycStatus(func(ctx context.Context, e KycStatusEvent) error { kyc = e; return nil })
+	h.OnIbanOrder(func(ctx context.Context, e IbanOrderEvent) error { iban = e; return nil })
+	h.OnEvent(func(ctx context.Context, e Event) error { all = append(all, e.Type); return nil })
+
+	for _, body := range []string{cardDelivery, kycDelivery, ibanDelivery, `{"id":"evt_x","type":"card.shipped","data":{}}`} {
+		if code := deliver(t, h, body); code != http.StatusOK {
+			t.Errorf("delivery status = %d, want 200", code)
+		}
+	}
+
+	if card.ID != "evt_card" || card.Transaction.Merchant == nil || card.Transaction.Merchant.Name != "REWE" || !card.Transaction.IsPending {
+		t.Errorf("card event = %+v", card)
+	}
+	if kyc.UserID != "user-1" || kyc.Status != gnosispay.APPROVED_KycStatus {
+		t.Errorf("kyc event = %+v", kyc)
+	}
+	if m, err := iban.Order.Money(); err != nil || m.String() != "100.50 EUR" {
+		t.Errorf("iban event amount = %v, %v", m, err)
+	}
+	if len(all) != 4 || all[3] != "card.shipped" {
+		t.Errorf("OnEvent saw %v, want all four events including the unknown type", all)
+	}
+}
+
+func TestHandler_retrySemantics(t *testing.T) {
+	h := newTestHandler()
+	calls := 0
+	fail := true
+	h.OnCardTransaction(func(ctx context.Context, e CardTransactionEvent) error {
+		calls++
+		if fail {
+			return errors.New("database unavailable")
+		}
+		return nil
+	})
+
+	if code := deliver(t, h, cardDelivery); code != http.StatusInternalServerError {
+		t.Errorf("failing handler status = %d, want 500", code)
+	}
+	fail = false
+	if code := deliver(t, h, cardDelivery); code != http.StatusOK {
+		t.Errorf("retry status = %d, want 200", code)
+	}
+	if code := deliver(t, h, cardDelivery); code != http.StatusOK {
+		t.Errorf("duplicate status = %d, want 200", code)
+	}
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2 (failure and retry, not the duplicate)", calls)
+	}
+}
+
+func TestHandler_rejects(t *testing.T) {
+	h := newTestHandler()
+	h.MaxBodyBytes = 512
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		sign   bool
+		want   int
+	}{
+		{"wrong method", http.MethodGet, "", true, http.StatusMethodNotAllowed},
+		{"unsigned", http.MethodPost, cardDelivery, false, http.StatusUnauthorized},
+		{"too large", http.MethodPost, `{"id":"` + strings.Repeat("x", 600) + `"}`, true, http.StatusRequestEntityTooLarge},
+		{"malformed json", http.MethodPost, `{"id":`, true, http.StatusBadRequest},
+		{"missing id", http.MethodPost, `{"type":"card.transaction","data":{}}`, true, http.StatusBadRequest},
+		{"bad data", http.MethodPost, `{"id":"evt_1","type":"card.transaction","data":{"isPending":"yes"}}`, true, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+			if tt.sign {
+				req.Header.Set(DefaultSignatureHeader, SignHMAC(testSecret, time.Now(), []byte(tt.body)))
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := testNow
+	s := NewMemoryStore(time.Hour)
+	s.now = func() time.Time { return now }
+
+	if err := s.Claim(ctx, "a"); err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	if err := s.Claim(ctx, "a"); !errors.Is(err, ErrInProgress) {
+		t.Errorf("second Claim() error = %v, want ErrInProgress", err)
+	}
+	now = now.Add(s.Lease)
+	if err := s.Claim(ctx, "a"); err != nil {
+		t.Errorf("Claim() after lease expiry error = %v", err)
+	}
+	s.Complete(ctx, "a")
+	s.Release(ctx, "a")
+	if err := s.Claim(ctx, "a"); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Claim() after Complete error = %v, want ErrDuplicate", err)
+	}
+	now = now.Add(time.Hour)
+	if err := s.Claim(ctx, "a"); err != nil {
+		t.Errorf("Claim() after TTL error = %v", err)
+	}
+}